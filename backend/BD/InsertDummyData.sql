@@ -13,8 +13,8 @@ USE quovi_db;
 -- =============================================
 -- 1. INSERTAR CIUDADES
 -- =============================================
-INSERT INTO ciudades (nombreCiudad, estado, pais, latitud, longitud) VALUES
-('Ciudad de México', 'CDMX', 'México', 19.432608, -99.133209);
+INSERT INTO ciudades (nombreCiudad, estado, pais, latitud, longitud, zonaHoraria) VALUES
+('Ciudad de México', 'CDMX', 'México', 19.432608, -99.133209, 'America/Mexico_City');
 
 SET @idCDMX = LAST_INSERT_ID();
 
@@ -22,12 +22,14 @@ CREATE TABLE IF NOT EXISTS usuarios (
     googleId VARCHAR(255) UNIQUE,
     provider VARCHAR(20) DEFAULT 'local',
     emailVerificado BOOLEAN DEFAULT FALSE,
+    rol VARCHAR(20) DEFAULT 'usuario',
     
     fechaRegistro TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     ultimoAcceso TIMESTAMP NULL,
     activo BOOLEAN DEFAULT TRUE,
     
     CONSTRAINT chk_provider CHECK (provider IN ('local', 'google', 'facebook', 'apple')),
+    CONSTRAINT chk_rol CHECK (rol IN ('usuario', 'admin')),
     CONSTRAINT chk_auth_method CHECK (
         (provider = 'local' AND password IS NOT NULL) OR 
         (provider != 'local' AND googleId IS NOT NULL)
@@ -60,6 +62,8 @@ CREATE TABLE IF NOT EXISTS ciudades (
     pais VARCHAR(50) DEFAULT 'México',
     latitud DECIMAL(10,8),
     longitud DECIMAL(11,8),
+    -- Zona IANA en la que se interpretan los horarios de sus restaurantes
+    zonaHoraria VARCHAR(64) NOT NULL DEFAULT 'America/Mexico_City',
     UNIQUE KEY unique_ciudad (nombreCiudad, estado)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
@@ -81,6 +85,9 @@ CREATE TABLE IF NOT EXISTS restaurantes (
     totalResenas INT DEFAULT 0,
     activo BOOLEAN DEFAULT TRUE,
     fechaRegistro TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    fechaActualizacion TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    -- Punto (longitud, latitud) derivado de las coordenadas para el indice espacial
+    ubicacion POINT AS (POINT(longitud, latitud)) STORED NOT NULL SRID 0,
     FOREIGN KEY (idCiudad) REFERENCES ciudades(idCiudad) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
@@ -117,6 +124,38 @@ CREATE TABLE IF NOT EXISTS platillos (
     imagen VARCHAR(500),
     disponible BOOLEAN DEFAULT TRUE,
     destacado BOOLEAN DEFAULT FALSE,
+    -- TRUE si el propietario asigno etiquetas; los alergenos se siguen infiriendo de los ingredientes
+    etiquetasManuales BOOLEAN DEFAULT FALSE,
+    fechaActualizacion TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (idRestaurante) REFERENCES restaurantes(idRestaurante) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: platillo_etiquetas (dieta y alergenos de cada platillo)
+-- =============================================
+CREATE TABLE IF NOT EXISTS platillo_etiquetas (
+    idPlatillo INT NOT NULL,
+    etiqueta VARCHAR(30) NOT NULL,
+    inferida BOOLEAN NOT NULL DEFAULT TRUE,
+    PRIMARY KEY (idPlatillo, etiqueta),
+    FOREIGN KEY (idPlatillo) REFERENCES platillos(idPlatillo) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: precios_restaurante (estadisticas de precio del menu, se recalculan desde platillos)
+-- =============================================
+CREATE TABLE IF NOT EXISTS precios_restaurante (
+    idRestaurante INT PRIMARY KEY,
+    totalPlatillos INT NOT NULL DEFAULT 0,
+    precioMinimo DECIMAL(8,2) NOT NULL,
+    precioMaximo DECIMAL(8,2) NOT NULL,
+    precioMediano DECIMAL(8,2) NOT NULL,
+    -- Platos fuertes: se excluyen bebidas, postres y extras; NULL si no se identifico ninguno
+    precioMinimoFuerte DECIMAL(8,2),
+    precioMedianoFuerte DECIMAL(8,2),
+    -- Precio que define la banda ($, $$, ...): mediana de platos fuertes o, sin ellos, de todo el menu
+    precioReferencia DECIMAL(8,2) NOT NULL,
+    fechaCalculo TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (idRestaurante) REFERENCES restaurantes(idRestaurante) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
@@ -143,15 +182,36 @@ CREATE TABLE IF NOT EXISTS restaurante_caracteristicas (
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- =============================================
--- TABLA: horarios
+-- TABLA: horarios (uno o varios intervalos por dia, en hora local de la ciudad)
 -- =============================================
 CREATE TABLE IF NOT EXISTS horarios (
+    idHorario INT AUTO_INCREMENT PRIMARY KEY,
     idRestaurante INT NOT NULL,
     dia TINYINT NOT NULL CHECK (dia >= 1 AND dia <= 7),
     apertura TIME NOT NULL,
+    -- Un cierre menor o igual a la apertura termina al dia siguiente, como 20:00 a 02:00
     cierre TIME NOT NULL,
     cerrado BOOLEAN DEFAULT FALSE,
-    PRIMARY KEY (idRestaurante, dia),
+    UNIQUE KEY unique_horario (idRestaurante, dia, apertura),
+    FOREIGN KEY (idRestaurante) REFERENCES restaurantes(idRestaurante) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: excepciones_horario (festivos, cierres y horarios especiales por fecha)
+-- =============================================
+CREATE TABLE IF NOT EXISTS excepciones_horario (
+    idExcepcion INT AUTO_INCREMENT PRIMARY KEY,
+    idRestaurante INT NOT NULL,
+    fechaInicio DATE NOT NULL,
+    fechaFin DATE NOT NULL,
+    -- TRUE cierra todo el dia; FALSE usa apertura y cierre en lugar del horario semanal
+    cerrado BOOLEAN NOT NULL DEFAULT TRUE,
+    apertura TIME,
+    cierre TIME,
+    -- TRUE repite el rango cada año, como el 16 de septiembre o del 24 de diciembre al 1 de enero
+    recurrenteAnual BOOLEAN NOT NULL DEFAULT FALSE,
+    motivo VARCHAR(100),
+    fechaCreacion TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (idRestaurante) REFERENCES restaurantes(idRestaurante) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
@@ -163,9 +223,11 @@ CREATE TABLE IF NOT EXISTS busquedas (
     idUsuario INT,
     texto TEXT NOT NULL,
     contexto VARCHAR(100),
+    idCiudad INT,
     resultadosEncontrados INT,
     fecha TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE SET NULL
+    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE SET NULL,
+    FOREIGN KEY (idCiudad) REFERENCES ciudades(idCiudad) ON DELETE SET NULL
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- =============================================
@@ -179,9 +241,48 @@ CREATE TABLE IF NOT EXISTS resenas (
     comentario TEXT,
     fecha TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     verificada BOOLEAN DEFAULT FALSE,
+    estado VARCHAR(20) NOT NULL DEFAULT 'publicada',
+    votosUtiles INT DEFAULT 0,
+    votosNoUtiles INT DEFAULT 0,
+    puntuacionUtilidad DECIMAL(6,5) DEFAULT 0,
     FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE CASCADE,
     FOREIGN KEY (idRestaurante) REFERENCES restaurantes(idRestaurante) ON DELETE CASCADE,
-    UNIQUE KEY unique_review (idUsuario, idRestaurante)
+    UNIQUE KEY unique_review (idUsuario, idRestaurante),
+    CONSTRAINT chk_resena_estado CHECK (estado IN ('pendiente', 'publicada', 'oculta', 'eliminada'))
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: votos_resena (utilidad de reseñas)
+-- =============================================
+CREATE TABLE IF NOT EXISTS votos_resena (
+    idResena INT NOT NULL,
+    idUsuario INT NOT NULL,
+    util BOOLEAN NOT NULL,
+    fecha TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (idResena, idUsuario),
+    FOREIGN KEY (idResena) REFERENCES resenas(idResena) ON DELETE CASCADE,
+    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: reportes_resena (moderación)
+-- =============================================
+CREATE TABLE IF NOT EXISTS reportes_resena (
+    idReporte INT AUTO_INCREMENT PRIMARY KEY,
+    idResena INT NOT NULL,
+    idUsuario INT NOT NULL,
+    motivo VARCHAR(20) NOT NULL,
+    descripcion VARCHAR(500),
+    estado VARCHAR(20) NOT NULL DEFAULT 'pendiente',
+    fecha TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    idModerador INT NULL,
+    fechaResolucion TIMESTAMP NULL,
+    FOREIGN KEY (idResena) REFERENCES resenas(idResena) ON DELETE CASCADE,
+    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE CASCADE,
+    FOREIGN KEY (idModerador) REFERENCES usuarios(idUsuario) ON DELETE SET NULL,
+    UNIQUE KEY unique_reporte (idResena, idUsuario),
+    CONSTRAINT chk_reporte_motivo CHECK (motivo IN ('spam', 'ofensivo', 'falso', 'irrelevante', 'otro')),
+    CONSTRAINT chk_reporte_estado CHECK (estado IN ('pendiente', 'resuelto', 'descartado'))
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
 -- =============================================
@@ -206,6 +307,95 @@ CREATE TABLE IF NOT EXISTS imagenes_restaurante (
     esPrincipal BOOLEAN DEFAULT FALSE,
     orden INT DEFAULT 0,
     fechaSubida TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    idUsuarioAutor INT NULL,
+    credito VARCHAR(150),
+    idImagenResena INT NULL,
+    FOREIGN KEY (idRestaurante) REFERENCES restaurantes(idRestaurante) ON DELETE CASCADE,
+    FOREIGN KEY (idUsuarioAutor) REFERENCES usuarios(idUsuario) ON DELETE SET NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: imagenes_resena (fotos adjuntas a reseñas)
+-- =============================================
+CREATE TABLE IF NOT EXISTS imagenes_resena (
+    idImagen INT AUTO_INCREMENT PRIMARY KEY,
+    idResena INT NOT NULL,
+    idUsuario INT NOT NULL,
+    url VARCHAR(500) NOT NULL,
+    aprobaciones INT DEFAULT 0,
+    promovida BOOLEAN DEFAULT FALSE,
+    fechaSubida TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (idResena) REFERENCES resenas(idResena) ON DELETE CASCADE,
+    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: aprobaciones_imagen_resena
+-- =============================================
+CREATE TABLE IF NOT EXISTS aprobaciones_imagen_resena (
+    idImagen INT NOT NULL,
+    idUsuario INT NOT NULL,
+    fecha TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (idImagen, idUsuario),
+    FOREIGN KEY (idImagen) REFERENCES imagenes_resena(idImagen) ON DELETE CASCADE,
+    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: propietarios_restaurante
+-- =============================================
+CREATE TABLE IF NOT EXISTS propietarios_restaurante (
+    idRestaurante INT NOT NULL,
+    idUsuario INT NOT NULL,
+    fecha TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (idRestaurante, idUsuario),
+    FOREIGN KEY (idRestaurante) REFERENCES restaurantes(idRestaurante) ON DELETE CASCADE,
+    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: respuestas_resena (respuesta del propietario)
+-- =============================================
+CREATE TABLE IF NOT EXISTS respuestas_resena (
+    idRespuesta INT AUTO_INCREMENT PRIMARY KEY,
+    idResena INT NOT NULL UNIQUE,
+    idUsuario INT NOT NULL,
+    comentario TEXT NOT NULL,
+    fecha TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    fechaEdicion TIMESTAMP NULL,
+    FOREIGN KEY (idResena) REFERENCES resenas(idResena) ON DELETE CASCADE,
+    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: notificaciones
+-- =============================================
+CREATE TABLE IF NOT EXISTS notificaciones (
+    idNotificacion INT AUTO_INCREMENT PRIMARY KEY,
+    idUsuario INT NOT NULL,
+    tipo VARCHAR(30) NOT NULL,
+    mensaje VARCHAR(500) NOT NULL,
+    idRestaurante INT NULL,
+    idResena INT NULL,
+    leida BOOLEAN DEFAULT FALSE,
+    fecha TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE CASCADE,
+    FOREIGN KEY (idRestaurante) REFERENCES restaurantes(idRestaurante) ON DELETE CASCADE,
+    FOREIGN KEY (idResena) REFERENCES resenas(idResena) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- =============================================
+-- TABLA: visitas (check-ins geolocalizados)
+-- =============================================
+CREATE TABLE IF NOT EXISTS visitas (
+    idVisita INT AUTO_INCREMENT PRIMARY KEY,
+    idUsuario INT NOT NULL,
+    idRestaurante INT NOT NULL,
+    latitud DECIMAL(10,8) NOT NULL,
+    longitud DECIMAL(11,8) NOT NULL,
+    distanciaMetros DECIMAL(8,2) NOT NULL,
+    fecha TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (idUsuario) REFERENCES usuarios(idUsuario) ON DELETE CASCADE,
     FOREIGN KEY (idRestaurante) REFERENCES restaurantes(idRestaurante) ON DELETE CASCADE
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
 
@@ -226,77 +416,55 @@ CREATE INDEX idx_sesiones_expira ON sesiones(expiraEn);
 CREATE INDEX idx_restaurantes_ciudad ON restaurantes(idCiudad);
 CREATE INDEX idx_restaurantes_calificacion ON restaurantes(calificacionPromedio);
 CREATE INDEX idx_restaurantes_activo ON restaurantes(activo);
+CREATE INDEX idx_restaurantes_precio ON restaurantes(precioPromedio);
+CREATE SPATIAL INDEX idx_restaurantes_ubicacion ON restaurantes(ubicacion);
+CREATE INDEX idx_restaurantes_actualizacion ON restaurantes(fechaActualizacion);
+
+-- Horarios
+CREATE INDEX idx_excepciones_horario_fechas ON excepciones_horario(idRestaurante, fechaFin);
 
 -- Platillos
 CREATE INDEX idx_platillos_restaurante ON platillos(idRestaurante);
 CREATE INDEX idx_platillos_disponible ON platillos(disponible);
+CREATE INDEX idx_platillos_actualizacion ON platillos(fechaActualizacion);
+CREATE INDEX idx_platillo_etiquetas_etiqueta ON platillo_etiquetas(etiqueta, idPlatillo);
+CREATE INDEX idx_precios_restaurante_referencia ON precios_restaurante(precioReferencia);
+CREATE INDEX idx_precios_restaurante_fuerte ON precios_restaurante(precioMinimoFuerte);
 
 -- Búsquedas
 CREATE INDEX idx_busquedas_usuario ON busquedas(idUsuario);
 CREATE INDEX idx_busquedas_fecha ON busquedas(fecha);
+CREATE INDEX idx_busquedas_ciudad_fecha ON busquedas(idCiudad, fecha);
 
 -- Resenas
 CREATE INDEX idx_resenas_restaurante ON resenas(idRestaurante);
 CREATE INDEX idx_resenas_usuario ON resenas(idUsuario);
 CREATE INDEX idx_resenas_calificacion ON resenas(calificacion);
+CREATE INDEX idx_resenas_verificada ON resenas(idRestaurante, verificada);
+CREATE INDEX idx_resenas_estado ON resenas(estado);
+CREATE INDEX idx_resenas_utilidad ON resenas(idRestaurante, puntuacionUtilidad);
+
+-- Reportes de reseñas
+CREATE INDEX idx_reportes_resena_estado ON reportes_resena(idResena, estado);
+
+-- Imagenes de reseñas
+CREATE INDEX idx_imagenes_resena_resena ON imagenes_resena(idResena);
+
+-- Propietarios
+CREATE INDEX idx_propietarios_usuario ON propietarios_restaurante(idUsuario);
+
+-- Notificaciones
+CREATE INDEX idx_notificaciones_usuario ON notificaciones(idUsuario, leida, fecha);
+
+-- Visitas
+CREATE INDEX idx_visitas_usuario_restaurante ON visitas(idUsuario, idRestaurante, fecha);
 
 -- Favoritos
 CREATE INDEX idx_favoritos_fecha ON favoritos(fecha);
 
 -- =============================================
--- TRIGGERS para mantener integridad
+-- ESTADÍSTICAS DE RESEÑAS
 -- =============================================
-
--- Actualizar calificación promedio cuando se crea/actualiza una resena
-DELIMITER $$
-
-CREATE TRIGGER after_resena_insert
-AFTER INSERT ON resenas
-FOR EACH ROW
-BEGIN
-    UPDATE restaurantes
-    SET calificacionPromedio = (
-        SELECT AVG(calificacion)
-        FROM resenas
-        WHERE idRestaurante = NEW.idRestaurante
-    ),
-    totalResenas = (
-        SELECT COUNT(*)
-        FROM resenas
-        WHERE idRestaurante = NEW.idRestaurante
-    )
-    WHERE idRestaurante = NEW.idRestaurante;
-END$$
-
-CREATE TRIGGER after_resena_update
-AFTER UPDATE ON resenas
-FOR EACH ROW
-BEGIN
-    UPDATE restaurantes
-    SET calificacionPromedio = (
-        SELECT AVG(calificacion)
-        FROM resenas
-        WHERE idRestaurante = NEW.idRestaurante
-    )
-    WHERE idRestaurante = NEW.idRestaurante;
-END$$
-
-CREATE TRIGGER after_resena_delete
-AFTER DELETE ON resenas
-FOR EACH ROW
-BEGIN
-    UPDATE restaurantes
-    SET calificacionPromedio = (
-        SELECT COALESCE(AVG(calificacion), 0)
-        FROM resenas
-        WHERE idRestaurante = OLD.idRestaurante
-    ),
-    totalResenas = (
-        SELECT COUNT(*)
-        FROM resenas
-        WHERE idRestaurante = OLD.idRestaurante
-    )
-    WHERE idRestaurante = OLD.idRestaurante;
-END$$
-
-DELIMITER ;
\ No newline at end of file
+-- calificacionPromedio y totalResenas se recalculan desde el backend
+-- (ReseñaService) dentro de la misma transacción que crea, edita o
+-- elimina la reseña, por lo que no se usan triggers.
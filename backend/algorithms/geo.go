@@ -0,0 +1,89 @@
+package algorithms
+
+import "math"
+
+// RadioTierraKm es el radio medio de la Tierra usado en todos los calculos de distancia
+const RadioTierraKm = 6371.0
+
+// CajaEnvolvente calcula el rectangulo de coordenadas que contiene todo el circulo de radioKm
+// alrededor del punto. A diferencia de un factor fijo por grado de longitud, el ancho en longitud
+// depende de la latitud; si el circulo toca un polo o cruza el antimeridiano se usa toda la longitud
+func CajaEnvolvente(lat, lng, radioKm float64) (minLat, minLng, maxLat, maxLng float64) {
+	angulo := radioKm / RadioTierraKm
+	latRad := lat * math.Pi / 180
+
+	minLatRad := latRad - angulo
+	maxLatRad := latRad + angulo
+
+	minLat = minLatRad * 180 / math.Pi
+	maxLat = maxLatRad * 180 / math.Pi
+	minLng, maxLng = -180, 180
+
+	if minLatRad > -math.Pi/2 && maxLatRad < math.Pi/2 {
+		deltaLng := math.Asin(math.Sin(angulo)/math.Cos(latRad)) * 180 / math.Pi
+		if lng-deltaLng >= -180 && lng+deltaLng <= 180 {
+			minLng, maxLng = lng-deltaLng, lng+deltaLng
+		}
+	}
+
+	return math.Max(minLat, -90), minLng, math.Min(maxLat, 90), maxLng
+}
+
+// Coordenada representa una ubicacion geografica
+type Coordenada struct {
+	Latitud  float64 `json:"latitud"`
+	Longitud float64 `json:"longitud"`
+}
+
+// DistanciaHaversine calcula la distancia en linea recta entre dos coordenadas en kilometros
+func DistanciaHaversine(a, b Coordenada) float64 {
+	lat1 := a.Latitud * math.Pi / 180
+	lat2 := b.Latitud * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Longitud - a.Longitud) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * RadioTierraKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// MatrizDistancias arma la matriz de distancias Haversine entre el origen (indice 0) y las paradas (1..n)
+func MatrizDistancias(origen Coordenada, paradas []Coordenada) [][]float64 {
+	puntos := append([]Coordenada{origen}, paradas...)
+	matriz := make([][]float64, len(puntos))
+	for i := range puntos {
+		matriz[i] = make([]float64, len(puntos))
+		for j := range puntos {
+			if i != j {
+				matriz[i][j] = DistanciaHaversine(puntos[i], puntos[j])
+			}
+		}
+	}
+	return matriz
+}
+
+// CodificarPolilinea codifica los puntos con el algoritmo de polilineas de Google (precision 1e-5),
+// el formato que leen directamente los mapas del frontend
+func CodificarPolilinea(puntos []Coordenada) string {
+	var salida []byte
+	codificar := func(valor int64) {
+		valor <<= 1
+		if valor < 0 {
+			valor = ^valor
+		}
+		for valor >= 0x20 {
+			salida = append(salida, byte((0x20|(valor&0x1f))+63))
+			valor >>= 5
+		}
+		salida = append(salida, byte(valor+63))
+	}
+
+	var latAnterior, lngAnterior int64
+	for _, punto := range puntos {
+		lat := int64(math.Round(punto.Latitud * 1e5))
+		lng := int64(math.Round(punto.Longitud * 1e5))
+		codificar(lat - latAnterior)
+		codificar(lng - lngAnterior)
+		latAnterior, lngAnterior = lat, lng
+	}
+	return string(salida)
+}
@@ -0,0 +1,339 @@
+package algorithms
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/tuusuario/quovi/utils"
+)
+
+// Similitud asignada segun como coincide un termino de la consulta con el vocabulario
+const (
+	similitudExacta     = 1.0
+	similitudPrefijo    = 0.8
+	similitudDistancia1 = 0.7
+	similitudDistancia2 = 0.45
+	longitudMinPrefijo  = 3
+)
+
+// palabrasVacias no aportan relevancia y se ignoran al indexar y al buscar
+var palabrasVacias = map[string]bool{
+	"de": true, "del": true, "la": true, "las": true, "el": true, "los": true,
+	"y": true, "e": true, "en": true, "al": true, "a": true, "con": true,
+	"un": true, "una": true, "por": true, "para": true, "o": true,
+	"the": true, "and": true, "of": true,
+}
+
+// CampoDocumento es un texto del documento con el peso que aporta a la relevancia
+type CampoDocumento struct {
+	Texto string
+	Peso  float64
+}
+
+// DocumentoBusqueda es la unidad indexable, normalmente un restaurante
+type DocumentoBusqueda struct {
+	ID     uint
+	Campos []CampoDocumento
+}
+
+// ResultadoIndice es un documento encontrado con su puntuacion
+type ResultadoIndice struct {
+	ID            uint    `json:"id"`
+	Puntuacion    float64 `json:"puntuacion"`
+	Coincidencias int     `json:"coincidencias"` // Terminos de la consulta que encontraron el documento
+}
+
+// IndiceBusqueda es un indice invertido en memoria, insensible a acentos y tolerante a errores de escritura
+// Es seguro para uso concurrente; las escrituras bloquean brevemente las lecturas
+type IndiceBusqueda struct {
+	mu sync.RWMutex
+
+	// termino -> documento -> mayor peso de campo en el que aparece
+	postings map[string]map[uint]float64
+
+	// documento -> terminos indexados, para poder retirarlo
+	terminosDocumento map[uint][]string
+}
+
+// NewIndiceBusqueda crea un indice vacio
+func NewIndiceBusqueda() *IndiceBusqueda {
+	return &IndiceBusqueda{
+		postings:          make(map[string]map[uint]float64),
+		terminosDocumento: make(map[uint][]string),
+	}
+}
+
+// Total retorna el numero de documentos indexados
+func (ib *IndiceBusqueda) Total() int {
+	ib.mu.RLock()
+	defer ib.mu.RUnlock()
+	return len(ib.terminosDocumento)
+}
+
+// Indexar agrega o reemplaza un documento
+func (ib *IndiceBusqueda) Indexar(doc DocumentoBusqueda) {
+	pesos := pesosTerminos(doc)
+
+	ib.mu.Lock()
+	defer ib.mu.Unlock()
+
+	ib.eliminarSinBloqueo(doc.ID)
+	ib.agregarSinBloqueo(doc.ID, pesos)
+}
+
+// Eliminar retira un documento del indice
+func (ib *IndiceBusqueda) Eliminar(id uint) {
+	ib.mu.Lock()
+	defer ib.mu.Unlock()
+
+	ib.eliminarSinBloqueo(id)
+}
+
+// Reemplazar sustituye todo el contenido del indice por los documentos dados
+// El nuevo indice se arma fuera del candado para no bloquear busquedas durante la reconstruccion
+func (ib *IndiceBusqueda) Reemplazar(docs []DocumentoBusqueda) {
+	nuevo := NewIndiceBusqueda()
+	for _, doc := range docs {
+		nuevo.agregarSinBloqueo(doc.ID, pesosTerminos(doc))
+	}
+
+	ib.mu.Lock()
+	defer ib.mu.Unlock()
+
+	ib.postings = nuevo.postings
+	ib.terminosDocumento = nuevo.terminosDocumento
+}
+
+// Buscar retorna los documentos que coinciden con la consulta ordenados por relevancia
+// Un documento debe coincidir con al menos la mitad de los terminos; limite <= 0 no limita
+func (ib *IndiceBusqueda) Buscar(consulta string, limite int) []ResultadoIndice {
+	terminos := tokenizarSinVacias(consulta)
+	if len(terminos) == 0 {
+		return []ResultadoIndice{}
+	}
+
+	ib.mu.RLock()
+	defer ib.mu.RUnlock()
+
+	totalDocs := float64(len(ib.terminosDocumento))
+	puntuaciones := make(map[uint]float64)
+	coincidencias := make(map[uint]int)
+
+	for _, termino := range terminos {
+		// Mejor puntuacion de este termino de consulta en cada documento
+		mejores := make(map[uint]float64)
+
+		for candidato, similitud := range ib.candidatosSinBloqueo(termino) {
+			documentos := ib.postings[candidato]
+			idf := math.Log(1 + totalDocs/float64(len(documentos)))
+			for id, peso := range documentos {
+				if puntuacion := peso * idf * similitud; puntuacion > mejores[id] {
+					mejores[id] = puntuacion
+				}
+			}
+		}
+
+		for id, puntuacion := range mejores {
+			puntuaciones[id] += puntuacion
+			coincidencias[id]++
+		}
+	}
+
+	minimo := (len(terminos) + 1) / 2
+	resultados := make([]ResultadoIndice, 0, len(puntuaciones))
+	for id, puntuacion := range puntuaciones {
+		if coincidencias[id] < minimo {
+			continue
+		}
+		resultados = append(resultados, ResultadoIndice{
+			ID:            id,
+			Puntuacion:    math.Round(puntuacion*1000) / 1000,
+			Coincidencias: coincidencias[id],
+		})
+	}
+
+	sort.Slice(resultados, func(i, j int) bool {
+		if resultados[i].Coincidencias != resultados[j].Coincidencias {
+			return resultados[i].Coincidencias > resultados[j].Coincidencias
+		}
+		if resultados[i].Puntuacion != resultados[j].Puntuacion {
+			return resultados[i].Puntuacion > resultados[j].Puntuacion
+		}
+		return resultados[i].ID < resultados[j].ID
+	})
+
+	if limite > 0 && len(resultados) > limite {
+		resultados = resultados[:limite]
+	}
+
+	return resultados
+}
+
+// candidatosSinBloqueo busca en el vocabulario los terminos parecidos a uno de la consulta
+// Coincide exacto, por prefijo o por distancia de edicion segun la longitud del termino
+func (ib *IndiceBusqueda) candidatosSinBloqueo(termino string) map[string]float64 {
+	candidatos := make(map[string]float64)
+
+	if _, ok := ib.postings[termino]; ok {
+		candidatos[termino] = similitudExacta
+	}
+
+	longitud := utf8.RuneCountInString(termino)
+	maxDistancia := distanciaPermitida(longitud)
+
+	for vocablo := range ib.postings {
+		if vocablo == termino {
+			continue
+		}
+
+		similitud := 0.0
+		if longitud >= longitudMinPrefijo && strings.HasPrefix(vocablo, termino) {
+			similitud = similitudPrefijo
+		}
+
+		if maxDistancia > 0 && similitud < similitudDistancia1 {
+			diferencia := utf8.RuneCountInString(vocablo) - longitud
+			if diferencia <= maxDistancia && diferencia >= -maxDistancia {
+				switch distancia := DistanciaEdicion(termino, vocablo, maxDistancia); {
+				case distancia == 1:
+					similitud = similitudDistancia1
+				case distancia == 2 && maxDistancia >= 2:
+					similitud = math.Max(similitud, similitudDistancia2)
+				}
+			}
+		}
+
+		if similitud > 0 {
+			candidatos[vocablo] = similitud
+		}
+	}
+
+	return candidatos
+}
+
+func (ib *IndiceBusqueda) agregarSinBloqueo(id uint, pesos map[string]float64) {
+	terminos := make([]string, 0, len(pesos))
+	for termino, peso := range pesos {
+		documentos, ok := ib.postings[termino]
+		if !ok {
+			documentos = make(map[uint]float64)
+			ib.postings[termino] = documentos
+		}
+		documentos[id] = peso
+		terminos = append(terminos, termino)
+	}
+	ib.terminosDocumento[id] = terminos
+}
+
+func (ib *IndiceBusqueda) eliminarSinBloqueo(id uint) {
+	for _, termino := range ib.terminosDocumento[id] {
+		documentos := ib.postings[termino]
+		delete(documentos, id)
+		if len(documentos) == 0 {
+			delete(ib.postings, termino)
+		}
+	}
+	delete(ib.terminosDocumento, id)
+}
+
+// pesosTerminos obtiene cada termino del documento con el mayor peso de los campos donde aparece
+func pesosTerminos(doc DocumentoBusqueda) map[string]float64 {
+	pesos := make(map[string]float64)
+	for _, campo := range doc.Campos {
+		for _, termino := range tokenizarSinVacias(campo.Texto) {
+			if campo.Peso > pesos[termino] {
+				pesos[termino] = campo.Peso
+			}
+		}
+	}
+	return pesos
+}
+
+func tokenizarSinVacias(texto string) []string {
+	tokens := utils.Tokenizar(texto)
+	terminos := tokens[:0]
+	for _, token := range tokens {
+		if !palabrasVacias[token] {
+			terminos = append(terminos, token)
+		}
+	}
+	return terminos
+}
+
+// distanciaPermitida define cuantos errores se toleran segun la longitud del termino
+func distanciaPermitida(longitud int) int {
+	switch {
+	case longitud <= 3:
+		return 0
+	case longitud <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// DistanciaEdicion calcula la distancia de Damerau-Levenshtein (alineamiento optimo) entre a y b
+// Si la distancia supera max retorna max+1 sin terminar el calculo
+func DistanciaEdicion(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	n, m := len(ra), len(rb)
+
+	if diferencia := n - m; diferencia > max || -diferencia > max {
+		return max + 1
+	}
+
+	// Tres filas: la anterior a la anterior (para transposiciones), la anterior y la actual
+	previaPrevia := make([]int, m+1)
+	previa := make([]int, m+1)
+	actual := make([]int, m+1)
+	for j := 0; j <= m; j++ {
+		previa[j] = j
+	}
+
+	for i := 1; i <= n; i++ {
+		actual[0] = i
+		minimoFila := actual[0]
+
+		for j := 1; j <= m; j++ {
+			costo := 1
+			if ra[i-1] == rb[j-1] {
+				costo = 0
+			}
+
+			actual[j] = minimo3(previa[j]+1, actual[j-1]+1, previa[j-1]+costo)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				if transposicion := previaPrevia[j-2] + 1; transposicion < actual[j] {
+					actual[j] = transposicion
+				}
+			}
+
+			if actual[j] < minimoFila {
+				minimoFila = actual[j]
+			}
+		}
+
+		if minimoFila > max {
+			return max + 1
+		}
+
+		previaPrevia, previa, actual = previa, actual, previaPrevia
+	}
+
+	if previa[m] > max {
+		return max + 1
+	}
+	return previa[m]
+}
+
+func minimo3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
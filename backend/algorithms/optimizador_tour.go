@@ -0,0 +1,522 @@
+package algorithms
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Metodos con los que se puede resolver un tour
+const (
+	MetodoHeldKarp   = "held-karp"
+	MetodoHeuristico = "vecino-mas-cercano+2-opt+or-opt"
+)
+
+// MaxParadasExacto es el numero de paradas hasta el que se usa Held-Karp; con 15 la tabla ocupa unos 4 MB
+// y cada parada adicional la duplica, por eso la configuracion no puede subirlo
+const MaxParadasExacto = 15
+
+// PresupuestoHeuristicoPorDefecto es el tiempo de mejora local cuando no se configura otro
+const PresupuestoHeuristicoPorDefecto = 200 * time.Millisecond
+
+// Iteraciones del calculo de la cota inferior por subgradiente
+const iteracionesCotaInferior = 150
+
+const epsilonMejora = 1e-9
+
+// OpcionesOptimizacion ajustan como se resuelve un tour
+type OpcionesOptimizacion struct {
+	MaxParadasExacto int             // Solo puede reducir el limite; 0 usa MaxParadasExacto
+	Presupuesto      time.Duration   // Tiempo maximo de la mejora local; 0 usa el valor por defecto
+	Ventanas         *VentanasTiempo // Horarios de las paradas; nil no calcula agenda ni restringe
+	// CostosFinal es el costo de terminar la ruta en cada nodo: el regreso al origen o el trayecto
+	// a un destino fijo. nil deja la ruta abierta en la ultima parada
+	CostosFinal []float64
+	Primera     int // Parada que debe visitarse primero; 0 la deja libre
+	Ultima      int // Parada que debe visitarse al final; 0 la deja libre
+}
+
+// ResultadoTour es el orden de visita encontrado para una matriz de costos
+type ResultadoTour struct {
+	Orden        []int   // Indices de las paradas en la matriz (1..n) en orden de visita
+	Costo        float64 // Costo de la ruta desde el origen, incluido el tramo final si lo hay
+	CotaInferior float64 // Ninguna ruta por las mismas paradas puede costar menos; igual al costo si es exacta
+	Metodo       string
+	Exacto       bool
+	Agenda       []Visita // Horario de cada parada del orden; solo con ventanas de tiempo
+	Omitidas     []int    // Paradas que no caben en su horario; no forman parte del orden
+}
+
+// Brecha es la diferencia relativa maxima contra el optimo, (costo - cota) / cota
+func (r ResultadoTour) Brecha() float64 {
+	if r.Exacto || r.CotaInferior <= 0 || r.Costo <= r.CotaInferior {
+		return 0
+	}
+	return (r.Costo - r.CotaInferior) / r.CotaInferior
+}
+
+// problemaTour reune la matriz de costos con el tramo final y las paradas fijas
+type problemaTour struct {
+	costos  [][]float64
+	final   []float64 // Costo de cerrar la ruta desde cada nodo; ceros si es abierta
+	primera int
+	ultima  int
+}
+
+// OptimizarTour ordena las paradas para minimizar el costo de una ruta que sale del origen.
+// costos[i][j] es el costo de ir de i a j; el indice 0 es el origen y 1..n las paradas.
+// Hasta MaxParadasExacto paradas el resultado es optimo (Held-Karp); por encima se usa vecino mas cercano
+// mejorado con 2-opt y Or-opt mientras dure el presupuesto, y se reporta una cota inferior para la brecha.
+// Con ventanas de tiempo primero se programan tantas paradas como sea posible y luego se minimiza el costo;
+// una parada fija que no cabe en su horario se omite y el resto se ordena libremente
+func OptimizarTour(costos [][]float64, opciones OpcionesOptimizacion) (ResultadoTour, error) {
+	n := len(costos) - 1
+	if n < 1 {
+		return ResultadoTour{}, errors.New("se requiere al menos una parada")
+	}
+	for _, fila := range costos {
+		if len(fila) != len(costos) {
+			return ResultadoTour{}, errors.New("la matriz de costos debe ser cuadrada")
+		}
+	}
+
+	p := &problemaTour{costos: costos, final: opciones.CostosFinal, primera: opciones.Primera, ultima: opciones.Ultima}
+	if p.final == nil {
+		p.final = make([]float64, len(costos))
+	}
+	if len(p.final) != len(costos) {
+		return ResultadoTour{}, errors.New("los costos finales no corresponden con la matriz de costos")
+	}
+	if p.primera < 0 || p.primera > n || p.ultima < 0 || p.ultima > n {
+		return ResultadoTour{}, errors.New("parada fija fuera de la matriz de costos")
+	}
+	if p.primera != 0 && p.primera == p.ultima && n > 1 {
+		return ResultadoTour{}, errors.New("una parada no puede ser la primera y la ultima a la vez")
+	}
+
+	limiteExacto := opciones.MaxParadasExacto
+	if limiteExacto <= 0 || limiteExacto > MaxParadasExacto {
+		limiteExacto = MaxParadasExacto
+	}
+	presupuesto := opciones.Presupuesto
+	if presupuesto <= 0 {
+		presupuesto = PresupuestoHeuristicoPorDefecto
+	}
+
+	if ventanas := opciones.Ventanas; ventanas != nil {
+		if err := ventanas.validar(len(costos)); err != nil {
+			return ResultadoTour{}, err
+		}
+		if ventanas.restringe() {
+			return p.optimizarConVentanas(ventanas, limiteExacto, presupuesto), nil
+		}
+	}
+
+	var resultado ResultadoTour
+	if n <= limiteExacto {
+		orden, costo := p.heldKarp()
+		resultado = ResultadoTour{Orden: orden, Costo: costo, CotaInferior: costo, Metodo: MetodoHeldKarp, Exacto: true}
+	} else {
+		limite := time.Now().Add(presupuesto)
+		orden, costo := p.busquedaLocalIterada(limite)
+		cota := math.Min(p.cotaInferiorUnArbol(costo), costo)
+		resultado = ResultadoTour{Orden: orden, Costo: costo, CotaInferior: cota, Metodo: MetodoHeuristico}
+	}
+
+	if ventanas := opciones.Ventanas; ventanas != nil {
+		// Ninguna parada tiene horario que la restrinja, pero se entrega la agenda
+		resultado.Agenda, _, _ = ventanas.programar(p, resultado.Orden)
+		if ventanas.MinimizarDuracion {
+			// Sin esperas la duracion son los trayectos mas las estancias, que no dependen del orden
+			fijo := ventanas.estancias(resultado.Orden)
+			resultado.Costo += fijo
+			resultado.CotaInferior += fijo
+		}
+	}
+	return resultado, nil
+}
+
+// costoRuta suma el costo de recorrer las paradas en orden partiendo del origen, con el tramo final
+func (p *problemaTour) costoRuta(orden []int) float64 {
+	total, anterior := 0.0, 0
+	for _, parada := range orden {
+		total += p.costos[anterior][parada]
+		anterior = parada
+	}
+	return total + p.final[anterior]
+}
+
+// libres es el rango de posiciones del orden que se pueden mover sin tocar las paradas fijas
+func (p *problemaTour) libres(n int) (int, int) {
+	desde, hasta := 0, n
+	if p.primera != 0 {
+		desde = 1
+	}
+	if p.ultima != 0 && n > desde {
+		hasta = n - 1
+	}
+	return desde, hasta
+}
+
+// heldKarp resuelve el tour exacto con programacion dinamica sobre subconjuntos de paradas.
+// dp[mascara][j] es el costo minimo de salir del origen, visitar las paradas de la mascara y terminar en j
+func (p *problemaTour) heldKarp() ([]int, float64) {
+	n := len(p.costos) - 1
+	completo := 1 << n
+
+	dp := make([]float64, completo*n)
+	previo := make([]int8, completo*n)
+	for i := range dp {
+		dp[i] = math.Inf(1)
+	}
+	for j := 0; j < n; j++ {
+		if p.primera == 0 || p.primera == j+1 {
+			dp[(1<<j)*n+j] = p.costos[0][j+1]
+			previo[(1<<j)*n+j] = -1
+		}
+	}
+
+	for mascara := 1; mascara < completo; mascara++ {
+		for j := 0; j < n; j++ {
+			actual := dp[mascara*n+j]
+			// Una ruta que ya llego a la ultima parada fija no puede continuar
+			if mascara&(1<<j) == 0 || math.IsInf(actual, 1) || p.ultima == j+1 {
+				continue
+			}
+			for k := 0; k < n; k++ {
+				if mascara&(1<<k) != 0 {
+					continue
+				}
+				siguiente := mascara | 1<<k
+				if costo := actual + p.costos[j+1][k+1]; costo < dp[siguiente*n+k] {
+					dp[siguiente*n+k] = costo
+					previo[siguiente*n+k] = int8(j)
+				}
+			}
+		}
+	}
+
+	mascara := completo - 1
+	ultimo, costo := -1, math.Inf(1)
+	for j := 0; j < n; j++ {
+		if p.ultima != 0 && p.ultima != j+1 {
+			continue
+		}
+		if total := dp[mascara*n+j] + p.final[j+1]; total < costo {
+			ultimo, costo = j, total
+		}
+	}
+
+	// Reconstruir el orden desde la ultima parada hacia atras
+	orden := make([]int, n)
+	for pos, j := n-1, ultimo; j >= 0; pos-- {
+		orden[pos] = j + 1
+		anterior := int(previo[mascara*n+j])
+		mascara &^= 1 << j
+		j = anterior
+	}
+
+	return orden, costo
+}
+
+// busquedaLocalIterada parte del vecino mas cercano, lo lleva a un optimo local con 2-opt y Or-opt
+// y, mientras quede presupuesto, lo perturba con un double-bridge y vuelve a mejorar conservando el mejor
+func (p *problemaTour) busquedaLocalIterada(limite time.Time) ([]int, float64) {
+	mejor := p.vecinoMasCercano()
+	p.mejorarRuta(mejor, limite)
+	mejorCosto := p.costoRuta(mejor)
+
+	// Semilla fija para que la misma solicitud de el mismo tour
+	aleatorio := rand.New(rand.NewSource(1))
+	desde, hasta := p.libres(len(mejor))
+	actual := append([]int(nil), mejor...)
+	for hasta-desde >= 8 && time.Now().Before(limite) {
+		candidata := perturbar(actual, desde, hasta, aleatorio)
+		p.mejorarRuta(candidata, limite)
+
+		costo := p.costoRuta(candidata)
+		if costo < p.costoRuta(actual)-epsilonMejora {
+			actual = candidata
+		}
+		if costo < mejorCosto-epsilonMejora {
+			mejor, mejorCosto = append([]int(nil), candidata...), costo
+		}
+	}
+
+	return mejor, mejorCosto
+}
+
+// vecinoMasCercano arma una ruta inicial yendo siempre a la parada pendiente mas cercana,
+// empezando y terminando con las paradas fijas
+func (p *problemaTour) vecinoMasCercano() []int {
+	n := len(p.costos) - 1
+	visitado := make([]bool, n+1)
+	orden := make([]int, 0, n)
+
+	actual := 0
+	if p.primera != 0 {
+		visitado[p.primera] = true
+		orden = append(orden, p.primera)
+		actual = p.primera
+	}
+	if p.ultima != 0 {
+		visitado[p.ultima] = true
+	}
+
+	for {
+		siguiente := -1
+		for j := 1; j <= n; j++ {
+			if !visitado[j] && (siguiente < 0 || p.costos[actual][j] < p.costos[actual][siguiente]) {
+				siguiente = j
+			}
+		}
+		if siguiente < 0 {
+			break
+		}
+		visitado[siguiente] = true
+		orden = append(orden, siguiente)
+		actual = siguiente
+	}
+
+	if p.ultima != 0 && p.ultima != p.primera {
+		orden = append(orden, p.ultima)
+	}
+	return orden
+}
+
+// mejorarRuta aplica movimientos 2-opt y Or-opt hasta que ninguno mejore o se agote el tiempo
+func (p *problemaTour) mejorarRuta(orden []int, limite time.Time) {
+	for time.Now().Before(limite) {
+		if !p.mejorar2Opt(orden) && !p.mejorarOrOpt(orden) {
+			return
+		}
+	}
+}
+
+// arista es el costo de ir de a a b; b < 0 representa el cierre de la ruta
+func (p *problemaTour) arista(a, b int) float64 {
+	if b < 0 {
+		return p.final[a]
+	}
+	return p.costos[a][b]
+}
+
+// mejorar2Opt invierte el primer tramo que acorte la ruta. Usa sumas acumuladas en ambos sentidos
+// para evaluar cada inversion en tiempo constante aun si los costos no son simetricos
+func (p *problemaTour) mejorar2Opt(orden []int) bool {
+	n := len(orden)
+	adelante := make([]float64, n)
+	atras := make([]float64, n)
+	for i := 1; i < n; i++ {
+		adelante[i] = adelante[i-1] + p.costos[orden[i-1]][orden[i]]
+		atras[i] = atras[i-1] + p.costos[orden[i]][orden[i-1]]
+	}
+
+	desde, hasta := p.libres(n)
+	for i := desde; i < hasta-1; i++ {
+		anterior := 0
+		if i > 0 {
+			anterior = orden[i-1]
+		}
+		for k := i + 1; k < hasta; k++ {
+			siguiente := -1
+			if k < n-1 {
+				siguiente = orden[k+1]
+			}
+
+			antes := p.costos[anterior][orden[i]] + adelante[k] - adelante[i] + p.arista(orden[k], siguiente)
+			despues := p.costos[anterior][orden[k]] + atras[k] - atras[i] + p.arista(orden[i], siguiente)
+			if despues < antes-epsilonMejora {
+				for a, b := i, k; a < b; a, b = a+1, b-1 {
+					orden[a], orden[b] = orden[b], orden[a]
+				}
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// mejorarOrOpt mueve el primer bloque de 1 a 3 paradas consecutivas cuya reubicacion acorte la ruta
+func (p *problemaTour) mejorarOrOpt(orden []int) bool {
+	n := len(orden)
+	// en(i) es la parada en la posicion i; -1 es el origen y n el cierre de la ruta
+	en := func(i int) int {
+		switch {
+		case i < 0:
+			return 0
+		case i >= n:
+			return -1
+		}
+		return orden[i]
+	}
+
+	desde, hasta := p.libres(n)
+	for largo := 1; largo <= 3 && largo < hasta-desde; largo++ {
+		for i := desde; i+largo <= hasta; i++ {
+			j := i + largo - 1
+			primero, ultimo := orden[i], orden[j]
+			anterior, siguiente := en(i-1), en(j+1)
+			ahorro := p.costos[anterior][primero] + p.arista(ultimo, siguiente) - p.arista(anterior, siguiente)
+
+			// Insertar el bloque entre las posiciones q y q+1, fuera del propio bloque
+			for q := desde - 1; q < hasta; q++ {
+				if q >= i-1 && q <= j {
+					continue
+				}
+				a, b := en(q), en(q+1)
+				extra := p.costos[a][primero] + p.arista(ultimo, b) - p.arista(a, b)
+				if extra < ahorro-epsilonMejora {
+					moverBloque(orden, i, largo, q)
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// moverBloque reubica orden[i:i+largo] justo despues de la posicion p (p = -1 lo deja al inicio)
+func moverBloque(orden []int, i, largo, p int) {
+	bloque := append([]int(nil), orden[i:i+largo]...)
+	resto := append(append([]int(nil), orden[:i]...), orden[i+largo:]...)
+	if p > i {
+		p -= largo
+	}
+
+	copy(orden, resto[:p+1])
+	copy(orden[p+1:], bloque)
+	copy(orden[p+1+largo:], resto[p+1:])
+}
+
+// perturbar aplica un double-bridge al tramo libre orden[desde:hasta]: lo corta en cuatro y lo
+// reordena A C B D, la perturbacion clasica que 2-opt y Or-opt no deshacen con un solo movimiento
+func perturbar(orden []int, desde, hasta int, aleatorio *rand.Rand) []int {
+	tramo := orden[desde:hasta]
+	n := len(tramo)
+	a := 1 + aleatorio.Intn(n/3)
+	b := a + 1 + aleatorio.Intn(n/3)
+	c := b + 1 + aleatorio.Intn(n-b-1)
+
+	nuevo := make([]int, 0, len(orden))
+	nuevo = append(nuevo, orden[:desde]...)
+	nuevo = append(nuevo, tramo[:a]...)
+	nuevo = append(nuevo, tramo[b:c]...)
+	nuevo = append(nuevo, tramo[a:b]...)
+	nuevo = append(nuevo, tramo[c:]...)
+	nuevo = append(nuevo, orden[hasta:]...)
+	return nuevo
+}
+
+// cotaInferiorUnArbol calcula la cota de Held-Karp con 1-arboles y subgradiente.
+// La ruta se convierte en un ciclo con un nodo de cierre unido a cada parada con su costo final
+// y obligado a conectarse con el origen; en cada arista se toma el menor de ambos sentidos,
+// asi la cota sigue siendo valida con costos asimetricos. Las paradas fijas obligan a usar la arista
+// del origen a la primera y la de la ultima al cierre. costoRuta es el de la mejor ruta conocida
+func (p *problemaTour) cotaInferiorUnArbol(costoRuta float64) float64 {
+	n := len(p.costos) // Origen y paradas; el nodo de cierre queda fuera de la matriz
+
+	// Restar un valor mayor que cualquier ruta a la arista obligada garantiza que el arbol la incluya
+	forzada := 1.0
+	for _, fila := range p.costos {
+		for _, costo := range fila {
+			forzada += math.Abs(costo)
+		}
+	}
+	simetrico := func(i, j int) float64 {
+		costo := math.Min(p.costos[i][j], p.costos[j][i])
+		if p.primera != 0 && (i == 0 && j == p.primera || j == 0 && i == p.primera) {
+			costo -= forzada
+		}
+		return costo
+	}
+
+	penal := make([]float64, n)
+	grado := make([]int, n)
+	distancia := make([]float64, n)
+	padre := make([]int, n)
+	enArbol := make([]bool, n)
+
+	mejorCota := 0.0
+	paso := 2.0
+	sinMejora := 0
+
+	for iter := 0; iter < iteracionesCotaInferior; iter++ {
+		for i := range grado {
+			grado[i] = 0
+			enArbol[i] = false
+			distancia[i] = math.Inf(1)
+		}
+
+		// Arbol de expansion minima (Prim) sobre el origen y las paradas con costos penalizados
+		total := 0.0
+		distancia[0] = 0
+		padre[0] = -1
+		for k := 0; k < n; k++ {
+			u := -1
+			for v := 0; v < n; v++ {
+				if !enArbol[v] && (u < 0 || distancia[v] < distancia[u]) {
+					u = v
+				}
+			}
+			enArbol[u] = true
+			if padre[u] >= 0 {
+				total += distancia[u]
+				grado[u]++
+				grado[padre[u]]++
+			}
+			for v := 0; v < n; v++ {
+				if !enArbol[v] {
+					if d := simetrico(u, v) + penal[u] + penal[v]; d < distancia[v] {
+						distancia[v] = d
+						padre[v] = u
+					}
+				}
+			}
+		}
+
+		if p.primera != 0 {
+			total += forzada
+		}
+
+		// El nodo de cierre se une al origen y a la parada mas barata de cerrar, que sera la ultima
+		ultima := 1
+		if p.ultima != 0 {
+			ultima = p.ultima
+		}
+		for v := 2; v < n && p.ultima == 0; v++ {
+			if p.final[v]+penal[v] < p.final[ultima]+penal[ultima] {
+				ultima = v
+			}
+		}
+		total += penal[0] + p.final[ultima] + penal[ultima]
+		grado[0]++
+		grado[ultima]++
+
+		cota := total
+		normal := 0.0
+		for i := 0; i < n; i++ {
+			cota -= 2 * penal[i]
+			normal += float64((grado[i] - 2) * (grado[i] - 2))
+		}
+
+		if cota > mejorCota+epsilonMejora {
+			mejorCota, sinMejora = cota, 0
+		} else if sinMejora++; sinMejora >= 20 {
+			paso, sinMejora = paso/2, 0
+		}
+		if normal == 0 || paso < 1e-4 {
+			break // El 1-arbol ya es una ruta, o el subgradiente dejo de avanzar
+		}
+
+		t := paso * math.Max(costoRuta-cota, 0.01*costoRuta) / normal
+		for i := 0; i < n; i++ {
+			penal[i] += t * float64(grado[i]-2)
+		}
+	}
+
+	return mejorCota
+}
@@ -0,0 +1,446 @@
+package algorithms
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const toleranciaCosto = 1e-6
+
+// matrizAleatoria genera costos enteros asimetricos entre 1 y 50 para el origen y n paradas
+func matrizAleatoria(aleatorio *rand.Rand, n int) [][]float64 {
+	costos := make([][]float64, n+1)
+	for i := range costos {
+		costos[i] = make([]float64, n+1)
+		for j := range costos[i] {
+			if i != j {
+				costos[i][j] = float64(1 + aleatorio.Intn(50))
+			}
+		}
+	}
+	return costos
+}
+
+func finalesAleatorios(aleatorio *rand.Rand, n int) []float64 {
+	final := make([]float64, n+1)
+	for i := range final {
+		final[i] = float64(aleatorio.Intn(40))
+	}
+	return final
+}
+
+// permutaciones llama a visitar con cada orden de las paradas 1..n
+func permutaciones(n int, visitar func([]int)) {
+	orden := make([]int, n)
+	for i := range orden {
+		orden[i] = i + 1
+	}
+	var generar func(k int)
+	generar = func(k int) {
+		if k == n {
+			visitar(orden)
+			return
+		}
+		for i := k; i < n; i++ {
+			orden[k], orden[i] = orden[i], orden[k]
+			generar(k + 1)
+			orden[k], orden[i] = orden[i], orden[k]
+		}
+	}
+	generar(0)
+}
+
+// optimoFuerzaBruta es el menor costo entre todos los ordenes que respetan las paradas fijas
+func optimoFuerzaBruta(p *problemaTour) float64 {
+	n := len(p.costos) - 1
+	mejor := math.Inf(1)
+	permutaciones(n, func(orden []int) {
+		if !respetaFijas(p, orden) {
+			return
+		}
+		mejor = math.Min(mejor, p.costoRuta(orden))
+	})
+	return mejor
+}
+
+func respetaFijas(p *problemaTour, orden []int) bool {
+	if p.primera != 0 && orden[0] != p.primera {
+		return false
+	}
+	return p.ultima == 0 || orden[len(orden)-1] == p.ultima
+}
+
+// esPermutacion indica si las listas juntas contienen cada parada 1..n exactamente una vez
+func esPermutacion(n int, listas ...[]int) bool {
+	vista := make([]bool, n+1)
+	total := 0
+	for _, lista := range listas {
+		for _, parada := range lista {
+			if parada < 1 || parada > n || vista[parada] {
+				return false
+			}
+			vista[parada] = true
+			total++
+		}
+	}
+	return total == n
+}
+
+// fijasDeCaso elige paradas fijas distintas segun el caso de la tabla
+func fijasDeCaso(aleatorio *rand.Rand, n int, conPrimera, conUltima bool) (int, int) {
+	paradas := aleatorio.Perm(n)
+	primera, ultima := 0, 0
+	if conPrimera {
+		primera = paradas[0] + 1
+	}
+	if conUltima && (n > 1 || !conPrimera) {
+		ultima = paradas[len(paradas)-1] + 1
+	}
+	return primera, ultima
+}
+
+var casosParadasFijas = []struct {
+	nombre     string
+	primera    bool
+	ultima     bool
+	conRegreso bool
+}{
+	{nombre: "libre abierta"},
+	{nombre: "libre con regreso", conRegreso: true},
+	{nombre: "primera fija", primera: true},
+	{nombre: "ultima fija", ultima: true},
+	{nombre: "primera y ultima fijas", primera: true, ultima: true},
+	{nombre: "primera y ultima fijas con regreso", primera: true, ultima: true, conRegreso: true},
+}
+
+func TestOptimizarTourExactoContraFuerzaBruta(t *testing.T) {
+	for _, caso := range casosParadasFijas {
+		t.Run(caso.nombre, func(t *testing.T) {
+			aleatorio := rand.New(rand.NewSource(7))
+			for intento := 0; intento < 40; intento++ {
+				n := 1 + aleatorio.Intn(7)
+				costos := matrizAleatoria(aleatorio, n)
+				var final []float64
+				if caso.conRegreso {
+					final = finalesAleatorios(aleatorio, n)
+				}
+				primera, ultima := fijasDeCaso(aleatorio, n, caso.primera, caso.ultima)
+
+				resultado, err := OptimizarTour(costos, OpcionesOptimizacion{CostosFinal: final, Primera: primera, Ultima: ultima})
+				if err != nil {
+					t.Fatalf("n=%d: error inesperado: %v", n, err)
+				}
+
+				p := &problemaTour{costos: costos, final: final, primera: primera, ultima: ultima}
+				if p.final == nil {
+					p.final = make([]float64, n+1)
+				}
+				optimo := optimoFuerzaBruta(p)
+
+				if !resultado.Exacto || resultado.Metodo != MetodoHeldKarp {
+					t.Fatalf("n=%d: se esperaba Held-Karp exacto, se obtuvo %s", n, resultado.Metodo)
+				}
+				if !esPermutacion(n, resultado.Orden) || !respetaFijas(p, resultado.Orden) {
+					t.Fatalf("n=%d primera=%d ultima=%d: orden invalido %v", n, primera, ultima, resultado.Orden)
+				}
+				if math.Abs(resultado.Costo-optimo) > toleranciaCosto {
+					t.Fatalf("n=%d: costo %v, el optimo es %v", n, resultado.Costo, optimo)
+				}
+				if math.Abs(p.costoRuta(resultado.Orden)-resultado.Costo) > toleranciaCosto {
+					t.Fatalf("n=%d: el costo %v no corresponde al orden %v", n, resultado.Costo, resultado.Orden)
+				}
+				if resultado.CotaInferior != resultado.Costo {
+					t.Fatalf("n=%d: la cota de una ruta exacta debe ser su costo", n)
+				}
+			}
+		})
+	}
+}
+
+func TestOptimizarTourHeuristicoContraFuerzaBruta(t *testing.T) {
+	for _, caso := range casosParadasFijas {
+		t.Run(caso.nombre, func(t *testing.T) {
+			aleatorio := rand.New(rand.NewSource(11))
+			for intento := 0; intento < 6; intento++ {
+				n := 8
+				costos := matrizAleatoria(aleatorio, n)
+				var final []float64
+				if caso.conRegreso {
+					final = finalesAleatorios(aleatorio, n)
+				}
+				primera, ultima := fijasDeCaso(aleatorio, n, caso.primera, caso.ultima)
+
+				resultado, err := OptimizarTour(costos, OpcionesOptimizacion{
+					MaxParadasExacto: 1,
+					Presupuesto:      20 * time.Millisecond,
+					CostosFinal:      final,
+					Primera:          primera,
+					Ultima:           ultima,
+				})
+				if err != nil {
+					t.Fatalf("error inesperado: %v", err)
+				}
+
+				p := &problemaTour{costos: costos, final: final, primera: primera, ultima: ultima}
+				if p.final == nil {
+					p.final = make([]float64, n+1)
+				}
+				optimo := optimoFuerzaBruta(p)
+
+				if resultado.Exacto || resultado.Metodo != MetodoHeuristico {
+					t.Fatalf("se esperaba el metodo heuristico, se obtuvo %s", resultado.Metodo)
+				}
+				if !esPermutacion(n, resultado.Orden) || !respetaFijas(p, resultado.Orden) {
+					t.Fatalf("primera=%d ultima=%d: orden invalido %v", primera, ultima, resultado.Orden)
+				}
+				if math.Abs(p.costoRuta(resultado.Orden)-resultado.Costo) > toleranciaCosto {
+					t.Fatalf("el costo %v no corresponde al orden %v", resultado.Costo, resultado.Orden)
+				}
+				if resultado.Costo < optimo-toleranciaCosto {
+					t.Fatalf("costo %v menor que el optimo %v", resultado.Costo, optimo)
+				}
+				if resultado.CotaInferior > optimo+toleranciaCosto || resultado.CotaInferior > resultado.Costo {
+					t.Fatalf("cota %v mayor que el optimo %v o el costo %v", resultado.CotaInferior, optimo, resultado.Costo)
+				}
+				if resultado.Brecha() < 0 {
+					t.Fatalf("brecha negativa %v", resultado.Brecha())
+				}
+			}
+		})
+	}
+}
+
+func TestCotaInferiorUnArbol(t *testing.T) {
+	for _, caso := range casosParadasFijas {
+		t.Run(caso.nombre, func(t *testing.T) {
+			aleatorio := rand.New(rand.NewSource(13))
+			for intento := 0; intento < 30; intento++ {
+				n := 2 + aleatorio.Intn(6)
+				p := &problemaTour{costos: matrizAleatoria(aleatorio, n), final: make([]float64, n+1)}
+				if caso.conRegreso {
+					p.final = finalesAleatorios(aleatorio, n)
+				}
+				p.primera, p.ultima = fijasDeCaso(aleatorio, n, caso.primera, caso.ultima)
+				optimo := optimoFuerzaBruta(p)
+
+				// La cota no depende de que la ruta de referencia sea optima, solo la usa para el paso
+				for _, referencia := range []float64{optimo, 2 * optimo} {
+					if cota := p.cotaInferiorUnArbol(referencia); cota < 0 || cota > optimo+toleranciaCosto {
+						t.Fatalf("n=%d primera=%d ultima=%d: cota %v fuera de [0, %v]", n, p.primera, p.ultima, cota, optimo)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMejorasLocalesRespetanParadasFijas(t *testing.T) {
+	mejoras := []struct {
+		nombre string
+		mejora func(p *problemaTour, orden []int) bool
+	}{
+		{nombre: "2-opt", mejora: (*problemaTour).mejorar2Opt},
+		{nombre: "or-opt", mejora: (*problemaTour).mejorarOrOpt},
+	}
+
+	for _, mejora := range mejoras {
+		for _, caso := range casosParadasFijas {
+			t.Run(mejora.nombre+" "+caso.nombre, func(t *testing.T) {
+				aleatorio := rand.New(rand.NewSource(17))
+				for intento := 0; intento < 30; intento++ {
+					n := 3 + aleatorio.Intn(6)
+					p := &problemaTour{costos: matrizAleatoria(aleatorio, n), final: make([]float64, n+1)}
+					if caso.conRegreso {
+						p.final = finalesAleatorios(aleatorio, n)
+					}
+					p.primera, p.ultima = fijasDeCaso(aleatorio, n, caso.primera, caso.ultima)
+					orden := ordenConFijas(aleatorio, p, n)
+
+					costo := p.costoRuta(orden)
+					for mejora.mejora(p, orden) {
+						nuevo := p.costoRuta(orden)
+						if nuevo >= costo-epsilonMejora {
+							t.Fatalf("el movimiento no acorto la ruta: %v -> %v", costo, nuevo)
+						}
+						if !esPermutacion(n, orden) || !respetaFijas(p, orden) {
+							t.Fatalf("primera=%d ultima=%d: orden invalido %v", p.primera, p.ultima, orden)
+						}
+						costo = nuevo
+					}
+
+					if candidata := mejorVecino(p, orden, mejora.nombre); candidata != nil {
+						t.Fatalf("%v no es optimo local: %v cuesta %v contra %v", orden, candidata, p.costoRuta(candidata), costo)
+					}
+				}
+			})
+		}
+	}
+}
+
+// ordenConFijas genera un orden aleatorio con las paradas fijas en sus extremos
+func ordenConFijas(aleatorio *rand.Rand, p *problemaTour, n int) []int {
+	var orden []int
+	for _, i := range aleatorio.Perm(n) {
+		if i+1 != p.primera && i+1 != p.ultima {
+			orden = append(orden, i+1)
+		}
+	}
+	if p.primera != 0 {
+		orden = append([]int{p.primera}, orden...)
+	}
+	if p.ultima != 0 {
+		orden = append(orden, p.ultima)
+	}
+	return orden
+}
+
+// mejorVecino busca por enumeracion una inversion o reubicacion de bloque del tramo libre que acorte
+// la ruta; nil si no existe
+func mejorVecino(p *problemaTour, orden []int, movimiento string) []int {
+	costo := p.costoRuta(orden)
+	desde, hasta := p.libres(len(orden))
+	candidata := make([]int, len(orden))
+
+	if movimiento == "2-opt" {
+		for i := desde; i < hasta; i++ {
+			for k := i + 1; k < hasta; k++ {
+				copy(candidata, orden)
+				for a, b := i, k; a < b; a, b = a+1, b-1 {
+					candidata[a], candidata[b] = candidata[b], candidata[a]
+				}
+				if p.costoRuta(candidata) < costo-epsilonMejora {
+					return candidata
+				}
+			}
+		}
+		return nil
+	}
+
+	for largo := 1; largo <= 3 && largo < hasta-desde; largo++ {
+		for i := desde; i+largo <= hasta; i++ {
+			bloque := orden[i : i+largo]
+			resto := append(append([]int(nil), orden[desde:i]...), orden[i+largo:hasta]...)
+			for q := 0; q <= len(resto); q++ {
+				candidata = append(append([]int(nil), orden[:desde]...), resto[:q]...)
+				candidata = append(append(candidata, bloque...), resto[q:]...)
+				candidata = append(candidata, orden[hasta:]...)
+				if p.costoRuta(candidata) < costo-epsilonMejora {
+					return candidata
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func TestMoverBloque(t *testing.T) {
+	casos := []struct {
+		nombre   string
+		i, largo int
+		p        int
+		esperado []int
+	}{
+		{nombre: "al inicio", i: 2, largo: 2, p: -1, esperado: []int{3, 4, 1, 2, 5, 6}},
+		{nombre: "hacia adelante", i: 0, largo: 2, p: 3, esperado: []int{3, 4, 1, 2, 5, 6}},
+		{nombre: "al final", i: 1, largo: 3, p: 5, esperado: []int{1, 5, 6, 2, 3, 4}},
+		{nombre: "una parada hacia atras", i: 4, largo: 1, p: 0, esperado: []int{1, 5, 2, 3, 4, 6}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			orden := []int{1, 2, 3, 4, 5, 6}
+			moverBloque(orden, caso.i, caso.largo, caso.p)
+			if !reflect.DeepEqual(orden, caso.esperado) {
+				t.Fatalf("se obtuvo %v, se esperaba %v", orden, caso.esperado)
+			}
+		})
+	}
+}
+
+func TestPerturbarDoubleBridge(t *testing.T) {
+	casos := []struct {
+		nombre       string
+		n            int
+		desde, hasta int
+	}{
+		{nombre: "todo el orden", n: 8, desde: 0, hasta: 8},
+		{nombre: "primera fija", n: 10, desde: 1, hasta: 10},
+		{nombre: "ultima fija", n: 10, desde: 0, hasta: 9},
+		{nombre: "ambas fijas", n: 12, desde: 1, hasta: 11},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			aleatorio := rand.New(rand.NewSource(19))
+			orden := make([]int, caso.n)
+			for i := range orden {
+				orden[i] = i + 1
+			}
+			original := append([]int(nil), orden...)
+
+			for intento := 0; intento < 200; intento++ {
+				nuevo := perturbar(orden, caso.desde, caso.hasta, aleatorio)
+				if !reflect.DeepEqual(orden, original) {
+					t.Fatal("perturbar modifico el orden recibido")
+				}
+				if !esPermutacion(caso.n, nuevo) {
+					t.Fatalf("orden invalido %v", nuevo)
+				}
+				if !reflect.DeepEqual(nuevo[:caso.desde], orden[:caso.desde]) || !reflect.DeepEqual(nuevo[caso.hasta:], orden[caso.hasta:]) {
+					t.Fatalf("se movieron paradas fuera del tramo libre: %v", nuevo)
+				}
+				if reflect.DeepEqual(nuevo, orden) {
+					t.Fatalf("el double-bridge dejo el orden igual")
+				}
+				if !esDoubleBridge(orden[caso.desde:caso.hasta], nuevo[caso.desde:caso.hasta]) {
+					t.Fatalf("%v no es un double-bridge de %v", nuevo, orden)
+				}
+			}
+		})
+	}
+}
+
+// esDoubleBridge indica si nuevo es A C B D para algun corte de original en A B C D con B y C no vacios
+func esDoubleBridge(original, nuevo []int) bool {
+	n := len(original)
+	for a := 1; a < n; a++ {
+		for b := a + 1; b < n; b++ {
+			for c := b + 1; c <= n; c++ {
+				candidata := append(append([]int(nil), original[:a]...), original[b:c]...)
+				candidata = append(append(candidata, original[a:b]...), original[c:]...)
+				if reflect.DeepEqual(candidata, nuevo) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func TestOptimizarTourErrores(t *testing.T) {
+	cuadrada := [][]float64{{0, 1, 2}, {1, 0, 3}, {2, 3, 0}}
+	casos := []struct {
+		nombre   string
+		costos   [][]float64
+		opciones OpcionesOptimizacion
+	}{
+		{nombre: "sin paradas", costos: [][]float64{{0}}},
+		{nombre: "matriz no cuadrada", costos: [][]float64{{0, 1}, {1}}},
+		{nombre: "costos finales de otro tamano", costos: cuadrada, opciones: OpcionesOptimizacion{CostosFinal: []float64{0, 1}}},
+		{nombre: "primera fuera de la matriz", costos: cuadrada, opciones: OpcionesOptimizacion{Primera: 3}},
+		{nombre: "ultima negativa", costos: cuadrada, opciones: OpcionesOptimizacion{Ultima: -1}},
+		{nombre: "primera y ultima iguales", costos: cuadrada, opciones: OpcionesOptimizacion{Primera: 1, Ultima: 1}},
+		{nombre: "ventanas de otro tamano", costos: cuadrada, opciones: OpcionesOptimizacion{Ventanas: &VentanasTiempo{}}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			if _, err := OptimizarTour(caso.costos, caso.opciones); err == nil {
+				t.Fatal("se esperaba un error")
+			}
+		})
+	}
+}
@@ -0,0 +1,473 @@
+package algorithms
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+)
+
+// PerfilRuteo indica por que vias puede circular un modo de viaje
+type PerfilRuteo int
+
+const (
+	PerfilPeaton    PerfilRuteo = iota // Cualquier via salvo las rapidas, en ambos sentidos
+	PerfilBicicleta                    // Calles, vias peatonales y ciclovias respetando el sentido
+	PerfilVehiculo                     // Calles y vias rapidas respetando el sentido
+)
+
+// claseVia agrupa los tipos de via de OpenStreetMap segun quien puede circular por ellas
+type claseVia int8
+
+const (
+	viaGeneral claseVia = iota
+	viaPeatonal
+	viaRapida
+)
+
+// DistanciaMaximaAjusteKm es lo mas lejos de una via que puede estar un punto para ajustarlo a la red
+const DistanciaMaximaAjusteKm = 0.5
+
+// tamanoCelda son los grados por lado de cada celda del indice espacial de tramos, poco mas de 1 km
+const tamanoCelda = 0.01
+
+// factorHeuristica reduce un poco la distancia en linea recta de A* para que siga siendo admisible
+// aunque la proyeccion sobre un tramo no coincida exactamente con la distancia Haversine
+const factorHeuristica = 0.995
+
+// RedVial es un grafo de calles para calcular rutas entre coordenadas. Cada tramo une dos nodos
+// consecutivos de una via y los puntos se ajustan al tramo permitido mas cercano
+type RedVial struct {
+	nodos      []Coordenada
+	adyacencia [][]aristaVial
+	tramos     []tramoVial
+	celdas     map[celdaRed][]int32
+	indice     map[[2]int64]int32 // Nodo de cada coordenada, para unir las vias que se cruzan
+}
+
+// RutaVial es el camino por calles entre dos puntos
+type RutaVial struct {
+	DistanciaKm float64      // Incluye el acceso desde cada punto hasta la via
+	Puntos      []Coordenada // Polilinea desde el punto de salida hasta el de llegada
+}
+
+type aristaVial struct {
+	hasta         int32
+	tramo         int32
+	contraSentido bool // Recorre al reves un tramo de sentido unico
+}
+
+type tramoVial struct {
+	a, b     int32
+	longitud float64 // Kilometros
+	clase    claseVia
+	unico    bool // Solo se circula de a hacia b
+}
+
+type celdaRed struct {
+	fila, columna int32
+}
+
+// ajusteRed es la posicion de un punto sobre el tramo mas cercano
+type ajusteRed struct {
+	tramo  int32
+	t      float64    // 0 en el nodo a del tramo y 1 en el b
+	punto  Coordenada // Punto de la via mas cercano
+	acceso float64    // Kilometros del punto original a la via
+}
+
+// extremoRed es un nodo por el que se entra o se sale de un punto ajustado, con lo que falta recorrer
+type extremoRed struct {
+	nodo  int32
+	costo float64
+}
+
+// NuevaRedVial crea una red vacia
+func NuevaRedVial() *RedVial {
+	return &RedVial{
+		celdas: make(map[celdaRed][]int32),
+		indice: make(map[[2]int64]int32),
+	}
+}
+
+// Nodos retorna cuantos nodos tiene la red
+func (r *RedVial) Nodos() int {
+	return len(r.nodos)
+}
+
+// Tramos retorna cuantos tramos tiene la red
+func (r *RedVial) Tramos() int {
+	return len(r.tramos)
+}
+
+// agregarVia une los puntos consecutivos de una via; los puntos repetidos entre vias son intersecciones
+func (r *RedVial) agregarVia(puntos []Coordenada, clase claseVia, unico bool) {
+	anterior := int32(-1)
+	for _, punto := range puntos {
+		actual := r.nodo(punto)
+		if anterior >= 0 && actual != anterior {
+			r.agregarTramo(anterior, actual, clase, unico)
+		}
+		anterior = actual
+	}
+}
+
+// nodo retorna el nodo de la coordenada, creandolo si no existe; OpenStreetMap usa 7 decimales
+func (r *RedVial) nodo(punto Coordenada) int32 {
+	clave := [2]int64{int64(math.Round(punto.Latitud * 1e7)), int64(math.Round(punto.Longitud * 1e7))}
+	if id, ok := r.indice[clave]; ok {
+		return id
+	}
+
+	id := int32(len(r.nodos))
+	r.nodos = append(r.nodos, punto)
+	r.adyacencia = append(r.adyacencia, nil)
+	r.indice[clave] = id
+	return id
+}
+
+func (r *RedVial) agregarTramo(a, b int32, clase claseVia, unico bool) {
+	id := int32(len(r.tramos))
+	inicio, fin := r.nodos[a], r.nodos[b]
+	r.tramos = append(r.tramos, tramoVial{a: a, b: b, longitud: DistanciaHaversine(inicio, fin), clase: clase, unico: unico})
+	r.adyacencia[a] = append(r.adyacencia[a], aristaVial{hasta: b, tramo: id})
+	r.adyacencia[b] = append(r.adyacencia[b], aristaVial{hasta: a, tramo: id, contraSentido: unico})
+
+	// El tramo se registra en todas las celdas de su rectangulo envolvente
+	minimo := celdaDe(Coordenada{Latitud: math.Min(inicio.Latitud, fin.Latitud), Longitud: math.Min(inicio.Longitud, fin.Longitud)})
+	maximo := celdaDe(Coordenada{Latitud: math.Max(inicio.Latitud, fin.Latitud), Longitud: math.Max(inicio.Longitud, fin.Longitud)})
+	for fila := minimo.fila; fila <= maximo.fila; fila++ {
+		for columna := minimo.columna; columna <= maximo.columna; columna++ {
+			celda := celdaRed{fila: fila, columna: columna}
+			r.celdas[celda] = append(r.celdas[celda], id)
+		}
+	}
+}
+
+func celdaDe(punto Coordenada) celdaRed {
+	return celdaRed{
+		fila:    int32(math.Floor(punto.Latitud / tamanoCelda)),
+		columna: int32(math.Floor(punto.Longitud / tamanoCelda)),
+	}
+}
+
+// permite indica si el perfil puede recorrer el tramo en el sentido dado
+func (p PerfilRuteo) permite(tramo tramoVial, contraSentido bool) bool {
+	switch p {
+	case PerfilPeaton:
+		return tramo.clase != viaRapida
+	case PerfilBicicleta:
+		return tramo.clase != viaRapida && !contraSentido
+	}
+	return tramo.clase != viaPeatonal && !contraSentido
+}
+
+// ajustar busca el punto mas cercano sobre un tramo por el que el perfil puede circular
+func (r *RedVial) ajustar(punto Coordenada, perfil PerfilRuteo) (ajusteRed, bool) {
+	centro := celdaDe(punto)
+	kmPorCelda := tamanoCelda * math.Pi / 180 * RadioTierraKm
+	filas := int32(math.Ceil(DistanciaMaximaAjusteKm / kmPorCelda))
+	columnas := int32(math.Ceil(DistanciaMaximaAjusteKm / (kmPorCelda * math.Max(math.Cos(punto.Latitud*math.Pi/180), 0.01))))
+
+	mejor := ajusteRed{tramo: -1, acceso: math.Inf(1)}
+	for fila := centro.fila - filas; fila <= centro.fila+filas; fila++ {
+		for columna := centro.columna - columnas; columna <= centro.columna+columnas; columna++ {
+			for _, id := range r.celdas[celdaRed{fila: fila, columna: columna}] {
+				tramo := r.tramos[id]
+				if !perfil.permite(tramo, false) {
+					continue
+				}
+				t, proyectado := proyectar(punto, r.nodos[tramo.a], r.nodos[tramo.b])
+				if distancia := DistanciaHaversine(punto, proyectado); distancia < mejor.acceso {
+					mejor = ajusteRed{tramo: id, t: t, punto: proyectado, acceso: distancia}
+				}
+			}
+		}
+	}
+
+	return mejor, mejor.tramo >= 0 && mejor.acceso <= DistanciaMaximaAjusteKm
+}
+
+// proyectar ubica el punto del segmento a-b mas cercano a p en una proyeccion plana local,
+// suficiente para segmentos de calle
+func proyectar(p, a, b Coordenada) (float64, Coordenada) {
+	escala := math.Cos(p.Latitud * math.Pi / 180)
+	dx, dy := (b.Longitud-a.Longitud)*escala, b.Latitud-a.Latitud
+	px, py := (p.Longitud-a.Longitud)*escala, p.Latitud-a.Latitud
+
+	t := 0.0
+	if largo := dx*dx + dy*dy; largo > 0 {
+		t = math.Max(0, math.Min(1, (px*dx+py*dy)/largo))
+	}
+	return t, Coordenada{
+		Latitud:  a.Latitud + t*(b.Latitud-a.Latitud),
+		Longitud: a.Longitud + t*(b.Longitud-a.Longitud),
+	}
+}
+
+// salidas son los nodos a los que se llega desde un punto ajustado recorriendo su tramo
+func (r *RedVial) salidas(ajuste ajusteRed, perfil PerfilRuteo) []extremoRed {
+	tramo := r.tramos[ajuste.tramo]
+	var extremos []extremoRed
+	if perfil.permite(tramo, false) {
+		extremos = append(extremos, extremoRed{nodo: tramo.b, costo: (1 - ajuste.t) * tramo.longitud})
+	}
+	if perfil.permite(tramo, tramo.unico) {
+		extremos = append(extremos, extremoRed{nodo: tramo.a, costo: ajuste.t * tramo.longitud})
+	}
+	return extremos
+}
+
+// llegadas son los nodos desde los que se alcanza un punto ajustado y lo que falta recorrer del tramo
+func (r *RedVial) llegadas(ajuste ajusteRed, perfil PerfilRuteo) []extremoRed {
+	tramo := r.tramos[ajuste.tramo]
+	var extremos []extremoRed
+	if perfil.permite(tramo, false) {
+		extremos = append(extremos, extremoRed{nodo: tramo.a, costo: ajuste.t * tramo.longitud})
+	}
+	if perfil.permite(tramo, tramo.unico) {
+		extremos = append(extremos, extremoRed{nodo: tramo.b, costo: (1 - ajuste.t) * tramo.longitud})
+	}
+	return extremos
+}
+
+// directo es la distancia entre dos puntos ajustados al mismo tramo sin salir de el
+func (r *RedVial) directo(origen, destino ajusteRed, perfil PerfilRuteo) float64 {
+	if origen.tramo != destino.tramo {
+		return math.Inf(1)
+	}
+	tramo := r.tramos[origen.tramo]
+	if destino.t >= origen.t && perfil.permite(tramo, false) {
+		return (destino.t - origen.t) * tramo.longitud
+	}
+	if destino.t <= origen.t && perfil.permite(tramo, tramo.unico) {
+		return (origen.t - destino.t) * tramo.longitud
+	}
+	return math.Inf(1)
+}
+
+// Ruta calcula con A* el camino mas corto por calles entre dos puntos para el perfil dado
+func (r *RedVial) Ruta(desde, hasta Coordenada, perfil PerfilRuteo) (RutaVial, error) {
+	origen, ok := r.ajustar(desde, perfil)
+	if !ok {
+		return RutaVial{}, errors.New("el punto de salida esta lejos de toda via de la red")
+	}
+	destino, ok := r.ajustar(hasta, perfil)
+	if !ok {
+		return RutaVial{}, errors.New("el punto de llegada esta lejos de toda via de la red")
+	}
+
+	restante := make(map[int32]float64, 2)
+	for _, llegada := range r.llegadas(destino, perfil) {
+		if costo, ok := restante[llegada.nodo]; !ok || llegada.costo < costo {
+			restante[llegada.nodo] = llegada.costo
+		}
+	}
+	heuristica := func(nodo int32) float64 {
+		return DistanciaHaversine(r.nodos[nodo], destino.punto) * factorHeuristica
+	}
+
+	busqueda := r.nuevaBusqueda(perfil)
+	for _, salida := range r.salidas(origen, perfil) {
+		busqueda.relajar(salida.nodo, salida.costo, -1, salida.costo+heuristica(salida.nodo))
+	}
+
+	mejor, ultimo := r.directo(origen, destino, perfil), int32(-1)
+	for busqueda.cola.Len() > 0 {
+		actual := heap.Pop(&busqueda.cola).(entradaCola)
+		if actual.prioridad >= mejor {
+			break
+		}
+		costo := busqueda.costo[actual.nodo]
+		if actual.costo > costo {
+			continue // Entrada vieja: el nodo ya se alcanzo por un camino mas corto
+		}
+		if extra, ok := restante[actual.nodo]; ok && costo+extra < mejor {
+			mejor, ultimo = costo+extra, actual.nodo
+		}
+		busqueda.expandir(actual.nodo, func(nodo int32, costo float64) float64 {
+			return costo + heuristica(nodo)
+		})
+	}
+	if math.IsInf(mejor, 1) {
+		return RutaVial{}, errors.New("no hay ruta por calles entre los puntos")
+	}
+
+	// Reconstruir la polilinea desde el ultimo nodo hacia atras
+	var intermedios []Coordenada
+	for nodo := ultimo; nodo >= 0; nodo = busqueda.previo[nodo] {
+		intermedios = append([]Coordenada{r.nodos[nodo]}, intermedios...)
+	}
+	puntos := []Coordenada{desde}
+	for _, punto := range append(append([]Coordenada{origen.punto}, intermedios...), destino.punto, hasta) {
+		if punto != puntos[len(puntos)-1] {
+			puntos = append(puntos, punto)
+		}
+	}
+
+	return RutaVial{DistanciaKm: origen.acceso + mejor + destino.acceso, Puntos: puntos}, nil
+}
+
+// MatrizRutas calcula la distancia por calles entre cada par de puntos con un Dijkstra desde cada uno
+// que se detiene al alcanzar todos los demas. Los pares sin ruta, o con un punto lejos de toda via,
+// quedan en +Inf
+func (r *RedVial) MatrizRutas(puntos []Coordenada, perfil PerfilRuteo) [][]float64 {
+	matriz := make([][]float64, len(puntos))
+	ajustes := make([]ajusteRed, len(puntos))
+	ajustado := make([]bool, len(puntos))
+	for i, punto := range puntos {
+		matriz[i] = make([]float64, len(puntos))
+		for j := range matriz[i] {
+			if i != j {
+				matriz[i][j] = math.Inf(1)
+			}
+		}
+		ajustes[i], ajustado[i] = r.ajustar(punto, perfil)
+	}
+
+	// Por cada nodo, los puntos que se alcanzan desde el y lo que falta recorrer
+	llegadas := make(map[int32][]extremoRed)
+	for j := range puntos {
+		if !ajustado[j] {
+			continue
+		}
+		for _, llegada := range r.llegadas(ajustes[j], perfil) {
+			llegadas[llegada.nodo] = append(llegadas[llegada.nodo], extremoRed{nodo: int32(j), costo: llegada.costo})
+		}
+	}
+
+	busqueda := r.nuevaBusqueda(perfil)
+	for i := range puntos {
+		if !ajustado[i] {
+			continue
+		}
+		busqueda.reiniciar()
+
+		mejor := make([]float64, len(puntos))
+		for j := range puntos {
+			mejor[j] = math.Inf(1)
+			if ajustado[j] && j != i {
+				mejor[j] = r.directo(ajustes[i], ajustes[j], perfil)
+			}
+		}
+		for _, salida := range r.salidas(ajustes[i], perfil) {
+			busqueda.relajar(salida.nodo, salida.costo, -1, salida.costo)
+		}
+
+		peor := peorPendiente(mejor, ajustado, i)
+		for busqueda.cola.Len() > 0 {
+			actual := heap.Pop(&busqueda.cola).(entradaCola)
+			if actual.costo > busqueda.costo[actual.nodo] {
+				continue
+			}
+			if actual.costo >= peor {
+				break // Ningun camino que falte puede mejorar a los destinos
+			}
+			if destinos := llegadas[actual.nodo]; len(destinos) > 0 {
+				for _, llegada := range destinos {
+					mejor[llegada.nodo] = math.Min(mejor[llegada.nodo], actual.costo+llegada.costo)
+				}
+				peor = peorPendiente(mejor, ajustado, i)
+			}
+			busqueda.expandir(actual.nodo, func(_ int32, costo float64) float64 {
+				return costo
+			})
+		}
+
+		for j := range puntos {
+			if j != i && ajustado[j] && !math.IsInf(mejor[j], 1) {
+				matriz[i][j] = ajustes[i].acceso + mejor[j] + ajustes[j].acceso
+			}
+		}
+	}
+
+	return matriz
+}
+
+// peorPendiente es la mayor de las mejores distancias conocidas a los puntos alcanzables; +Inf si falta alguno
+func peorPendiente(mejor []float64, ajustado []bool, origen int) float64 {
+	peor := 0.0
+	for j, distancia := range mejor {
+		if j != origen && ajustado[j] {
+			peor = math.Max(peor, distancia)
+		}
+	}
+	return peor
+}
+
+// busquedaRed guarda el estado de Dijkstra o A*; se reinicia solo en los nodos que se tocaron
+type busquedaRed struct {
+	red     *RedVial
+	perfil  PerfilRuteo
+	costo   []float64
+	previo  []int32
+	tocados []int32
+	cola    colaRed
+}
+
+func (r *RedVial) nuevaBusqueda(perfil PerfilRuteo) *busquedaRed {
+	busqueda := &busquedaRed{
+		red:    r,
+		perfil: perfil,
+		costo:  make([]float64, len(r.nodos)),
+		previo: make([]int32, len(r.nodos)),
+	}
+	for i := range busqueda.costo {
+		busqueda.costo[i] = math.Inf(1)
+	}
+	return busqueda
+}
+
+func (b *busquedaRed) reiniciar() {
+	for _, nodo := range b.tocados {
+		b.costo[nodo] = math.Inf(1)
+	}
+	b.tocados = b.tocados[:0]
+	b.cola = b.cola[:0]
+}
+
+// relajar registra un camino hasta el nodo si es mas corto que el conocido
+func (b *busquedaRed) relajar(nodo int32, costo float64, previo int32, prioridad float64) {
+	if costo >= b.costo[nodo] {
+		return
+	}
+	if math.IsInf(b.costo[nodo], 1) {
+		b.tocados = append(b.tocados, nodo)
+	}
+	b.costo[nodo] = costo
+	b.previo[nodo] = previo
+	heap.Push(&b.cola, entradaCola{nodo: nodo, costo: costo, prioridad: prioridad})
+}
+
+// expandir relaja los vecinos del nodo por los tramos que el perfil puede recorrer
+func (b *busquedaRed) expandir(nodo int32, prioridad func(nodo int32, costo float64) float64) {
+	base := b.costo[nodo]
+	for _, arista := range b.red.adyacencia[nodo] {
+		tramo := b.red.tramos[arista.tramo]
+		if !b.perfil.permite(tramo, arista.contraSentido) {
+			continue
+		}
+		costo := base + tramo.longitud
+		b.relajar(arista.hasta, costo, nodo, prioridad(arista.hasta, costo))
+	}
+}
+
+// entradaCola es un nodo pendiente de Dijkstra o A* con su costo y su prioridad
+type entradaCola struct {
+	nodo      int32
+	costo     float64
+	prioridad float64
+}
+
+// colaRed es un monticulo minimo por prioridad para container/heap
+type colaRed []entradaCola
+
+func (c colaRed) Len() int           { return len(c) }
+func (c colaRed) Less(i, j int) bool { return c[i].prioridad < c[j].prioridad }
+func (c colaRed) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c *colaRed) Push(x any)        { *c = append(*c, x.(entradaCola)) }
+func (c *colaRed) Pop() any {
+	anterior := *c
+	ultimo := anterior[len(anterior)-1]
+	*c = anterior[:len(anterior)-1]
+	return ultimo
+}
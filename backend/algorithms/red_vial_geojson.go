@@ -0,0 +1,158 @@
+package algorithms
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tipos de via de OpenStreetMap que no se pueden recorrer
+var viasExcluidas = map[string]bool{
+	"proposed":     true,
+	"construction": true,
+	"abandoned":    true,
+	"disused":      true,
+	"platform":     true,
+	"raceway":      true,
+	"bus_stop":     true,
+	"elevator":     true,
+	"rest_area":    true,
+	"services":     true,
+}
+
+// Tipos de via de OpenStreetMap solo para peatones y bicicletas
+var viasPeatonales = map[string]bool{
+	"footway":    true,
+	"pedestrian": true,
+	"path":       true,
+	"steps":      true,
+	"cycleway":   true,
+	"bridleway":  true,
+	"corridor":   true,
+}
+
+// Tipos de via de OpenStreetMap sin acceso a peatones ni bicicletas
+var viasRapidas = map[string]bool{
+	"motorway":      true,
+	"motorway_link": true,
+	"trunk":         true,
+	"trunk_link":    true,
+}
+
+type coleccionGeoJSON struct {
+	Type     string           `json:"type"`
+	Features []featureGeoJSON `json:"features"`
+}
+
+type featureGeoJSON struct {
+	Geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// CargarRedVialGeoJSON construye la red de calles con las vias de un extracto de OpenStreetMap en GeoJSON:
+// LineString o MultiLineString con las etiquetas en properties o en properties.tags, como las exportan
+// osmium export u osmtogeojson. Solo se importan las vias con etiqueta highway y se respeta oneway,
+// incluido -1, y las glorietas. Un extracto .osm.pbf se convierte antes con
+// `osmium export extracto.osm.pbf --geometry-types=linestring -o vias.geojson`
+func CargarRedVialGeoJSON(lector io.Reader) (*RedVial, error) {
+	var coleccion coleccionGeoJSON
+	if err := json.NewDecoder(lector).Decode(&coleccion); err != nil {
+		return nil, fmt.Errorf("GeoJSON invalido: %w", err)
+	}
+	if coleccion.Type != "FeatureCollection" {
+		return nil, errors.New("se esperaba un FeatureCollection de GeoJSON")
+	}
+
+	red := NuevaRedVial()
+	for _, feature := range coleccion.Features {
+		etiquetas := feature.Properties
+		if anidadas, ok := etiquetas["tags"].(map[string]any); ok {
+			etiquetas = anidadas
+		}
+		tipo := etiquetaTexto(etiquetas, "highway")
+		if tipo == "" || viasExcluidas[tipo] {
+			continue
+		}
+
+		var lineas [][][]float64
+		switch feature.Geometry.Type {
+		case "LineString":
+			var linea [][]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &linea); err != nil {
+				return nil, fmt.Errorf("coordenadas invalidas en una via: %w", err)
+			}
+			lineas = [][][]float64{linea}
+		case "MultiLineString":
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &lineas); err != nil {
+				return nil, fmt.Errorf("coordenadas invalidas en una via: %w", err)
+			}
+		default:
+			continue // Las areas peatonales y los puntos no forman parte de la red
+		}
+
+		clase := viaGeneral
+		if viasPeatonales[tipo] {
+			clase = viaPeatonal
+		} else if viasRapidas[tipo] {
+			clase = viaRapida
+		}
+		unico, invertida := sentidoVia(etiquetas, tipo)
+
+		for _, linea := range lineas {
+			puntos := make([]Coordenada, 0, len(linea))
+			for _, posicion := range linea {
+				if len(posicion) < 2 {
+					return nil, errors.New("cada posicion de GeoJSON debe tener longitud y latitud")
+				}
+				puntos = append(puntos, Coordenada{Latitud: posicion[1], Longitud: posicion[0]})
+			}
+			if invertida {
+				for i, j := 0, len(puntos)-1; i < j; i, j = i+1, j-1 {
+					puntos[i], puntos[j] = puntos[j], puntos[i]
+				}
+			}
+			red.agregarVia(puntos, clase, unico)
+		}
+	}
+
+	if red.Tramos() == 0 {
+		return nil, errors.New("el archivo no contiene vias con etiqueta highway")
+	}
+	return red, nil
+}
+
+// sentidoVia interpreta oneway; las autopistas y glorietas son de sentido unico salvo oneway=no.
+// invertida indica que el sentido permitido es el contrario al de los puntos
+func sentidoVia(etiquetas map[string]any, tipo string) (unico, invertida bool) {
+	switch etiquetaTexto(etiquetas, "oneway") {
+	case "yes", "true", "1":
+		return true, false
+	case "-1", "reverse":
+		return true, true
+	case "no", "false", "0":
+		return false, false
+	}
+	cruce := etiquetaTexto(etiquetas, "junction")
+	return tipo == "motorway" || tipo == "motorway_link" || cruce == "roundabout" || cruce == "circular", false
+}
+
+// etiquetaTexto lee una etiqueta como texto; algunos exportadores convierten yes y no en booleanos
+func etiquetaTexto(etiquetas map[string]any, clave string) string {
+	switch valor := etiquetas[clave].(type) {
+	case string:
+		return strings.ToLower(strings.TrimSpace(valor))
+	case bool:
+		if valor {
+			return "yes"
+		}
+		return "no"
+	case float64:
+		return fmt.Sprint(valor)
+	}
+	return ""
+}
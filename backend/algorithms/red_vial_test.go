@@ -0,0 +1,275 @@
+package algorithms
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// gradosPorKm convierte kilometros a grados de latitud
+const gradosPorKm = 180 / (math.Pi * RadioTierraKm)
+
+var perfilesRuteo = []struct {
+	nombre string
+	perfil PerfilRuteo
+}{
+	{nombre: "peaton", perfil: PerfilPeaton},
+	{nombre: "bicicleta", perfil: PerfilBicicleta},
+	{nombre: "vehiculo", perfil: PerfilVehiculo},
+}
+
+// redCuadricula arma una cuadricula de lado x lado nodos separados unos 200 m, con tramos
+// peatonales, vias rapidas y sentidos unicos al azar
+func redCuadricula(aleatorio *rand.Rand, lado int) *RedVial {
+	red := NuevaRedVial()
+	punto := func(fila, columna int) Coordenada {
+		return Coordenada{Latitud: 19.40 + float64(fila)*0.002, Longitud: -99.15 + float64(columna)*0.002}
+	}
+	agregar := func(a, b Coordenada) {
+		if aleatorio.Intn(2) == 0 {
+			a, b = b, a
+		}
+		clase := viaGeneral
+		switch aleatorio.Intn(6) {
+		case 0:
+			clase = viaPeatonal
+		case 1:
+			clase = viaRapida
+		}
+		red.agregarVia([]Coordenada{a, b}, clase, aleatorio.Intn(3) == 0)
+	}
+
+	for fila := 0; fila < lado; fila++ {
+		for columna := 0; columna < lado; columna++ {
+			if columna+1 < lado {
+				agregar(punto(fila, columna), punto(fila, columna+1))
+			}
+			if fila+1 < lado {
+				agregar(punto(fila, columna), punto(fila+1, columna))
+			}
+		}
+	}
+	return red
+}
+
+// distanciasFloydWarshall calcula la distancia entre todos los nodos por los tramos que el perfil permite
+func distanciasFloydWarshall(red *RedVial, perfil PerfilRuteo) [][]float64 {
+	n := red.Nodos()
+	distancia := make([][]float64, n)
+	for i := range distancia {
+		distancia[i] = make([]float64, n)
+		for j := range distancia[i] {
+			if i != j {
+				distancia[i][j] = math.Inf(1)
+			}
+		}
+	}
+	for _, tramo := range red.tramos {
+		if perfil.permite(tramo, false) {
+			distancia[tramo.a][tramo.b] = math.Min(distancia[tramo.a][tramo.b], tramo.longitud)
+		}
+		if perfil.permite(tramo, tramo.unico) {
+			distancia[tramo.b][tramo.a] = math.Min(distancia[tramo.b][tramo.a], tramo.longitud)
+		}
+	}
+	for k := 0; k < n; k++ {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				distancia[i][j] = math.Min(distancia[i][j], distancia[i][k]+distancia[k][j])
+			}
+		}
+	}
+	return distancia
+}
+
+// distanciaReferencia combina los ajustes de ambos puntos con las distancias entre todos los nodos
+func distanciaReferencia(red *RedVial, distancias [][]float64, desde, hasta Coordenada, perfil PerfilRuteo) float64 {
+	origen, ok := red.ajustar(desde, perfil)
+	if !ok {
+		return math.Inf(1)
+	}
+	destino, ok := red.ajustar(hasta, perfil)
+	if !ok {
+		return math.Inf(1)
+	}
+
+	mejor := red.directo(origen, destino, perfil)
+	for _, salida := range red.salidas(origen, perfil) {
+		for _, llegada := range red.llegadas(destino, perfil) {
+			mejor = math.Min(mejor, salida.costo+distancias[salida.nodo][llegada.nodo]+llegada.costo)
+		}
+	}
+	return origen.acceso + mejor + destino.acceso
+}
+
+func longitudPolilinea(puntos []Coordenada) float64 {
+	total := 0.0
+	for i := 1; i < len(puntos); i++ {
+		total += DistanciaHaversine(puntos[i-1], puntos[i])
+	}
+	return total
+}
+
+func TestRutasContraFloydWarshall(t *testing.T) {
+	for _, caso := range perfilesRuteo {
+		t.Run(caso.nombre, func(t *testing.T) {
+			aleatorio := rand.New(rand.NewSource(31))
+			for intento := 0; intento < 5; intento++ {
+				red := redCuadricula(aleatorio, 5)
+				distancias := distanciasFloydWarshall(red, caso.perfil)
+
+				puntos := make([]Coordenada, 0, 12)
+				for len(puntos) < 11 {
+					puntos = append(puntos, Coordenada{
+						Latitud:  19.399 + aleatorio.Float64()*0.010,
+						Longitud: -99.151 + aleatorio.Float64()*0.010,
+					})
+				}
+				puntos = append(puntos, Coordenada{Latitud: 19.50, Longitud: -99.15}) // Lejos de toda via
+
+				matriz := red.MatrizRutas(puntos, caso.perfil)
+				for i, desde := range puntos {
+					if matriz[i][i] != 0 {
+						t.Fatalf("la distancia de un punto a si mismo es %v", matriz[i][i])
+					}
+					for j, hasta := range puntos {
+						if i == j {
+							continue
+						}
+						referencia := distanciaReferencia(red, distancias, desde, hasta, caso.perfil)
+
+						if math.IsInf(referencia, 1) != math.IsInf(matriz[i][j], 1) ||
+							!math.IsInf(referencia, 1) && math.Abs(matriz[i][j]-referencia) > toleranciaCosto {
+							t.Fatalf("Dijkstra de %d a %d: %v, se esperaba %v", i, j, matriz[i][j], referencia)
+						}
+
+						ruta, err := red.Ruta(desde, hasta, caso.perfil)
+						if math.IsInf(referencia, 1) {
+							if err == nil {
+								t.Fatalf("A* de %d a %d encontro ruta de %v km sin camino posible", i, j, ruta.DistanciaKm)
+							}
+							continue
+						}
+						if err != nil {
+							t.Fatalf("A* de %d a %d: error inesperado: %v", i, j, err)
+						}
+						if math.Abs(ruta.DistanciaKm-referencia) > toleranciaCosto {
+							t.Fatalf("A* de %d a %d: %v, se esperaba %v", i, j, ruta.DistanciaKm, referencia)
+						}
+						if ruta.Puntos[0] != desde || ruta.Puntos[len(ruta.Puntos)-1] != hasta {
+							t.Fatalf("la polilinea no va de la salida a la llegada: %v", ruta.Puntos)
+						}
+						if math.Abs(longitudPolilinea(ruta.Puntos)-ruta.DistanciaKm) > 1e-4 {
+							t.Fatalf("la polilinea mide %v y la ruta %v", longitudPolilinea(ruta.Puntos), ruta.DistanciaKm)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAjustarPuntoALaRed(t *testing.T) {
+	inicio := Coordenada{Latitud: 19.40, Longitud: -99.15}
+	fin := Coordenada{Latitud: 19.40, Longitud: -99.14}
+
+	red := NuevaRedVial()
+	red.agregarVia([]Coordenada{inicio, fin}, viaGeneral, false)
+	red.agregarVia([]Coordenada{{Latitud: 19.41, Longitud: -99.15}, {Latitud: 19.41, Longitud: -99.14}}, viaPeatonal, false)
+
+	casos := []struct {
+		nombre string
+		punto  Coordenada
+		perfil PerfilRuteo
+		ok     bool
+		t      float64
+		acceso float64
+	}{
+		{nombre: "sobre la via", punto: Coordenada{Latitud: 19.40, Longitud: -99.145}, perfil: PerfilVehiculo, ok: true, t: 0.5},
+		{nombre: "a 100 m de la via", punto: Coordenada{Latitud: 19.40 + 0.1*gradosPorKm, Longitud: -99.1475}, perfil: PerfilVehiculo, ok: true, t: 0.25, acceso: 0.1},
+		{nombre: "pasando el final de la via", punto: Coordenada{Latitud: 19.40, Longitud: -99.14 + 0.2*gradosPorKm/math.Cos(19.40*math.Pi/180)}, perfil: PerfilVehiculo, ok: true, t: 1, acceso: 0.2},
+		{nombre: "mas cerca de la peatonal a pie", punto: Coordenada{Latitud: 19.409, Longitud: -99.145}, perfil: PerfilPeaton, ok: true, t: 0.5, acceso: 0.001 / gradosPorKm},
+		{nombre: "el vehiculo ignora la peatonal", punto: Coordenada{Latitud: 19.404, Longitud: -99.145}, perfil: PerfilVehiculo, ok: true, t: 0.5, acceso: 0.004 / gradosPorKm},
+		{nombre: "lejos de toda via", punto: Coordenada{Latitud: 19.40 + 0.6*gradosPorKm, Longitud: -99.145}, perfil: PerfilVehiculo},
+		{nombre: "solo cerca de la peatonal en vehiculo", punto: Coordenada{Latitud: 19.412, Longitud: -99.145}, perfil: PerfilVehiculo},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			ajuste, ok := red.ajustar(caso.punto, caso.perfil)
+			if ok != caso.ok {
+				t.Fatalf("ajustado = %v, se esperaba %v", ok, caso.ok)
+			}
+			if !ok {
+				return
+			}
+			if math.Abs(ajuste.t-caso.t) > 1e-3 || math.Abs(ajuste.acceso-caso.acceso) > 1e-3 {
+				t.Fatalf("t=%v acceso=%v, se esperaba t=%v acceso=%v", ajuste.t, ajuste.acceso, caso.t, caso.acceso)
+			}
+			if caso.perfil == PerfilVehiculo && red.tramos[ajuste.tramo].clase == viaPeatonal {
+				t.Fatal("el vehiculo se ajusto a una via peatonal")
+			}
+			tramo := red.tramos[ajuste.tramo]
+			if recorrido := DistanciaHaversine(red.nodos[tramo.a], ajuste.punto); math.Abs(recorrido-ajuste.t*tramo.longitud) > 1e-6 {
+				t.Fatalf("el punto ajustado esta a %v km del inicio del tramo, se esperaba %v", recorrido, ajuste.t*tramo.longitud)
+			}
+		})
+	}
+}
+
+func TestRutaRespetaPerfiles(t *testing.T) {
+	// Tres calles paralelas de este a oeste unidas en sus extremos: la del centro es de sentido unico
+	// hacia el este, la del norte es peatonal y la del sur es una via rapida de doble sentido
+	oeste, este := -99.15, -99.14
+	centro, norte, sur := 19.40, 19.402, 19.398
+	red := NuevaRedVial()
+	red.agregarVia([]Coordenada{{Latitud: centro, Longitud: oeste}, {Latitud: centro, Longitud: este}}, viaGeneral, true)
+	red.agregarVia([]Coordenada{{Latitud: norte, Longitud: oeste}, {Latitud: norte, Longitud: este}}, viaPeatonal, false)
+	red.agregarVia([]Coordenada{{Latitud: sur, Longitud: oeste}, {Latitud: sur, Longitud: este}}, viaRapida, false)
+	for _, lng := range []float64{oeste, este} {
+		red.agregarVia([]Coordenada{{Latitud: sur, Longitud: lng}, {Latitud: centro, Longitud: lng}, {Latitud: norte, Longitud: lng}}, viaGeneral, false)
+	}
+
+	directo := DistanciaHaversine(Coordenada{Latitud: centro, Longitud: oeste}, Coordenada{Latitud: centro, Longitud: este})
+	rodeo := func(lat float64) float64 {
+		return longitudPolilinea([]Coordenada{
+			{Latitud: centro, Longitud: este}, {Latitud: lat, Longitud: este},
+			{Latitud: lat, Longitud: oeste}, {Latitud: centro, Longitud: oeste},
+		})
+	}
+	casaOeste := Coordenada{Latitud: centro, Longitud: oeste}
+	casaEste := Coordenada{Latitud: centro, Longitud: este}
+
+	casos := []struct {
+		nombre     string
+		desde      Coordenada
+		hasta      Coordenada
+		perfil     PerfilRuteo
+		esperadoKm float64
+		sinRuta    bool
+	}{
+		{nombre: "vehiculo a favor del sentido", desde: casaOeste, hasta: casaEste, perfil: PerfilVehiculo, esperadoKm: directo},
+		{nombre: "vehiculo contra el sentido rodea por la via rapida", desde: casaEste, hasta: casaOeste, perfil: PerfilVehiculo, esperadoKm: rodeo(sur)},
+		{nombre: "peaton contra el sentido va directo", desde: casaEste, hasta: casaOeste, perfil: PerfilPeaton, esperadoKm: directo},
+		{nombre: "bicicleta contra el sentido rodea por la peatonal", desde: casaEste, hasta: casaOeste, perfil: PerfilBicicleta, esperadoKm: rodeo(norte)},
+		{nombre: "vehiculo sin acceso a la peatonal", desde: Coordenada{Latitud: norte + 0.004, Longitud: -99.145}, hasta: casaEste, perfil: PerfilVehiculo, sinRuta: true},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			ruta, err := red.Ruta(caso.desde, caso.hasta, caso.perfil)
+			if caso.sinRuta {
+				if err == nil {
+					t.Fatalf("se esperaba un error y se obtuvo una ruta de %v km", ruta.DistanciaKm)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if math.Abs(ruta.DistanciaKm-caso.esperadoKm) > toleranciaCosto {
+				t.Fatalf("la ruta mide %v km, se esperaba %v", ruta.DistanciaKm, caso.esperadoKm)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package algorithms
+
+import (
+	"strings"
+
+	"github.com/tuusuario/quovi/utils"
+)
+
+// Sugerencia es un texto completable con el tipo de entidad a la que pertenece
+type Sugerencia struct {
+	Tipo  string  `json:"tipo"`
+	ID    uint    `json:"id,omitempty"`
+	Texto string  `json:"texto"`
+	Peso  float64 `json:"-"`
+}
+
+// nodoTrie guarda sus hijos y las mejores sugerencias de todo su subarbol
+type nodoTrie struct {
+	hijos   map[rune]*nodoTrie
+	mejores []int // Indices en Trie.sugerencias ordenados por peso descendente
+}
+
+// Trie resuelve prefijos en tiempo proporcional a la longitud del prefijo
+// Cada nodo conserva precalculadas sus mejores sugerencias, por lo que la consulta no recorre el subarbol
+// No es seguro insertar mientras se consulta: se construye completo y despues se comparte
+type Trie struct {
+	raiz        *nodoTrie
+	sugerencias []Sugerencia
+	maxPorNodo  int
+}
+
+// NewTrie crea un trie que conserva hasta maxPorNodo sugerencias por prefijo
+func NewTrie(maxPorNodo int) *Trie {
+	return &Trie{
+		raiz:       &nodoTrie{},
+		maxPorNodo: maxPorNodo,
+	}
+}
+
+// Total retorna cuantas sugerencias contiene el trie
+func (t *Trie) Total() int {
+	return len(t.sugerencias)
+}
+
+// Insertar agrega una sugerencia accesible desde el inicio de cada una de sus palabras,
+// asi "Tacos de Birria" se encuentra con "tac" y con "bir"
+func (t *Trie) Insertar(sugerencia Sugerencia) {
+	terminos := utils.Tokenizar(sugerencia.Texto)
+	if len(terminos) == 0 {
+		return
+	}
+
+	indice := len(t.sugerencias)
+	t.sugerencias = append(t.sugerencias, sugerencia)
+
+	t.agregarMejor(t.raiz, indice)
+	for i, termino := range terminos {
+		if i > 0 && palabrasVacias[termino] {
+			continue
+		}
+
+		nodo := t.raiz
+		for _, r := range strings.Join(terminos[i:], " ") {
+			hijo, ok := nodo.hijos[r]
+			if !ok {
+				if nodo.hijos == nil {
+					nodo.hijos = make(map[rune]*nodoTrie)
+				}
+				hijo = &nodoTrie{}
+				nodo.hijos[r] = hijo
+			}
+			nodo = hijo
+			t.agregarMejor(nodo, indice)
+		}
+	}
+}
+
+// BuscarPrefijo retorna las sugerencias de mayor peso que empiezan con el prefijo
+// El prefijo se normaliza igual que los textos; un prefijo vacio retorna las mejores en general
+func (t *Trie) BuscarPrefijo(prefijo string, limite int) []Sugerencia {
+	nodo := t.raiz
+	for _, r := range NormalizarPrefijo(prefijo) {
+		hijo, ok := nodo.hijos[r]
+		if !ok {
+			return []Sugerencia{}
+		}
+		nodo = hijo
+	}
+
+	if limite <= 0 || limite > len(nodo.mejores) {
+		limite = len(nodo.mejores)
+	}
+
+	resultado := make([]Sugerencia, 0, limite)
+	for _, indice := range nodo.mejores[:limite] {
+		resultado = append(resultado, t.sugerencias[indice])
+	}
+	return resultado
+}
+
+// NormalizarPrefijo quita acentos, mayusculas y signos, conservando el espacio final
+// para que "pozole " no complete "pozoleria"
+func NormalizarPrefijo(prefijo string) string {
+	normalizado := strings.Join(utils.Tokenizar(prefijo), " ")
+	if normalizado != "" && strings.HasSuffix(prefijo, " ") {
+		normalizado += " "
+	}
+	return normalizado
+}
+
+// agregarMejor inserta la sugerencia en la lista ordenada del nodo si esta entre las mejores
+func (t *Trie) agregarMejor(nodo *nodoTrie, indice int) {
+	peso := t.sugerencias[indice].Peso
+
+	posicion := len(nodo.mejores)
+	for i, otro := range nodo.mejores {
+		if otro == indice {
+			return // Ya llego por otra palabra del mismo texto
+		}
+		if posicion == len(nodo.mejores) && t.sugerencias[otro].Peso < peso {
+			posicion = i
+		}
+	}
+
+	if posicion >= t.maxPorNodo {
+		return
+	}
+
+	nodo.mejores = append(nodo.mejores, 0)
+	copy(nodo.mejores[posicion+1:], nodo.mejores[posicion:])
+	nodo.mejores[posicion] = indice
+
+	if len(nodo.mejores) > t.maxPorNodo {
+		nodo.mejores = nodo.mejores[:t.maxPorNodo]
+	}
+}
@@ -0,0 +1,137 @@
+package algorithms
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tuusuario/quovi/utils"
+)
+
+func TestBuscarPrefijo(t *testing.T) {
+	trie := NewTrie(3)
+	for _, sugerencia := range []Sugerencia{
+		{Tipo: "platillo", ID: 1, Texto: "Tacos de Birria", Peso: 5},
+		{Tipo: "platillo", ID: 2, Texto: "Pozole Rojo", Peso: 4},
+		{Tipo: "restaurante", ID: 3, Texto: "Pozoleria La Güera", Peso: 3},
+		{Tipo: "platillo", ID: 4, Texto: "Café de Olla", Peso: 2},
+		{Tipo: "platillo", ID: 5, Texto: "Tamales Oaxaqueños", Peso: 6},
+		{Tipo: "platillo", ID: 6, Texto: "Tacos al Pastor", Peso: 5},
+		{Tipo: "platillo", ID: 7, Texto: "Tostadas de Tinga", Peso: 1},
+		{Tipo: "platillo", ID: 8, Texto: "¡!", Peso: 9}, // Sin palabras, no se inserta
+	} {
+		trie.Insertar(sugerencia)
+	}
+
+	casos := []struct {
+		nombre   string
+		prefijo  string
+		limite   int
+		esperado []uint
+	}{
+		{nombre: "inicio del texto ordenado por peso", prefijo: "ta", esperado: []uint{5, 1, 6}},
+		{nombre: "empates en orden de insercion", prefijo: "tacos", esperado: []uint{1, 6}},
+		{nombre: "palabra intermedia", prefijo: "bir", esperado: []uint{1}},
+		{nombre: "varias palabras", prefijo: "tacos al p", esperado: []uint{6}},
+		{nombre: "acentos y mayusculas", prefijo: "CAFÉ", esperado: []uint{4}},
+		{nombre: "acento en el texto", prefijo: "guera", esperado: []uint{3}},
+		{nombre: "las palabras vacias no inician sugerencias", prefijo: "de ", esperado: []uint{}},
+		{nombre: "espacio final no completa otra palabra", prefijo: "pozole ", esperado: []uint{2}},
+		{nombre: "sin espacio final completa", prefijo: "pozole", esperado: []uint{2, 3}},
+		{nombre: "limite", prefijo: "t", limite: 2, esperado: []uint{5, 1}},
+		{nombre: "limite mayor que las guardadas", prefijo: "t", limite: 10, esperado: []uint{5, 1, 6}},
+		{nombre: "prefijo vacio", prefijo: "  ", esperado: []uint{5, 1, 6}},
+		{nombre: "sin coincidencias", prefijo: "sushi", esperado: []uint{}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			obtenidos := make([]uint, 0)
+			for _, sugerencia := range trie.BuscarPrefijo(caso.prefijo, caso.limite) {
+				obtenidos = append(obtenidos, sugerencia.ID)
+			}
+			if !reflect.DeepEqual(obtenidos, caso.esperado) {
+				t.Fatalf("se obtuvo %v, se esperaba %v", obtenidos, caso.esperado)
+			}
+		})
+	}
+
+	if trie.Total() != 7 {
+		t.Fatalf("el trie tiene %d sugerencias, se esperaban 7", trie.Total())
+	}
+}
+
+// buscarPrefijoFuerzaBruta revisa cada sugerencia desde el inicio de cada palabra que no sea vacia
+func buscarPrefijoFuerzaBruta(sugerencias []Sugerencia, prefijo string, limite int) []Sugerencia {
+	normalizado := NormalizarPrefijo(prefijo)
+	var resultado []Sugerencia
+	for _, sugerencia := range sugerencias {
+		terminos := utils.Tokenizar(sugerencia.Texto)
+		for i := range terminos {
+			if (i == 0 || !palabrasVacias[terminos[i]]) && strings.HasPrefix(strings.Join(terminos[i:], " "), normalizado) {
+				resultado = append(resultado, sugerencia)
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(resultado, func(i, j int) bool {
+		return resultado[i].Peso > resultado[j].Peso
+	})
+	if len(resultado) > limite {
+		resultado = resultado[:limite]
+	}
+	return resultado
+}
+
+func TestBuscarPrefijoContraFuerzaBruta(t *testing.T) {
+	palabras := []string{"taco", "tacos", "tamal", "de", "la", "pozole", "pozoleria", "café", "Cafetería", "ñoño", "birria", "el", "tinga", "pastor", "al"}
+
+	casos := []struct {
+		nombre     string
+		maxPorNodo int
+		limite     int
+	}{
+		{nombre: "una por nodo", maxPorNodo: 1, limite: 1},
+		{nombre: "limite menor que el nodo", maxPorNodo: 5, limite: 3},
+		{nombre: "limite igual al nodo", maxPorNodo: 4, limite: 4},
+		{nombre: "muchas por nodo", maxPorNodo: 50, limite: 50},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			aleatorio := rand.New(rand.NewSource(37))
+			trie := NewTrie(caso.maxPorNodo)
+			var sugerencias []Sugerencia
+			for id := 1; id <= 60; id++ {
+				texto := make([]string, 1+aleatorio.Intn(4))
+				for i := range texto {
+					texto[i] = palabras[aleatorio.Intn(len(palabras))]
+				}
+				sugerencia := Sugerencia{ID: uint(id), Texto: strings.Join(texto, " "), Peso: float64(aleatorio.Intn(10))}
+				trie.Insertar(sugerencia)
+				sugerencias = append(sugerencias, sugerencia)
+			}
+
+			for intento := 0; intento < 300; intento++ {
+				texto := utils.NormalizarTexto(sugerencias[aleatorio.Intn(len(sugerencias))].Texto)
+				inicio := aleatorio.Intn(len(texto) + 1)
+				prefijo := texto[inicio:min(len(texto), inicio+aleatorio.Intn(12))]
+				if aleatorio.Intn(3) == 0 {
+					prefijo = strings.ToUpper(prefijo) + " "
+				}
+
+				esperado := buscarPrefijoFuerzaBruta(sugerencias, prefijo, min(caso.limite, caso.maxPorNodo))
+				obtenido := trie.BuscarPrefijo(prefijo, caso.limite)
+				if len(esperado) == 0 && len(obtenido) == 0 {
+					continue
+				}
+				if !reflect.DeepEqual(obtenido, esperado) {
+					t.Fatalf("prefijo %q: se obtuvo %v, se esperaba %v", prefijo, obtenido, esperado)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package algorithms
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"math/rand"
+	"time"
+)
+
+// maxEtiquetasPorEstado limita las etiquetas no dominadas que Held-Karp guarda por estado con ventanas;
+// si se alcanza el resultado deja de garantizarse optimo y se reporta como no exacto
+const maxEtiquetasPorEstado = 64
+
+// Intervalo es un periodo en minutos contados desde la salida del origen
+type Intervalo struct {
+	Inicio float64
+	Fin    float64
+}
+
+// VentanasTiempo describe cuando se puede visitar cada parada. Usa los mismos indices que la matriz
+// de costos: 0 es el origen y 1..n las paradas
+type VentanasTiempo struct {
+	Tiempos   [][]float64   // Minutos de trayecto entre nodos
+	Estancias []float64     // Minutos que se permanece en cada parada; el del origen se ignora
+	Ventanas  [][]Intervalo // Periodos de atencion ordenados; nil deja la parada sin restriccion
+	// MinimizarDuracion hace que el costo de una ruta sea el minuto en que termina, con esperas y
+	// estancias. La matriz de costos y los costos finales deben estar en los minutos de Tiempos
+	MinimizarDuracion bool
+}
+
+// Visita es el horario calculado para una parada, en minutos desde la salida
+type Visita struct {
+	Parada  int
+	Llegada float64
+	Inicio  float64 // Llegada mas la espera hasta que abra
+	Salida  float64
+}
+
+// Espera son los minutos entre la llegada y el inicio de la visita
+func (v Visita) Espera() float64 {
+	return v.Inicio - v.Llegada
+}
+
+// evaluacion compara rutas con ventanas: primero que se visite la ultima parada fija, despues las
+// paradas omitidas y al final el costo
+type evaluacion struct {
+	sinUltima bool
+	omitidas  int
+	costo     float64
+}
+
+func (e evaluacion) mejorQue(otra evaluacion) bool {
+	if e.sinUltima != otra.sinUltima {
+		return !e.sinUltima
+	}
+	if e.omitidas != otra.omitidas {
+		return e.omitidas < otra.omitidas
+	}
+	return e.costo < otra.costo-epsilonMejora
+}
+
+// etiqueta es una ruta parcial no dominada de Held-Karp con ventanas
+type etiqueta struct {
+	costo  float64
+	salida float64
+	previo int8  // Parada anterior (base 0), -1 si la ruta sale del origen
+	indice int32 // Etiqueta de la parada anterior en su estado
+}
+
+func (v *VentanasTiempo) validar(nodos int) error {
+	if len(v.Tiempos) != nodos || len(v.Estancias) != nodos || len(v.Ventanas) != nodos {
+		return errors.New("las ventanas de tiempo no corresponden con la matriz de costos")
+	}
+	for _, fila := range v.Tiempos {
+		if len(fila) != nodos {
+			return errors.New("la matriz de tiempos debe ser cuadrada")
+		}
+	}
+	return nil
+}
+
+// restringe indica si alguna parada tiene horario; sin horarios basta con ordenar por costo
+func (v *VentanasTiempo) restringe() bool {
+	for _, ventanas := range v.Ventanas[1:] {
+		if ventanas != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// inicioVisita retorna cuando empieza la visita si se llega a la parada en el minuto dado:
+// en la primera ventana en la que cabe toda la estancia, esperando a que abra si hace falta
+func (v *VentanasTiempo) inicioVisita(parada int, llegada float64) (float64, bool) {
+	if v.Ventanas[parada] == nil {
+		return llegada, true
+	}
+	for _, ventana := range v.Ventanas[parada] {
+		inicio := math.Max(llegada, ventana.Inicio)
+		if inicio+v.Estancias[parada] <= ventana.Fin {
+			return inicio, true
+		}
+	}
+	return 0, false
+}
+
+// costoEtiqueta es el costo de una ruta parcial que acumula costo y sale de su ultima parada en salida
+func (v *VentanasTiempo) costoEtiqueta(costo, salida float64) float64 {
+	if v.MinimizarDuracion {
+		return salida
+	}
+	return costo
+}
+
+// estancias suma los minutos que se permanece en las paradas dadas
+func (v *VentanasTiempo) estancias(paradas []int) float64 {
+	total := 0.0
+	for _, parada := range paradas {
+		total += v.Estancias[parada]
+	}
+	return total
+}
+
+// programar recorre las paradas en orden a la hora mas temprana posible; las que no caben
+// en su horario se omiten y la ruta sigue desde la ultima parada visitada
+func (v *VentanasTiempo) programar(p *problemaTour, orden []int) ([]Visita, []int, float64) {
+	agenda := make([]Visita, 0, len(orden))
+	var omitidas []int
+	costo, reloj, actual := 0.0, 0.0, 0
+
+	for _, parada := range orden {
+		llegada := reloj + v.Tiempos[actual][parada]
+		inicio, ok := v.inicioVisita(parada, llegada)
+		if !ok {
+			omitidas = append(omitidas, parada)
+			continue
+		}
+
+		reloj = inicio + v.Estancias[parada]
+		costo += p.costos[actual][parada]
+		agenda = append(agenda, Visita{Parada: parada, Llegada: llegada, Inicio: inicio, Salida: reloj})
+		actual = parada
+	}
+
+	return agenda, omitidas, v.costoEtiqueta(costo, reloj) + p.final[actual]
+}
+
+// optimizarConVentanas resuelve el tour cuando alguna parada tiene horario
+func (p *problemaTour) optimizarConVentanas(v *VentanasTiempo, limiteExacto int, presupuesto time.Duration) ResultadoTour {
+	var orden, omitidasExacto []int
+	metodo, exacto := MetodoHeuristico, false
+	if len(p.costos)-1 <= limiteExacto {
+		orden, omitidasExacto, exacto = p.heldKarpVentanas(v)
+		metodo = MetodoHeldKarp
+	} else {
+		orden = p.busquedaVentanas(v, time.Now().Add(presupuesto))
+	}
+
+	// Las omitidas de Held-Karp no se vuelven a intentar: alguna podria caber despues de la ultima parada fija
+	agenda, omitidas, costo := v.programar(p, orden)
+	if metodo == MetodoHeldKarp {
+		omitidas = omitidasExacto
+	}
+	visitadas := make([]int, len(agenda))
+	for i, visita := range agenda {
+		visitadas[i] = visita.Parada
+	}
+
+	cota := costo
+	if !exacto {
+		// Con duracion la cota de los trayectos se completa con las estancias, que no dependen del orden
+		fijo := 0.0
+		if v.MinimizarDuracion {
+			fijo = v.estancias(visitadas)
+		}
+		cota = math.Min(p.cotaSobreParadas(visitadas, costo-fijo)+fijo, costo)
+	}
+
+	return ResultadoTour{
+		Orden:        visitadas,
+		Costo:        costo,
+		CotaInferior: cota,
+		Metodo:       metodo,
+		Exacto:       exacto,
+		Agenda:       agenda,
+		Omitidas:     omitidas,
+	}
+}
+
+// heldKarpVentanas extiende Held-Karp a ventanas de tiempo. Cada estado (mascara, j) guarda las rutas
+// parciales no dominadas en costo y hora de salida, porque una ruta mas cara puede llegar a tiempo a
+// paradas que la mas barata ya no alcanza; al minimizar la duracion ambos coinciden y basta una.
+//
+// Elige la mascara con mas paradas y, entre ellas, la de menor costo con el tramo final. Las paradas
+// fijas se respetan si caben en su horario; si no, se omiten. Con ultima parada fija se prefieren las
+// rutas que terminan en ella, aunque visiten menos paradas, y solo si ninguna la alcanza se usan las demas.
+// Retorna el orden de las paradas programadas y las que quedan fuera
+func (p *problemaTour) heldKarpVentanas(v *VentanasTiempo) ([]int, []int, bool) {
+	n := len(p.costos) - 1
+	completo := 1 << n
+	etiquetas := make([][]etiqueta, completo*n)
+	exacto := true
+
+	agregar := func(estado int, nueva etiqueta) {
+		frente := etiquetas[estado]
+		for _, otra := range frente {
+			if otra.costo <= nueva.costo+epsilonMejora && otra.salida <= nueva.salida+epsilonMejora {
+				return
+			}
+		}
+		filtrado := frente[:0]
+		for _, otra := range frente {
+			if nueva.costo > otra.costo || nueva.salida > otra.salida {
+				filtrado = append(filtrado, otra)
+			}
+		}
+		if len(filtrado) >= maxEtiquetasPorEstado {
+			exacto = false
+			etiquetas[estado] = filtrado
+			return
+		}
+		etiquetas[estado] = append(filtrado, nueva)
+	}
+
+	// La primera parada fija abre la ruta si se puede visitar; si no, se omite y cualquier otra puede abrirla
+	primera := 0
+	if p.primera != 0 {
+		if _, ok := v.inicioVisita(p.primera, v.Tiempos[0][p.primera]); ok {
+			primera = p.primera
+		}
+	}
+	for j := 0; j < n; j++ {
+		if primera != 0 && primera != j+1 || primera == 0 && p.primera == j+1 {
+			continue
+		}
+		if inicio, ok := v.inicioVisita(j+1, v.Tiempos[0][j+1]); ok {
+			salida := inicio + v.Estancias[j+1]
+			agregar((1<<j)*n+j, etiqueta{costo: v.costoEtiqueta(p.costos[0][j+1], salida), salida: salida, previo: -1, indice: -1})
+		}
+	}
+
+	mejorMascara, mejorFinal, mejorIndice, mejorCosto := 0, -1, -1, math.Inf(1)
+	mejorEnUltima := false
+	for mascara := 1; mascara < completo; mascara++ {
+		for j := 0; j < n; j++ {
+			if mascara&(1<<j) == 0 {
+				continue
+			}
+			for indice, actual := range etiquetas[mascara*n+j] {
+				total := actual.costo + p.final[j+1]
+				enUltima := p.ultima == j+1
+				if mejorFinal < 0 || enUltima != mejorEnUltima && enUltima ||
+					enUltima == mejorEnUltima && mejorCierre(mascara, total, mejorMascara, mejorCosto) {
+					mejorMascara, mejorFinal, mejorIndice, mejorCosto = mascara, j, indice, total
+					mejorEnUltima = enUltima
+				}
+
+				// Una ruta que ya llego a la ultima parada fija no puede continuar
+				if p.ultima == j+1 {
+					continue
+				}
+				for k := 0; k < n; k++ {
+					// La primera parada fija solo puede ir al inicio
+					if mascara&(1<<k) != 0 || p.primera == k+1 {
+						continue
+					}
+					inicio, ok := v.inicioVisita(k+1, actual.salida+v.Tiempos[j+1][k+1])
+					if !ok {
+						continue
+					}
+					salida := inicio + v.Estancias[k+1]
+					agregar((mascara|1<<k)*n+k, etiqueta{
+						costo:  v.costoEtiqueta(actual.costo+p.costos[j+1][k+1], salida),
+						salida: salida,
+						previo: int8(j),
+						indice: int32(indice),
+					})
+				}
+			}
+		}
+	}
+
+	// Reconstruir el orden desde la ultima parada hacia atras
+	var orden, omitidas []int
+	for mascara, j, indice := mejorMascara, mejorFinal, mejorIndice; j >= 0; {
+		orden = append([]int{j + 1}, orden...)
+		actual := etiquetas[mascara*n+j][indice]
+		mascara &^= 1 << j
+		j, indice = int(actual.previo), int(actual.indice)
+	}
+	for j := 0; j < n; j++ {
+		if mejorMascara&(1<<j) == 0 {
+			omitidas = append(omitidas, j+1)
+		}
+	}
+
+	return orden, omitidas, exacto
+}
+
+// mejorCierre prefiere la ruta que visita mas paradas y, con las mismas, la de menor costo total
+func mejorCierre(mascara int, costo float64, otraMascara int, otroCosto float64) bool {
+	if a, b := bits.OnesCount(uint(mascara)), bits.OnesCount(uint(otraMascara)); a != b {
+		return a > b
+	}
+	return costo < otroCosto-epsilonMejora
+}
+
+// busquedaVentanas es la busqueda local iterada para tours grandes con horarios. Cada movimiento se
+// evalua programando la ruta completa, ya que cambiar una parada mueve la hora de todas las siguientes
+func (p *problemaTour) busquedaVentanas(v *VentanasTiempo, limite time.Time) []int {
+	evaluar := func(orden []int) evaluacion {
+		_, omitidas, costo := v.programar(p, orden)
+		sinUltima := false
+		for _, parada := range omitidas {
+			sinUltima = sinUltima || parada == p.ultima
+		}
+		return evaluacion{sinUltima: sinUltima, omitidas: len(omitidas), costo: costo}
+	}
+
+	mejor := p.inicialPorHorario(v)
+	desde, hasta := p.libres(len(mejor))
+	mejorarPorEvaluacion(mejor, desde, hasta, evaluar, limite)
+	mejorEvaluacion := evaluar(mejor)
+
+	aleatorio := rand.New(rand.NewSource(1))
+	actual, actualEvaluacion := append([]int(nil), mejor...), mejorEvaluacion
+	for hasta-desde >= 8 && time.Now().Before(limite) {
+		candidata := perturbar(actual, desde, hasta, aleatorio)
+		mejorarPorEvaluacion(candidata, desde, hasta, evaluar, limite)
+
+		evaluacionCandidata := evaluar(candidata)
+		if evaluacionCandidata.mejorQue(actualEvaluacion) {
+			actual, actualEvaluacion = candidata, evaluacionCandidata
+		}
+		if evaluacionCandidata.mejorQue(mejorEvaluacion) {
+			mejor, mejorEvaluacion = append([]int(nil), candidata...), evaluacionCandidata
+		}
+	}
+
+	return mejor
+}
+
+// inicialPorHorario arma la ruta inicial yendo siempre a la parada que se puede empezar a visitar
+// mas pronto; las que ya no caben en ningun horario quedan despues y las paradas fijas en sus extremos
+func (p *problemaTour) inicialPorHorario(v *VentanasTiempo) []int {
+	n := len(p.costos) - 1
+	pendiente := make([]bool, n+1)
+	for j := 1; j <= n; j++ {
+		pendiente[j] = j != p.primera && j != p.ultima
+	}
+
+	orden := make([]int, 0, n)
+	reloj, actual := 0.0, 0
+	if p.primera != 0 {
+		orden = append(orden, p.primera)
+		if inicio, ok := v.inicioVisita(p.primera, v.Tiempos[0][p.primera]); ok {
+			reloj, actual = inicio+v.Estancias[p.primera], p.primera
+		}
+	}
+
+	for {
+		siguiente, mejorInicio := -1, math.Inf(1)
+		for j := 1; j <= n; j++ {
+			if !pendiente[j] {
+				continue
+			}
+			inicio, ok := v.inicioVisita(j, reloj+v.Tiempos[actual][j])
+			if ok && (inicio < mejorInicio || inicio == mejorInicio && p.costos[actual][j] < p.costos[actual][siguiente]) {
+				siguiente, mejorInicio = j, inicio
+			}
+		}
+		if siguiente < 0 {
+			break
+		}
+
+		pendiente[siguiente] = false
+		orden = append(orden, siguiente)
+		reloj, actual = mejorInicio+v.Estancias[siguiente], siguiente
+	}
+
+	for j := 1; j <= n; j++ {
+		if pendiente[j] {
+			orden = append(orden, j)
+		}
+	}
+	if p.ultima != 0 && p.ultima != p.primera {
+		orden = append(orden, p.ultima)
+	}
+	return orden
+}
+
+// mejorarPorEvaluacion aplica el primer movimiento 2-opt u Or-opt dentro de orden[desde:hasta]
+// que mejore la evaluacion y repite hasta llegar a un optimo local o agotar el tiempo
+func mejorarPorEvaluacion(orden []int, desde, hasta int, evaluar func([]int) evaluacion, limite time.Time) {
+	actual := evaluar(orden)
+	candidata := make([]int, len(orden))
+
+	probar := func() bool {
+		if evaluacionCandidata := evaluar(candidata); evaluacionCandidata.mejorQue(actual) {
+			copy(orden, candidata)
+			actual = evaluacionCandidata
+			return true
+		}
+		return false
+	}
+
+	for mejoro := true; mejoro; {
+		mejoro = false
+
+		for i := desde; i < hasta-1 && !mejoro; i++ {
+			if time.Now().After(limite) {
+				return
+			}
+			for k := i + 1; k < hasta && !mejoro; k++ {
+				copy(candidata, orden)
+				for a, b := i, k; a < b; a, b = a+1, b-1 {
+					candidata[a], candidata[b] = candidata[b], candidata[a]
+				}
+				mejoro = probar()
+			}
+		}
+
+		for largo := 1; largo <= 3 && largo < hasta-desde && !mejoro; largo++ {
+			for i := desde; i+largo <= hasta && !mejoro; i++ {
+				if time.Now().After(limite) {
+					return
+				}
+				for q := desde - 1; q < hasta && !mejoro; q++ {
+					if q >= i-1 && q <= i+largo-1 {
+						continue
+					}
+					copy(candidata, orden)
+					moverBloque(candidata, i, largo, q)
+					mejoro = probar()
+				}
+			}
+		}
+	}
+}
+
+// cotaSobreParadas calcula la cota inferior de una ruta que visita solo las paradas dadas
+func (p *problemaTour) cotaSobreParadas(paradas []int, costo float64) float64 {
+	if len(paradas) < 2 {
+		return costo // Con una sola parada la ruta directa es la optima
+	}
+
+	nodos := append([]int{0}, paradas...)
+	sub := &problemaTour{costos: make([][]float64, len(nodos)), final: make([]float64, len(nodos))}
+	for i, a := range nodos {
+		sub.costos[i] = make([]float64, len(nodos))
+		for j, b := range nodos {
+			sub.costos[i][j] = p.costos[a][b]
+		}
+		sub.final[i] = p.final[a]
+	}
+	if paradas[0] == p.primera {
+		sub.primera = 1
+	}
+	if paradas[len(paradas)-1] == p.ultima {
+		sub.ultima = len(paradas)
+	}
+	return sub.cotaInferiorUnArbol(costo)
+}
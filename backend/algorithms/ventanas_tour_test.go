@@ -0,0 +1,287 @@
+package algorithms
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// ventanasAleatorias pone horario a cerca de la mitad de las paradas, a veces partido en dos turnos
+func ventanasAleatorias(aleatorio *rand.Rand, costos [][]float64, minimizarDuracion bool) *VentanasTiempo {
+	n := len(costos)
+	v := &VentanasTiempo{
+		Tiempos:           costos,
+		Estancias:         make([]float64, n),
+		Ventanas:          make([][]Intervalo, n),
+		MinimizarDuracion: minimizarDuracion,
+	}
+	for parada := 1; parada < n; parada++ {
+		v.Estancias[parada] = float64(5 + aleatorio.Intn(16))
+		if aleatorio.Intn(2) == 0 {
+			continue
+		}
+		inicio := float64(aleatorio.Intn(120))
+		v.Ventanas[parada] = []Intervalo{{Inicio: inicio, Fin: inicio + float64(20+aleatorio.Intn(40))}}
+		if aleatorio.Intn(2) == 0 {
+			segundo := v.Ventanas[parada][0].Fin + float64(10+aleatorio.Intn(60))
+			v.Ventanas[parada] = append(v.Ventanas[parada], Intervalo{Inicio: segundo, Fin: segundo + float64(20+aleatorio.Intn(40))})
+		}
+	}
+	return v
+}
+
+// mejorConVentanas es la mejor ruta por enumeracion: primero la que termina en la ultima parada fija,
+// despues la que visita mas paradas y al final la de menor costo
+type mejorConVentanas struct {
+	enUltima bool
+	paradas  int
+	costo    float64
+}
+
+func (m mejorConVentanas) superaA(otra mejorConVentanas) bool {
+	if m.enUltima != otra.enUltima {
+		return m.enUltima
+	}
+	if m.paradas != otra.paradas {
+		return m.paradas > otra.paradas
+	}
+	return m.costo < otra.costo-toleranciaCosto
+}
+
+// optimoVentanasFuerzaBruta recorre todas las secuencias de paradas que caben en su horario.
+// La primera parada fija solo puede abrir la ruta y, si no cabe al inicio, queda fuera;
+// la ultima parada fija cierra la ruta
+func optimoVentanasFuerzaBruta(p *problemaTour, v *VentanasTiempo) mejorConVentanas {
+	n := len(p.costos) - 1
+	mejor := mejorConVentanas{costo: v.costoEtiqueta(0, 0) + p.final[0]}
+
+	primeraAbre := false
+	if p.primera != 0 {
+		_, primeraAbre = v.inicioVisita(p.primera, v.Tiempos[0][p.primera])
+	}
+
+	var recorrer func(visitadas []bool, actual, paradas int, reloj, costo float64)
+	recorrer = func(visitadas []bool, actual, paradas int, reloj, costo float64) {
+		if paradas > 0 {
+			candidata := mejorConVentanas{
+				enUltima: p.ultima != 0 && actual == p.ultima,
+				paradas:  paradas,
+				costo:    v.costoEtiqueta(costo, reloj) + p.final[actual],
+			}
+			if candidata.superaA(mejor) {
+				mejor = candidata
+			}
+			if actual == p.ultima {
+				return
+			}
+		}
+
+		for k := 1; k <= n; k++ {
+			if visitadas[k] || paradas == 0 && primeraAbre && k != p.primera || k == p.primera && (paradas > 0 || !primeraAbre) {
+				continue
+			}
+			inicio, ok := v.inicioVisita(k, reloj+v.Tiempos[actual][k])
+			if !ok {
+				continue
+			}
+			visitadas[k] = true
+			recorrer(visitadas, k, paradas+1, inicio+v.Estancias[k], costo+p.costos[actual][k])
+			visitadas[k] = false
+		}
+	}
+	recorrer(make([]bool, n+1), 0, 0, 0, 0)
+
+	return mejor
+}
+
+// comprobarAgenda verifica que cada visita empiece dentro de un horario de su parada y que la
+// ruta siga el orden reportado
+func comprobarAgenda(t *testing.T, v *VentanasTiempo, resultado ResultadoTour) {
+	t.Helper()
+	if len(resultado.Agenda) != len(resultado.Orden) {
+		t.Fatalf("la agenda tiene %d visitas para %d paradas", len(resultado.Agenda), len(resultado.Orden))
+	}
+
+	reloj, actual := 0.0, 0
+	for i, visita := range resultado.Agenda {
+		if visita.Parada != resultado.Orden[i] {
+			t.Fatalf("la agenda no sigue el orden %v", resultado.Orden)
+		}
+		if math.Abs(visita.Llegada-(reloj+v.Tiempos[actual][visita.Parada])) > toleranciaCosto || visita.Espera() < 0 {
+			t.Fatalf("llegada incorrecta a la parada %d: %+v", visita.Parada, visita)
+		}
+		if ventanas := v.Ventanas[visita.Parada]; ventanas != nil {
+			dentro := false
+			for _, ventana := range ventanas {
+				dentro = dentro || visita.Inicio >= ventana.Inicio && visita.Inicio+v.Estancias[visita.Parada] <= ventana.Fin
+			}
+			if !dentro {
+				t.Fatalf("la visita a %d no cabe en su horario %v: %+v", visita.Parada, ventanas, visita)
+			}
+		}
+		reloj, actual = visita.Salida, visita.Parada
+	}
+}
+
+func evaluarResultado(p *problemaTour, resultado ResultadoTour) mejorConVentanas {
+	evaluacion := mejorConVentanas{paradas: len(resultado.Orden), costo: resultado.Costo}
+	if n := len(resultado.Orden); n > 0 && p.ultima != 0 {
+		evaluacion.enUltima = resultado.Orden[n-1] == p.ultima
+	}
+	return evaluacion
+}
+
+var casosVentanas = []struct {
+	nombre            string
+	primera           bool
+	ultima            bool
+	conRegreso        bool
+	minimizarDuracion bool
+}{
+	{nombre: "libre"},
+	{nombre: "libre minimizando duracion", minimizarDuracion: true},
+	{nombre: "primera fija", primera: true},
+	{nombre: "ultima fija", ultima: true},
+	{nombre: "ultima fija con regreso", ultima: true, conRegreso: true},
+	{nombre: "primera y ultima fijas", primera: true, ultima: true},
+	{nombre: "primera y ultima fijas minimizando duracion", primera: true, ultima: true, minimizarDuracion: true},
+}
+
+func TestHeldKarpVentanasContraFuerzaBruta(t *testing.T) {
+	for _, caso := range casosVentanas {
+		t.Run(caso.nombre, func(t *testing.T) {
+			aleatorio := rand.New(rand.NewSource(23))
+			for intento := 0; intento < 60; intento++ {
+				n := 2 + aleatorio.Intn(5)
+				costos := matrizAleatoria(aleatorio, n)
+				v := ventanasAleatorias(aleatorio, costos, caso.minimizarDuracion)
+				if !v.restringe() {
+					v.Ventanas[1] = []Intervalo{{Inicio: 30, Fin: 90}}
+				}
+				p := &problemaTour{costos: costos, final: make([]float64, n+1)}
+				if caso.conRegreso {
+					p.final = finalesAleatorios(aleatorio, n)
+				}
+				p.primera, p.ultima = fijasDeCaso(aleatorio, n, caso.primera, caso.ultima)
+
+				resultado, err := OptimizarTour(costos, OpcionesOptimizacion{
+					Ventanas:    v,
+					CostosFinal: p.final,
+					Primera:     p.primera,
+					Ultima:      p.ultima,
+				})
+				if err != nil {
+					t.Fatalf("error inesperado: %v", err)
+				}
+
+				optimo := optimoVentanasFuerzaBruta(p, v)
+				obtenido := evaluarResultado(p, resultado)
+				if !resultado.Exacto || resultado.Metodo != MetodoHeldKarp {
+					t.Fatalf("se esperaba Held-Karp exacto, se obtuvo %s", resultado.Metodo)
+				}
+				if obtenido.enUltima != optimo.enUltima || obtenido.paradas != optimo.paradas ||
+					math.Abs(obtenido.costo-optimo.costo) > toleranciaCosto {
+					t.Fatalf("n=%d primera=%d ultima=%d: se obtuvo %+v con orden %v, el optimo es %+v",
+						n, p.primera, p.ultima, obtenido, resultado.Orden, optimo)
+				}
+				if !esPermutacion(n, resultado.Orden, resultado.Omitidas) {
+					t.Fatalf("orden %v y omitidas %v no cubren las paradas", resultado.Orden, resultado.Omitidas)
+				}
+				for _, parada := range resultado.Orden[min(1, len(resultado.Orden)):] {
+					if parada == p.primera {
+						t.Fatalf("la primera parada fija %d aparece a mitad del orden %v", p.primera, resultado.Orden)
+					}
+				}
+				if resultado.CotaInferior != resultado.Costo {
+					t.Fatalf("la cota de una ruta exacta debe ser su costo")
+				}
+				comprobarAgenda(t, v, resultado)
+			}
+		})
+	}
+}
+
+func TestBusquedaVentanasNoSuperaAlOptimo(t *testing.T) {
+	for _, caso := range casosVentanas {
+		t.Run(caso.nombre, func(t *testing.T) {
+			aleatorio := rand.New(rand.NewSource(29))
+			for intento := 0; intento < 10; intento++ {
+				n := 2 + aleatorio.Intn(5)
+				costos := matrizAleatoria(aleatorio, n)
+				v := ventanasAleatorias(aleatorio, costos, caso.minimizarDuracion)
+				if !v.restringe() {
+					v.Ventanas[1] = []Intervalo{{Inicio: 30, Fin: 90}}
+				}
+				p := &problemaTour{costos: costos, final: make([]float64, n+1)}
+				if caso.conRegreso {
+					p.final = finalesAleatorios(aleatorio, n)
+				}
+				p.primera, p.ultima = fijasDeCaso(aleatorio, n, caso.primera, caso.ultima)
+
+				resultado, err := OptimizarTour(costos, OpcionesOptimizacion{
+					MaxParadasExacto: 1,
+					Presupuesto:      10 * time.Millisecond,
+					Ventanas:         v,
+					CostosFinal:      p.final,
+					Primera:          p.primera,
+					Ultima:           p.ultima,
+				})
+				if err != nil {
+					t.Fatalf("error inesperado: %v", err)
+				}
+
+				optimo := optimoVentanasFuerzaBruta(p, v)
+				if resultado.Metodo != MetodoHeuristico {
+					t.Fatalf("se esperaba el metodo heuristico, se obtuvo %s", resultado.Metodo)
+				}
+				if evaluarResultado(p, resultado).superaA(optimo) {
+					t.Fatalf("el resultado %+v supera al optimo %+v", evaluarResultado(p, resultado), optimo)
+				}
+				if !esPermutacion(n, resultado.Orden, resultado.Omitidas) {
+					t.Fatalf("orden %v y omitidas %v no cubren las paradas", resultado.Orden, resultado.Omitidas)
+				}
+				if resultado.CotaInferior > resultado.Costo+toleranciaCosto {
+					t.Fatalf("cota %v mayor que el costo %v", resultado.CotaInferior, resultado.Costo)
+				}
+				comprobarAgenda(t, v, resultado)
+			}
+		})
+	}
+}
+
+func TestInicioVisita(t *testing.T) {
+	v := &VentanasTiempo{
+		Estancias: []float64{0, 30, 30},
+		Ventanas: [][]Intervalo{
+			nil,
+			nil,
+			{{Inicio: 60, Fin: 180}, {Inicio: 300, Fin: 480}}, // Turno partido
+		},
+	}
+
+	casos := []struct {
+		nombre  string
+		parada  int
+		llegada float64
+		inicio  float64
+		ok      bool
+	}{
+		{nombre: "sin horario", parada: 1, llegada: 17, inicio: 17, ok: true},
+		{nombre: "espera a que abra", parada: 2, llegada: 10, inicio: 60, ok: true},
+		{nombre: "dentro del primer turno", parada: 2, llegada: 100, inicio: 100, ok: true},
+		{nombre: "la estancia no cabe y espera al segundo turno", parada: 2, llegada: 160, inicio: 300, ok: true},
+		{nombre: "justo al cierre del primer turno", parada: 2, llegada: 150, inicio: 150, ok: true},
+		{nombre: "entre turnos", parada: 2, llegada: 200, inicio: 300, ok: true},
+		{nombre: "despues del ultimo turno", parada: 2, llegada: 460, ok: false},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			inicio, ok := v.inicioVisita(caso.parada, caso.llegada)
+			if ok != caso.ok || ok && inicio != caso.inicio {
+				t.Fatalf("se obtuvo (%v, %v), se esperaba (%v, %v)", inicio, ok, caso.inicio, caso.ok)
+			}
+		})
+	}
+}
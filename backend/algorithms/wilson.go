@@ -0,0 +1,23 @@
+package algorithms
+
+import "math"
+
+// zConfianza95 es el valor z de la normal estandar para un intervalo de confianza del 95%
+const zConfianza95 = 1.96
+
+// LimiteInferiorWilson calcula el limite inferior del intervalo de Wilson para una proporcion
+// Con pocos votos el limite es bajo, asi una reseña con 1 de 1 no supera a una con 90 de 100
+func LimiteInferiorWilson(positivos, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+
+	n := float64(total)
+	p := float64(positivos) / n
+	z2 := zConfianza95 * zConfianza95
+
+	centro := p + z2/(2*n)
+	margen := zConfianza95 * math.Sqrt((p*(1-p)+z2/(4*n))/n)
+
+	return (centro - margen) / (1 + z2/n)
+}
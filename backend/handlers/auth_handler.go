@@ -333,3 +333,40 @@ func (ah *AuthHandler) VerificarToken(c *gin.Context) {
 	c.Set("userID", userID)
 	c.Next()
 }
+
+// IdentificarUsuario middleware para rutas publicas: si hay un token valido guarda el usuario
+// en el contexto, pero nunca rechaza la peticion
+func (ah *AuthHandler) IdentificarUsuario(c *gin.Context) {
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) == 2 && parts[0] == "Bearer" {
+		if userID, err := ah.authService.ValidarToken(parts[1]); err == nil {
+			c.Set("userID", userID)
+		}
+	}
+	c.Next()
+}
+
+// VerificarAdmin middleware que restringe rutas a administradores; requiere VerificarToken antes
+func (ah *AuthHandler) VerificarAdmin(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		c.Abort()
+		return
+	}
+
+	esAdmin, err := ah.authService.EsAdministrador(userID.(uint))
+	if err != nil || !esAdmin {
+		c.JSON(http.StatusForbidden, ErrorResponse{
+			Error:   "forbidden",
+			Message: "Se requieren permisos de administrador",
+		})
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
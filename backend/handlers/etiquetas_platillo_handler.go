@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/services"
+)
+
+// EtiquetasPlatilloHandler maneja las etiquetas de dieta y alergenos de los platillos
+type EtiquetasPlatilloHandler struct {
+	etiquetasService *services.EtiquetasPlatilloService
+}
+
+// NewEtiquetasPlatilloHandler crea una nueva instancia del handler
+func NewEtiquetasPlatilloHandler(etiquetasService *services.EtiquetasPlatilloService) *EtiquetasPlatilloHandler {
+	return &EtiquetasPlatilloHandler{etiquetasService: etiquetasService}
+}
+
+// AsignarEtiquetasRequest contiene las etiquetas que el propietario asigna al platillo
+type AsignarEtiquetasRequest struct {
+	Etiquetas []string `json:"etiquetas"`
+}
+
+// ObtenerCatalogoEtiquetas devuelve las etiquetas disponibles con su nombre y tipo
+func (eh *EtiquetasPlatilloHandler) ObtenerCatalogoEtiquetas(c *gin.Context) {
+	catalogo := eh.etiquetasService.ObtenerCatalogo()
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    catalogo,
+		"total":   len(catalogo),
+		"message": "Etiquetas obtenidas exitosamente",
+	})
+}
+
+// AsignarEtiquetas reemplaza las etiquetas manuales del platillo por las indicadas por el propietario
+// Los alergenos inferidos de los ingredientes se conservan; una lista vacia deja solo esos
+func (eh *EtiquetasPlatilloHandler) AsignarEtiquetas(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idPlatillo, ok := obtenerIDPlatillo(c)
+	if !ok {
+		return
+	}
+
+	var req AsignarEtiquetasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	platillo, err := eh.etiquetasService.AsignarEtiquetas(userID.(uint), idPlatillo, req.Etiquetas)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "update_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    platillo,
+		"message": "Etiquetas asignadas exitosamente",
+	})
+}
+
+// RestablecerEtiquetas descarta las etiquetas manuales y vuelve a inferir los alergenos de los ingredientes
+func (eh *EtiquetasPlatilloHandler) RestablecerEtiquetas(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idPlatillo, ok := obtenerIDPlatillo(c)
+	if !ok {
+		return
+	}
+
+	platillo, err := eh.etiquetasService.RestablecerEtiquetas(userID.(uint), idPlatillo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "update_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    platillo,
+		"message": "Etiquetas restablecidas a los alergenos inferidos de los ingredientes",
+	})
+}
+
+// obtenerIDPlatillo lee el parametro :id; si es invalido responde 400 y retorna false
+func obtenerIDPlatillo(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de platillo invalido",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
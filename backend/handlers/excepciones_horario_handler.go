@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/services"
+)
+
+// ExcepcionesHorarioHandler maneja los cierres y horarios especiales por fecha de un restaurante
+type ExcepcionesHorarioHandler struct {
+	excepcionesService *services.ExcepcionesHorarioService
+}
+
+// NewExcepcionesHorarioHandler crea una nueva instancia del handler
+func NewExcepcionesHorarioHandler(excepcionesService *services.ExcepcionesHorarioService) *ExcepcionesHorarioHandler {
+	return &ExcepcionesHorarioHandler{excepcionesService: excepcionesService}
+}
+
+// ExcepcionHorarioRequest describe un cierre o un horario especial
+// Si cerrado se omite, la excepcion es un cierre cuando no trae apertura ni cierre
+type ExcepcionHorarioRequest struct {
+	FechaInicio     string `json:"fechaInicio" binding:"required"` // 2006-01-02
+	FechaFin        string `json:"fechaFin,omitempty"`             // Vacia para un solo dia
+	Cerrado         *bool  `json:"cerrado,omitempty"`
+	Apertura        string `json:"apertura,omitempty"` // 15:04
+	Cierre          string `json:"cierre,omitempty"`   // Menor o igual a la apertura termina al dia siguiente
+	RecurrenteAnual bool   `json:"recurrenteAnual,omitempty"`
+	Motivo          string `json:"motivo,omitempty"`
+}
+
+func (req ExcepcionHorarioRequest) datos() services.DatosExcepcionHorario {
+	cerrado := req.Apertura == "" && req.Cierre == ""
+	if req.Cerrado != nil {
+		cerrado = *req.Cerrado
+	}
+
+	return services.DatosExcepcionHorario{
+		FechaInicio:     req.FechaInicio,
+		FechaFin:        req.FechaFin,
+		Cerrado:         cerrado,
+		Apertura:        req.Apertura,
+		Cierre:          req.Cierre,
+		RecurrenteAnual: req.RecurrenteAnual,
+		Motivo:          req.Motivo,
+	}
+}
+
+// ObtenerExcepciones lista las excepciones vigentes del restaurante; ?incluirPasadas=true agrega las pasadas
+func (eh *ExcepcionesHorarioHandler) ObtenerExcepciones(c *gin.Context) {
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	excepciones, err := eh.excepcionesService.ObtenerExcepciones(idRestaurante, c.Query("incluirPasadas") == "true")
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    excepciones,
+		"total":   len(excepciones),
+		"message": "Excepciones de horario obtenidas exitosamente",
+	})
+}
+
+// CrearExcepcion registra un cierre u horario especial del restaurante
+func (eh *ExcepcionesHorarioHandler) CrearExcepcion(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	var req ExcepcionHorarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	excepcion, err := eh.excepcionesService.CrearExcepcion(userID.(uint), idRestaurante, req.datos())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "create_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    excepcion,
+		"message": "Excepcion de horario registrada exitosamente",
+	})
+}
+
+// ActualizarExcepcion reemplaza los datos de una excepcion del restaurante
+func (eh *ExcepcionesHorarioHandler) ActualizarExcepcion(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+	idExcepcion, ok := obtenerIDExcepcion(c)
+	if !ok {
+		return
+	}
+
+	var req ExcepcionHorarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	excepcion, err := eh.excepcionesService.ActualizarExcepcion(userID.(uint), idRestaurante, idExcepcion, req.datos())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "update_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    excepcion,
+		"message": "Excepcion de horario actualizada exitosamente",
+	})
+}
+
+// EliminarExcepcion borra una excepcion del restaurante
+func (eh *ExcepcionesHorarioHandler) EliminarExcepcion(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+	idExcepcion, ok := obtenerIDExcepcion(c)
+	if !ok {
+		return
+	}
+
+	if err := eh.excepcionesService.EliminarExcepcion(userID.(uint), idRestaurante, idExcepcion); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "delete_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Excepcion de horario eliminada exitosamente",
+	})
+}
+
+// obtenerIDExcepcion lee el parametro :idExcepcion; si es invalido responde 400 y retorna false
+func obtenerIDExcepcion(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("idExcepcion"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de excepcion invalido",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
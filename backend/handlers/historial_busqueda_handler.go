@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/services"
+)
+
+// HistorialBusquedaHandler maneja el historial de busquedas y las busquedas populares
+type HistorialBusquedaHandler struct {
+	historialService *services.HistorialBusquedaService
+}
+
+// NewHistorialBusquedaHandler crea una nueva instancia del handler
+func NewHistorialBusquedaHandler(historialService *services.HistorialBusquedaService) *HistorialBusquedaHandler {
+	return &HistorialBusquedaHandler{historialService: historialService}
+}
+
+// ObtenerBusquedasRecientes lista las ultimas busquedas del usuario autenticado
+func (hh *HistorialBusquedaHandler) ObtenerBusquedasRecientes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	limite, _ := strconv.Atoi(c.Query("limite"))
+
+	busquedas, err := hh.historialService.ObtenerBusquedasRecientes(userID.(uint), limite)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "Error al obtener busquedas recientes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    busquedas,
+		"total":   len(busquedas),
+		"message": "Busquedas recientes obtenidas exitosamente",
+	})
+}
+
+// EliminarBusquedaReciente quita una busqueda (y sus repeticiones) del historial del usuario
+func (hh *HistorialBusquedaHandler) EliminarBusquedaReciente(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idBusqueda, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de busqueda invalido",
+		})
+		return
+	}
+
+	if err := hh.historialService.EliminarBusquedaReciente(userID.(uint), uint(idBusqueda)); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Busqueda eliminada del historial",
+	})
+}
+
+// LimpiarBusquedasRecientes vacia el historial de busquedas del usuario
+func (hh *HistorialBusquedaHandler) LimpiarBusquedasRecientes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	total, err := hh.historialService.LimpiarBusquedasRecientes(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "delete_failed",
+			Message: "Error al limpiar el historial de busquedas",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   total,
+		"message": "Historial de busquedas eliminado",
+	})
+}
+
+// ObtenerBusquedasPopulares lista lo mas buscado en los ultimos 7 dias, opcionalmente por ciudad
+func (hh *HistorialBusquedaHandler) ObtenerBusquedasPopulares(c *gin.Context) {
+	var idCiudad *uint
+	if valor := c.Query("idCiudad"); valor != "" {
+		id, err := strconv.ParseUint(valor, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_id",
+				Message: "ID de ciudad invalido",
+			})
+			return
+		}
+		ciudad := uint(id)
+		idCiudad = &ciudad
+	}
+
+	limite, _ := strconv.Atoi(c.Query("limite"))
+
+	populares, err := hh.historialService.ObtenerBusquedasPopulares(idCiudad, limite)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "Error al obtener busquedas populares",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    populares,
+		"total":   len(populares),
+		"message": "Busquedas populares obtenidas exitosamente",
+	})
+}
+
+// usuarioOpcional retorna el usuario identificado en rutas publicas, o nil si la peticion es anonima
+func usuarioOpcional(c *gin.Context) *uint {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return nil
+	}
+	id := userID.(uint)
+	return &id
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/models"
+)
+
+// ImagenReseñaResponse expone una foto adjunta a una reseña
+type ImagenReseñaResponse struct {
+	IDImagen     uint   `json:"idImagen"`
+	URL          string `json:"url"`
+	Aprobaciones int    `json:"aprobaciones"`
+	Promovida    bool   `json:"promovida"`
+	FechaSubida  string `json:"fechaSubida"`
+}
+
+// SubirImagenesReseña adjunta fotos a la reseña del usuario autenticado
+// Acepta multipart/form-data con uno o varios archivos en el campo "imagenes" (o "imagen")
+func (rh *ReseñaHandler) SubirImagenesReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "missing_file",
+			Message: "No se encontraron imagenes en la peticion",
+		})
+		return
+	}
+
+	var archivos []*multipart.FileHeader
+	archivos = append(archivos, form.File["imagenes"]...)
+	archivos = append(archivos, form.File["imagen"]...)
+	if len(archivos) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "missing_file",
+			Message: "No se encontro el campo 'imagenes' en la peticion",
+		})
+		return
+	}
+
+	imagenes, err := rh.reseñaService.AgregarImagenesReseña(userID.(uint), idReseña, archivos)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "upload_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    nuevasImagenesReseñaResponse(imagenes),
+		"total":   len(imagenes),
+		"message": "Fotos agregadas exitosamente",
+	})
+}
+
+// EliminarImagenReseña quita una foto de la reseña del usuario autenticado
+func (rh *ReseñaHandler) EliminarImagenReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	idImagen, ok := obtenerIDImagen(c)
+	if !ok {
+		return
+	}
+
+	if err := rh.reseñaService.EliminarImagenReseña(userID.(uint), idReseña, idImagen); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "delete_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Foto eliminada exitosamente",
+	})
+}
+
+// AprobarImagenReseña registra la aprobacion de una foto por el usuario autenticado
+func (rh *ReseñaHandler) AprobarImagenReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	idImagen, ok := obtenerIDImagen(c)
+	if !ok {
+		return
+	}
+
+	imagen, galeria, err := rh.reseñaService.AprobarImagenReseña(userID.(uint), idReseña, idImagen)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "approval_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	respuesta := gin.H{
+		"data":    nuevaImagenReseñaResponse(*imagen),
+		"message": "Aprobacion registrada",
+	}
+	if galeria != nil {
+		respuesta["galeria"] = galeria
+		respuesta["message"] = "Aprobacion registrada; la foto ahora forma parte de la galeria del restaurante"
+	}
+
+	c.JSON(http.StatusOK, respuesta)
+}
+
+// obtenerIDImagen lee el parametro :idImagen y responde con error si es invalido
+func obtenerIDImagen(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("idImagen"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de imagen invalido",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func nuevaImagenReseñaResponse(imagen models.ImagenReseña) ImagenReseñaResponse {
+	return ImagenReseñaResponse{
+		IDImagen:     imagen.IDImagen,
+		URL:          imagen.URL,
+		Aprobaciones: imagen.Aprobaciones,
+		Promovida:    imagen.Promovida,
+		FechaSubida:  imagen.FechaSubida.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func nuevasImagenesReseñaResponse(imagenes []models.ImagenReseña) []ImagenReseñaResponse {
+	data := make([]ImagenReseñaResponse, 0, len(imagenes))
+	for _, imagen := range imagenes {
+		data = append(data, nuevaImagenReseñaResponse(imagen))
+	}
+	return data
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/repository"
+	"github.com/tuusuario/quovi/services"
+)
+
+// MapaHandler maneja las consultas del mapa del dashboard
+type MapaHandler struct {
+	mapaService *services.MapaService
+}
+
+// NewMapaHandler crea una nueva instancia del handler
+func NewMapaHandler(mapaService *services.MapaService) *MapaHandler {
+	return &MapaHandler{mapaService: mapaService}
+}
+
+// ObtenerMapa retorna los restaurantes del area visible del mapa
+// Requiere minLat, minLng, maxLat, maxLng y zoom; acepta idCategoria, calificacionMin y precioMax
+func (mh *MapaHandler) ObtenerMapa(c *gin.Context) {
+	var caja repository.CajaCoordenadas
+	coordenadas := []struct {
+		nombre  string
+		destino *float64
+	}{
+		{"minLat", &caja.MinLat},
+		{"minLng", &caja.MinLng},
+		{"maxLat", &caja.MaxLat},
+		{"maxLng", &caja.MaxLng},
+	}
+	for _, coordenada := range coordenadas {
+		valor, err := strconv.ParseFloat(c.Query(coordenada.nombre), 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error:   "invalid_bounds",
+				Message: "Parametro " + coordenada.nombre + " requerido y numerico",
+			})
+			return
+		}
+		*coordenada.destino = valor
+	}
+
+	zoom, err := strconv.Atoi(c.Query("zoom"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_zoom",
+			Message: "Parametro zoom requerido y entero",
+		})
+		return
+	}
+
+	filtros := services.FiltrosMapa{Caja: caja, Zoom: zoom}
+	for _, valor := range c.QueryArray("idCategoria") {
+		if id, err := strconv.ParseUint(valor, 10, 32); err == nil {
+			filtros.IDCategorias = append(filtros.IDCategorias, uint(id))
+		}
+	}
+	if valor, err := strconv.ParseFloat(c.Query("calificacionMin"), 64); err == nil {
+		filtros.CalificacionMin = &valor
+	}
+	if valor, err := strconv.ParseFloat(c.Query("precioMax"), 64); err == nil {
+		filtros.PrecioMax = &valor
+	}
+
+	resultado, err := mh.mapaService.ObtenerMapa(filtros)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "map_failed",
+			Message: "Error al obtener el mapa: " + err.Error(),
+		})
+		return
+	}
+
+	var data interface{} = resultado.Restaurantes
+	if resultado.Tipo == services.TipoMapaGrupos {
+		data = resultado.Grupos
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    data,
+		"tipo":    resultado.Tipo,
+		"total":   resultado.Total,
+		"zoom":    resultado.Zoom,
+		"message": "Mapa obtenido exitosamente",
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/services"
+)
+
+// ModeracionHandler maneja reportes de reseñas y la cola de moderacion
+type ModeracionHandler struct {
+	moderacionService *services.ModeracionService
+}
+
+// NewModeracionHandler crea una nueva instancia del handler
+func NewModeracionHandler(moderacionService *services.ModeracionService) *ModeracionHandler {
+	return &ModeracionHandler{moderacionService: moderacionService}
+}
+
+// Estructuras de peticion
+type ReportarReseñaRequest struct {
+	Motivo      string `json:"motivo" binding:"required"`
+	Descripcion string `json:"descripcion"`
+}
+
+type CambiarEstadoReseñaRequest struct {
+	Estado string `json:"estado" binding:"required"`
+}
+
+// Estructuras de respuesta
+type ElementoModeracionResponse struct {
+	ReseñaResponse
+	ReportesPendientes int64 `json:"reportesPendientes"`
+}
+
+// ReportarReseña registra un reporte del usuario autenticado sobre una reseña
+func (mh *ModeracionHandler) ReportarReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	var req ReportarReseñaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	reporte, err := mh.moderacionService.ReportarReseña(userID.(uint), idReseña, req.Motivo, req.Descripcion)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "report_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    reporte,
+		"message": "Reporte enviado, gracias por ayudarnos a moderar",
+	})
+}
+
+// ObtenerColaModeracion lista las reseñas que requieren revision
+func (mh *ModeracionHandler) ObtenerColaModeracion(c *gin.Context) {
+	pagina, _ := strconv.Atoi(c.DefaultQuery("pagina", "1"))
+	limite, _ := strconv.Atoi(c.Query("limite"))
+	pagina, limite = services.NormalizarPaginacion(pagina, limite)
+
+	cola, total, err := mh.moderacionService.ObtenerColaModeracion(c.Query("estado"), pagina, limite)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "Error al obtener cola de moderacion: " + err.Error(),
+		})
+		return
+	}
+
+	data := make([]ElementoModeracionResponse, 0, len(cola))
+	for _, elemento := range cola {
+		data = append(data, ElementoModeracionResponse{
+			ReseñaResponse:     nuevaReseñaResponse(elemento.Reseña),
+			ReportesPendientes: elemento.ReportesPendientes,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    data,
+		"total":   total,
+		"pagina":  pagina,
+		"limite":  limite,
+		"message": "Cola de moderacion obtenida exitosamente",
+	})
+}
+
+// ObtenerReportes lista los reportes recibidos por una reseña
+func (mh *ModeracionHandler) ObtenerReportes(c *gin.Context) {
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	reportes, err := mh.moderacionService.ObtenerReportes(idReseña)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "Error al obtener reportes: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    reportes,
+		"total":   len(reportes),
+		"message": "Reportes obtenidos exitosamente",
+	})
+}
+
+// CambiarEstadoReseña aplica una decision de moderacion sobre una reseña
+func (mh *ModeracionHandler) CambiarEstadoReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	var req CambiarEstadoReseñaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	reseña, err := mh.moderacionService.CambiarEstadoReseña(userID.(uint), idReseña, req.Estado)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "moderation_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    nuevaReseñaResponse(*reseña),
+		"message": "Estado de la reseña actualizado",
+	})
+}
+
+// obtenerIDReseña lee el parametro :id y responde con error si es invalido
+func obtenerIDReseña(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de reseña invalido",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/services"
+)
+
+// NotificacionHandler maneja las rutas de notificaciones del usuario
+type NotificacionHandler struct {
+	notificacionService *services.NotificacionService
+}
+
+// NewNotificacionHandler crea una nueva instancia del handler
+func NewNotificacionHandler(notificacionService *services.NotificacionService) *NotificacionHandler {
+	return &NotificacionHandler{notificacionService: notificacionService}
+}
+
+// ObtenerNotificaciones lista las notificaciones del usuario autenticado
+func (nh *NotificacionHandler) ObtenerNotificaciones(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	pagina, _ := strconv.Atoi(c.DefaultQuery("pagina", "1"))
+	limite, _ := strconv.Atoi(c.Query("limite"))
+	pagina, limite = services.NormalizarPaginacion(pagina, limite)
+
+	notificaciones, total, noLeidas, err := nh.notificacionService.ObtenerNotificaciones(
+		userID.(uint), pagina, limite, c.Query("noLeidas") == "true",
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "Error al obtener notificaciones",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     notificaciones,
+		"total":    total,
+		"noLeidas": noLeidas,
+		"pagina":   pagina,
+		"limite":   limite,
+		"message":  "Notificaciones obtenidas exitosamente",
+	})
+}
+
+// MarcarNotificacionLeida marca una notificacion como leida
+func (nh *NotificacionHandler) MarcarNotificacionLeida(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idNotificacion, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de notificacion invalido",
+		})
+		return
+	}
+
+	notificacion, err := nh.notificacionService.MarcarLeida(userID.(uint), uint(idNotificacion))
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    notificacion,
+		"message": "Notificacion marcada como leida",
+	})
+}
+
+// MarcarTodasLeidas marca como leidas todas las notificaciones del usuario
+func (nh *NotificacionHandler) MarcarTodasLeidas(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	actualizadas, err := nh.notificacionService.MarcarTodasLeidas(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "update_failed",
+			Message: "Error al actualizar notificaciones",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total":   actualizadas,
+		"message": "Notificaciones marcadas como leidas",
+	})
+}
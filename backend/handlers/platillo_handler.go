@@ -1,22 +1,44 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/models"
 	"github.com/tuusuario/quovi/services"
 )
 
 type PlatilloHandler struct {
-	platilloService *services.PlatilloService
+	platilloService  *services.PlatilloService
+	historialService *services.HistorialBusquedaService
 }
 
-func NewPlatilloHandler(platilloService *services.PlatilloService) *PlatilloHandler {
-	return &PlatilloHandler{platilloService: platilloService}
+func NewPlatilloHandler(platilloService *services.PlatilloService, historialService *services.HistorialBusquedaService) *PlatilloHandler {
+	return &PlatilloHandler{
+		platilloService:  platilloService,
+		historialService: historialService,
+	}
+}
+
+// BuscarPlatillosRequest contiene los filtros de la busqueda por platillo
+type BuscarPlatillosRequest struct {
+	Termino          string   `json:"termino" binding:"required"`
+	PrecioMax        *float64 `json:"precioMax,omitempty"`
+	Etiquetas        []string `json:"etiquetas,omitempty"`        // Ej. ["vegano"]
+	ExcluirEtiquetas []string `json:"excluirEtiquetas,omitempty"` // Ej. ["contiene_cacahuate"]
+	IDCiudad         *uint    `json:"idCiudad,omitempty"`
+	AbiertoAhora     bool     `json:"abiertoAhora,omitempty"`
+	Latitud          *float64 `json:"latitud,omitempty"`
+	Longitud         *float64 `json:"longitud,omitempty"`
+	Radio            float64  `json:"radio,omitempty"`
+	Limite           int      `json:"limite,omitempty"`
 }
 
-// ObtenerPlatillosPorRestaurante devuelve todos los platillos de un restaurante
+// ObtenerPlatillosPorRestaurante devuelve los platillos de un restaurante con sus etiquetas
+// Acepta etiqueta y excluir (repetibles) para filtrar, por ejemplo ?excluir=contiene_cacahuate
 func (ph *PlatilloHandler) ObtenerPlatillosPorRestaurante(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -28,11 +50,19 @@ func (ph *PlatilloHandler) ObtenerPlatillosPorRestaurante(c *gin.Context) {
 		return
 	}
 
-	platillos, err := ph.platilloService.ObtenerPlatillosPorRestaurante(uint(id))
+	platillos, err := ph.platilloService.ObtenerPlatillosPorRestaurante(uint(id), c.QueryArray("etiqueta"), c.QueryArray("excluir"))
+	if errors.Is(err, services.ErrEtiquetaInvalida) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
 	if err != nil {
+		log.Printf("Error al obtener platillos del restaurante %d: %v", id, err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "fetch_failed",
-			Message: "Error al obtener platillos: " + err.Error(),
+			Message: "Error al obtener platillos",
 		})
 		return
 	}
@@ -71,3 +101,74 @@ func (ph *PlatilloHandler) ObtenerPlatilloDestacados(c *gin.Context) {
 		"message": "Platillos destacados obtenidos exitosamente",
 	})
 }
+
+// BuscarPlatillos busca lo que el usuario quiere comer y devuelve los restaurantes que lo sirven
+// junto con los platillos que coincidieron y sus precios
+func (ph *PlatilloHandler) BuscarPlatillos(c *gin.Context) {
+	var req BuscarPlatillosRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos de busqueda invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	// Validar coordenadas si se proporcionan
+	if req.Latitud != nil && (*req.Latitud < -90 || *req.Latitud > 90) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_latitude",
+			Message: "Latitud debe estar entre -90 y 90",
+		})
+		return
+	}
+
+	if req.Longitud != nil && (*req.Longitud < -180 || *req.Longitud > 180) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_longitude",
+			Message: "Longitud debe estar entre -180 y 180",
+		})
+		return
+	}
+
+	// Limitar el radio maximo igual que en la busqueda de cercanos
+	if req.Radio > services.RadioMaximoPlatillosKm {
+		req.Radio = services.RadioMaximoPlatillosKm
+	}
+
+	restaurantes, err := ph.platilloService.BuscarPlatillos(services.FiltrosBusquedaPlatillos{
+		Termino:          req.Termino,
+		PrecioMax:        req.PrecioMax,
+		Etiquetas:        req.Etiquetas,
+		ExcluirEtiquetas: req.ExcluirEtiquetas,
+		IDCiudad:         req.IDCiudad,
+		AbiertoAhora:     req.AbiertoAhora,
+		Latitud:          req.Latitud,
+		Longitud:         req.Longitud,
+		RadioKm:          req.Radio,
+		Limite:           req.Limite,
+	})
+	if errors.Is(err, services.ErrEtiquetaInvalida) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		responderErrorListado(c, err, "search_failed", "Error al buscar platillos")
+		return
+	}
+
+	ph.historialService.RegistrarBusqueda(usuarioOpcional(c), req.Termino, models.ContextoBusquedaPlatillos,
+		req.IDCiudad, len(restaurantes))
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    restaurantes,
+		"total":   len(restaurantes),
+		"limite":  services.NormalizarLimiteRestaurantes(req.Limite),
+		"termino": req.Termino,
+		"message": "Busqueda de platillos completada",
+	})
+}
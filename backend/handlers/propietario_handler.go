@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/services"
+)
+
+// PropietarioHandler maneja la asignacion de propietarios de restaurantes
+type PropietarioHandler struct {
+	propietarioService *services.PropietarioService
+}
+
+// NewPropietarioHandler crea una nueva instancia del handler
+func NewPropietarioHandler(propietarioService *services.PropietarioService) *PropietarioHandler {
+	return &PropietarioHandler{propietarioService: propietarioService}
+}
+
+// Estructuras de peticion
+type AsignarPropietarioRequest struct {
+	IDUsuario uint `json:"idUsuario" binding:"required"`
+}
+
+// Estructuras de respuesta
+type PropietarioResponse struct {
+	IDUsuario     uint   `json:"idUsuario"`
+	NombreUsuario string `json:"nombreUsuario"`
+	Nombre        string `json:"nombre"`
+	Apellido      string `json:"apellido"`
+	Email         string `json:"email"`
+}
+
+// ObtenerPropietarios lista los propietarios de un restaurante
+func (ph *PropietarioHandler) ObtenerPropietarios(c *gin.Context) {
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	usuarios, err := ph.propietarioService.ObtenerPropietarios(idRestaurante)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "Error al obtener propietarios",
+		})
+		return
+	}
+
+	data := make([]PropietarioResponse, 0, len(usuarios))
+	for _, usuario := range usuarios {
+		data = append(data, PropietarioResponse{
+			IDUsuario:     usuario.IDUsuario,
+			NombreUsuario: usuario.NombreUsuario,
+			Nombre:        usuario.Nombre,
+			Apellido:      usuario.Apellido,
+			Email:         usuario.Email,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    data,
+		"total":   len(data),
+		"message": "Propietarios obtenidos exitosamente",
+	})
+}
+
+// AsignarPropietario vincula a un usuario como propietario del restaurante
+func (ph *PropietarioHandler) AsignarPropietario(c *gin.Context) {
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	var req AsignarPropietarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	if err := ph.propietarioService.AsignarPropietario(req.IDUsuario, idRestaurante); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "assign_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Propietario asignado exitosamente",
+	})
+}
+
+// QuitarPropietario desvincula a un usuario del restaurante
+func (ph *PropietarioHandler) QuitarPropietario(c *gin.Context) {
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	idUsuario, err := strconv.ParseUint(c.Param("idUsuario"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de usuario invalido",
+		})
+		return
+	}
+
+	if err := ph.propietarioService.QuitarPropietario(uint(idUsuario), idRestaurante); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "delete_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Propietario removido exitosamente",
+	})
+}
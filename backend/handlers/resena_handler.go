@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/services"
+)
+
+// ReseñaHandler maneja las rutas de reseñas de restaurantes
+type ReseñaHandler struct {
+	reseñaService *services.ReseñaService
+}
+
+// NewReseñaHandler crea una nueva instancia del handler
+func NewReseñaHandler(reseñaService *services.ReseñaService) *ReseñaHandler {
+	return &ReseñaHandler{reseñaService: reseñaService}
+}
+
+// Estructuras de peticion
+type ReseñaRequest struct {
+	Calificacion int    `json:"calificacion" binding:"required,min=1,max=5"`
+	Comentario   string `json:"comentario"`
+}
+
+type VotoReseñaRequest struct {
+	Util *bool `json:"util" binding:"required"`
+}
+
+// CheckinRequest usa punteros para que required acepte coordenadas en 0
+type CheckinRequest struct {
+	Latitud  *float64 `json:"latitud" binding:"required"`
+	Longitud *float64 `json:"longitud" binding:"required"`
+}
+
+// Estructuras de respuesta
+type AutorReseñaResponse struct {
+	IDUsuario     uint   `json:"idUsuario"`
+	NombreUsuario string `json:"nombreUsuario"`
+	Nombre        string `json:"nombre"`
+	Foto          string `json:"foto,omitempty"`
+}
+
+type ReseñaResponse struct {
+	IDReseña      uint                     `json:"idReseña"`
+	IDRestaurante uint                     `json:"idRestaurante"`
+	Calificacion  int8                     `json:"calificacion"`
+	Comentario    string                   `json:"comentario"`
+	Fecha         string                   `json:"fecha"`
+	Verificada    bool                     `json:"verificada"`
+	Estado        string                   `json:"estado"`
+	VotosUtiles   int                      `json:"votosUtiles"`
+	VotosNoUtiles int                      `json:"votosNoUtiles"`
+	Autor         AutorReseñaResponse      `json:"autor"`
+	Imagenes      []ImagenReseñaResponse   `json:"imagenes"`
+	Respuesta     *RespuestaReseñaResponse `json:"respuesta,omitempty"`
+}
+
+// CrearReseña registra la reseña del usuario autenticado para un restaurante
+func (rh *ReseñaHandler) CrearReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	var req ReseñaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	reseña, err := rh.reseñaService.CrearReseña(userID.(uint), idRestaurante, req.Calificacion, req.Comentario)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "review_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    nuevaReseñaResponse(*reseña),
+		"message": mensajeEstadoReseña(reseña.Estado, "Reseña publicada exitosamente"),
+	})
+}
+
+// ActualizarReseña modifica la reseña del usuario autenticado
+func (rh *ReseñaHandler) ActualizarReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	var req ReseñaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	reseña, err := rh.reseñaService.ActualizarReseña(userID.(uint), idRestaurante, req.Calificacion, req.Comentario)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "update_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    nuevaReseñaResponse(*reseña),
+		"message": mensajeEstadoReseña(reseña.Estado, "Reseña actualizada exitosamente"),
+	})
+}
+
+// EliminarReseña borra la reseña del usuario autenticado
+func (rh *ReseñaHandler) EliminarReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	if err := rh.reseñaService.EliminarReseña(userID.(uint), idRestaurante); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "delete_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reseña eliminada exitosamente",
+	})
+}
+
+// ObtenerMiReseña devuelve la reseña del usuario autenticado para un restaurante
+func (rh *ReseñaHandler) ObtenerMiReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	reseña, err := rh.reseñaService.ObtenerReseñaUsuario(userID.(uint), idRestaurante)
+	if err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Error:   "not_found",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    nuevaReseñaResponse(*reseña),
+		"message": "Reseña obtenida exitosamente",
+	})
+}
+
+// ObtenerReseñas lista las reseñas de un restaurante con paginacion
+func (rh *ReseñaHandler) ObtenerReseñas(c *gin.Context) {
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	pagina, _ := strconv.Atoi(c.DefaultQuery("pagina", "1"))
+	limite, _ := strconv.Atoi(c.Query("limite"))
+	pagina, limite = services.NormalizarPaginacion(pagina, limite)
+
+	reseñas, total, err := rh.reseñaService.ObtenerReseñasPorRestaurante(idRestaurante, services.OpcionesListadoReseñas{
+		Pagina:               pagina,
+		Limite:               limite,
+		Orden:                c.Query("orden"),
+		SoloVerificadas:      c.Query("verificadas") == "true",
+		PriorizarVerificadas: c.Query("priorizarVerificadas") == "true",
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "Error al obtener reseñas: " + err.Error(),
+		})
+		return
+	}
+
+	data := make([]ReseñaResponse, 0, len(reseñas))
+	for _, reseña := range reseñas {
+		data = append(data, nuevaReseñaResponse(reseña))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    data,
+		"total":   total,
+		"pagina":  pagina,
+		"limite":  limite,
+		"message": "Reseñas obtenidas exitosamente",
+	})
+}
+
+// VotarReseña registra si la reseña le resulto util al usuario autenticado
+func (rh *ReseñaHandler) VotarReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	var req VotoReseñaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	reseña, err := rh.reseñaService.VotarReseña(userID.(uint), idReseña, *req.Util)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "vote_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"idReseña":      reseña.IDReseña,
+			"votosUtiles":   reseña.VotosUtiles,
+			"votosNoUtiles": reseña.VotosNoUtiles,
+		},
+		"message": "Voto registrado",
+	})
+}
+
+// EliminarVotoReseña retira el voto del usuario autenticado
+func (rh *ReseñaHandler) EliminarVotoReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	reseña, err := rh.reseñaService.EliminarVotoReseña(userID.(uint), idReseña)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "vote_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"idReseña":      reseña.IDReseña,
+			"votosUtiles":   reseña.VotosUtiles,
+			"votosNoUtiles": reseña.VotosNoUtiles,
+		},
+		"message": "Voto eliminado",
+	})
+}
+
+// RegistrarCheckin guarda una visita si el usuario esta cerca del restaurante
+func (rh *ReseñaHandler) RegistrarCheckin(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idRestaurante, ok := obtenerIDRestaurante(c)
+	if !ok {
+		return
+	}
+
+	var req CheckinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	if *req.Latitud < -90 || *req.Latitud > 90 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_latitude",
+			Message: "Latitud debe estar entre -90 y 90",
+		})
+		return
+	}
+
+	if *req.Longitud < -180 || *req.Longitud > 180 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_longitude",
+			Message: "Longitud debe estar entre -180 y 180",
+		})
+		return
+	}
+
+	visita, err := rh.reseñaService.RegistrarVisita(userID.(uint), idRestaurante, *req.Latitud, *req.Longitud)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "checkin_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":                 visita,
+		"verificaResenasHasta": rh.reseñaService.VigenciaVisita(visita).Format("2006-01-02T15:04:05Z07:00"),
+		"message":              "Visita registrada exitosamente",
+	})
+}
+
+// obtenerIDRestaurante lee el parametro :id y responde con error si es invalido
+func obtenerIDRestaurante(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de restaurante invalido",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// mensajeEstadoReseña avisa al autor cuando su reseña no quedo publicada
+func mensajeEstadoReseña(estado, mensajePublicada string) string {
+	switch estado {
+	case models.EstadoReseñaPendiente:
+		return "Tu reseña sera revisada antes de publicarse"
+	case models.EstadoReseñaOculta:
+		return "Reseña guardada; permanece oculta por moderacion"
+	}
+	return mensajePublicada
+}
+
+// nuevaReseñaResponse expone solo los datos publicos de la reseña y su autor
+func nuevaReseñaResponse(reseña models.Reseña) ReseñaResponse {
+	return ReseñaResponse{
+		IDReseña:      reseña.IDReseña,
+		IDRestaurante: reseña.IDRestaurante,
+		Calificacion:  reseña.Calificacion,
+		Comentario:    reseña.Comentario,
+		Fecha:         reseña.Fecha.Format("2006-01-02T15:04:05Z07:00"),
+		Verificada:    reseña.Verificada,
+		Estado:        reseña.Estado,
+		VotosUtiles:   reseña.VotosUtiles,
+		VotosNoUtiles: reseña.VotosNoUtiles,
+		Autor: AutorReseñaResponse{
+			IDUsuario:     reseña.IDUsuario,
+			NombreUsuario: reseña.Usuario.NombreUsuario,
+			Nombre:        reseña.Usuario.Nombre,
+			Foto:          reseña.Usuario.Foto,
+		},
+		Imagenes:  nuevasImagenesReseñaResponse(reseña.Imagenes),
+		Respuesta: nuevaRespuestaReseñaResponse(reseña.Respuesta),
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/services"
+)
+
+// RespuestaReseñaHandler maneja las respuestas de propietarios a las reseñas
+type RespuestaReseñaHandler struct {
+	respuestaService *services.RespuestaReseñaService
+}
+
+// NewRespuestaReseñaHandler crea una nueva instancia del handler
+func NewRespuestaReseñaHandler(respuestaService *services.RespuestaReseñaService) *RespuestaReseñaHandler {
+	return &RespuestaReseñaHandler{respuestaService: respuestaService}
+}
+
+// Estructuras de peticion
+type RespuestaReseñaRequest struct {
+	Comentario string `json:"comentario" binding:"required"`
+}
+
+// Estructuras de respuesta
+type RespuestaReseñaResponse struct {
+	IDRespuesta  uint   `json:"idRespuesta"`
+	Comentario   string `json:"comentario"`
+	Fecha        string `json:"fecha"`
+	FechaEdicion string `json:"fechaEdicion,omitempty"`
+	Autor        string `json:"autor,omitempty"`
+}
+
+// ResponderReseña publica la respuesta del propietario autenticado
+func (rh *RespuestaReseñaHandler) ResponderReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	var req RespuestaReseñaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	respuesta, err := rh.respuestaService.ResponderReseña(userID.(uint), idReseña, req.Comentario)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "reply_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    nuevaRespuestaReseñaResponse(respuesta),
+		"message": "Respuesta publicada exitosamente",
+	})
+}
+
+// EditarRespuestaReseña modifica la respuesta existente de una reseña
+func (rh *RespuestaReseñaHandler) EditarRespuestaReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	var req RespuestaReseñaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Datos invalidos: " + err.Error(),
+		})
+		return
+	}
+
+	respuesta, err := rh.respuestaService.EditarRespuestaReseña(userID.(uint), idReseña, req.Comentario)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "update_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    nuevaRespuestaReseñaResponse(respuesta),
+		"message": "Respuesta actualizada exitosamente",
+	})
+}
+
+// EliminarRespuestaReseña retira la respuesta de una reseña
+func (rh *RespuestaReseñaHandler) EliminarRespuestaReseña(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error:   "unauthorized",
+			Message: "Usuario no autenticado",
+		})
+		return
+	}
+
+	idReseña, ok := obtenerIDReseña(c)
+	if !ok {
+		return
+	}
+
+	if err := rh.respuestaService.EliminarRespuestaReseña(userID.(uint), idReseña); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "delete_failed",
+			Message: err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Respuesta eliminada exitosamente",
+	})
+}
+
+// nuevaRespuestaReseñaResponse expone la respuesta del propietario; nil si la reseña no tiene
+func nuevaRespuestaReseñaResponse(respuesta *models.RespuestaReseña) *RespuestaReseñaResponse {
+	if respuesta == nil {
+		return nil
+	}
+
+	response := &RespuestaReseñaResponse{
+		IDRespuesta: respuesta.IDRespuesta,
+		Comentario:  respuesta.Comentario,
+		Fecha:       respuesta.Fecha.Format("2006-01-02T15:04:05Z07:00"),
+		Autor:       respuesta.Usuario.NombreUsuario,
+	}
+	if respuesta.FechaEdicion != nil {
+		response.FechaEdicion = respuesta.FechaEdicion.Format("2006-01-02T15:04:05Z07:00")
+	}
+
+	return response
+}
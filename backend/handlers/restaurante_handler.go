@@ -1,19 +1,30 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
 	"github.com/tuusuario/quovi/services"
+	"gorm.io/gorm"
 )
 
 type RestauranteHandler struct {
 	restauranteService *services.RestauranteService
+	historialService   *services.HistorialBusquedaService
 }
 
-func NewRestauranteHandler(restauranteService *services.RestauranteService) *RestauranteHandler {
-	return &RestauranteHandler{restauranteService: restauranteService}
+func NewRestauranteHandler(restauranteService *services.RestauranteService, historialService *services.HistorialBusquedaService) *RestauranteHandler {
+	return &RestauranteHandler{
+		restauranteService: restauranteService,
+		historialService:   historialService,
+	}
 }
 
 // Estructuras de peticion
@@ -23,35 +34,52 @@ type ObtenerRestaurantesCercanosRequest struct {
 	Radio    float64 `json:"radio"`
 }
 
+// BuscarRestaurantesRequest combina todos los filtros; los que se omiten no restringen la busqueda
 type BuscarRestaurantesRequest struct {
-	Termino     string   `json:"termino"`
-	Categoria   string   `json:"categoria,omitempty"`
-	IDCategoria *uint    `json:"idCategoria,omitempty"`
-	Latitud     *float64 `json:"latitud,omitempty"`
-	Longitud    *float64 `json:"longitud,omitempty"`
-	Radio       float64  `json:"radio,omitempty"`
+	Termino             string   `json:"termino"`
+	Categoria           string   `json:"categoria,omitempty"`
+	IDCategoria         *uint    `json:"idCategoria,omitempty"`
+	IDCategorias        []uint   `json:"idCategorias,omitempty"`
+	IDCaracteristicas   []uint   `json:"idCaracteristicas,omitempty"`
+	ModoCaracteristicas string   `json:"modoCaracteristicas,omitempty"` // todas (por defecto) o alguna
+	PrecioMin           *float64 `json:"precioMin,omitempty"`
+	PrecioMax           *float64 `json:"precioMax,omitempty"`
+	BandasPrecio        []string `json:"bandasPrecio,omitempty"`
+	CalificacionMin     *float64 `json:"calificacionMin,omitempty"`
+	IDCiudad            *uint    `json:"idCiudad,omitempty"`
+	AbiertoAhora        bool     `json:"abiertoAhora,omitempty"`
+	Latitud             *float64 `json:"latitud,omitempty"`
+	Longitud            *float64 `json:"longitud,omitempty"`
+	Radio               float64  `json:"radio,omitempty"`
+
+	// PrecioPlatoFuerteMax pide un plato fuerte que cueste eso o menos, sin contar bebidas ni postres
+	PrecioPlatoFuerteMax *float64 `json:"precioPlatoFuerteMax,omitempty"`
+
+	// PlatillosConEtiqueta pide un minimo de platillos con una etiqueta, ej. [{"etiqueta":"vegano","minimo":3}]
+	PlatillosConEtiqueta []MinimoPlatillosEtiquetaRequest `json:"platillosConEtiqueta,omitempty"`
+}
+
+// MinimoPlatillosEtiquetaRequest es un requisito de platillos etiquetados; minimo omitido equivale a 1
+type MinimoPlatillosEtiquetaRequest struct {
+	Etiqueta string `json:"etiqueta" binding:"required"`
+	Minimo   int    `json:"minimo"`
 }
 
 type AgregarFavoritoRequest struct {
 	IDRestaurante uint `json:"idRestaurante" binding:"required"`
 }
 
-// ObtenerTodosLosRestaurantes devuelve la lista completa de restaurantes
+// ObtenerTodosLosRestaurantes devuelve los restaurantes activos paginados
 func (rh *RestauranteHandler) ObtenerTodosLosRestaurantes(c *gin.Context) {
-	restaurantes, err := rh.restauranteService.ObtenerTodosLosRestaurantes()
+	lat, lng := leerUbicacionOpcional(c)
+
+	pagina, err := rh.restauranteService.ObtenerTodosLosRestaurantes(lat, lng, leerPaginacion(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "fetch_failed",
-			Message: "Error al obtener restaurantes: " + err.Error(),
-		})
+		responderErrorListado(c, err, "fetch_failed", "Error al obtener restaurantes")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":    restaurantes,
-		"total":   len(restaurantes),
-		"message": "Restaurantes obtenidos exitosamente",
-	})
+	c.JSON(http.StatusOK, respuestaPagina(pagina, "Restaurantes obtenidos exitosamente"))
 }
 
 // ObtenerRestaurantePorID devuelve los detalles de un restaurante especifico
@@ -118,32 +146,28 @@ func (rh *RestauranteHandler) ObtenerRestaurantesCercanos(c *gin.Context) {
 		req.Radio = 50.0
 	}
 
-	restaurantes, err := rh.restauranteService.ObtenerRestaurantesCercanos(
+	pagina, err := rh.restauranteService.ObtenerRestaurantesCercanos(
 		req.Latitud,
 		req.Longitud,
 		req.Radio,
+		leerPaginacion(c),
 	)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "fetch_failed",
-			Message: "Error al buscar restaurantes cercanos: " + err.Error(),
-		})
+		responderErrorListado(c, err, "fetch_failed", "Error al buscar restaurantes cercanos")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":  restaurantes,
-		"total": len(restaurantes),
-		"ubicacion": gin.H{
-			"latitud":  req.Latitud,
-			"longitud": req.Longitud,
-			"radio":    req.Radio,
-		},
-		"message": "Restaurantes cercanos encontrados",
-	})
+	respuesta := respuestaPagina(pagina, "Restaurantes cercanos encontrados")
+	respuesta["ubicacion"] = gin.H{
+		"latitud":  req.Latitud,
+		"longitud": req.Longitud,
+		"radio":    req.Radio,
+	}
+	c.JSON(http.StatusOK, respuesta)
 }
 
-// BuscarRestaurantes realiza una busqueda por termino y/o categoria
+// BuscarRestaurantes realiza una busqueda combinando termino, categorias, caracteristicas,
+// precio, calificacion, ciudad, horario y ubicacion, e incluye conteos por faceta
 func (rh *RestauranteHandler) BuscarRestaurantes(c *gin.Context) {
 	var req BuscarRestaurantesRequest
 
@@ -155,15 +179,6 @@ func (rh *RestauranteHandler) BuscarRestaurantes(c *gin.Context) {
 		return
 	}
 
-	// Validar que haya al menos un criterio de busqueda
-	if req.Termino == "" && req.Categoria == "" && req.IDCategoria == nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "missing_criteria",
-			Message: "Debe proporcionar al menos un termino de busqueda o categoria",
-		})
-		return
-	}
-
 	// Validar coordenadas si se proporcionan
 	if req.Latitud != nil && (*req.Latitud < -90 || *req.Latitud > 90) {
 		c.JSON(http.StatusBadRequest, ErrorResponse{
@@ -181,28 +196,68 @@ func (rh *RestauranteHandler) BuscarRestaurantes(c *gin.Context) {
 		return
 	}
 
-	restaurantes, err := rh.restauranteService.BuscarRestaurantes(
-		req.Termino,
-		req.Categoria,
-		req.IDCategoria,
-		req.Latitud,
-		req.Longitud,
-		req.Radio,
-	)
+	// idCategoria se mantiene por compatibilidad y se combina con idCategorias
+	idCategorias := req.IDCategorias
+	if req.IDCategoria != nil {
+		idCategorias = append(idCategorias, *req.IDCategoria)
+	}
+
+	resultado, err := rh.restauranteService.BuscarRestaurantes(services.FiltrosBusqueda{
+		Termino:             req.Termino,
+		NombreCategoria:     req.Categoria,
+		IDCategorias:        idCategorias,
+		IDCaracteristicas:   req.IDCaracteristicas,
+		ModoCaracteristicas: req.ModoCaracteristicas,
+		PrecioMin:           req.PrecioMin,
+		PrecioMax:           req.PrecioMax,
+		BandasPrecio:        req.BandasPrecio,
+		CalificacionMin:     req.CalificacionMin,
+		IDCiudad:            req.IDCiudad,
+		AbiertoAhora:        req.AbiertoAhora,
+		Latitud:             req.Latitud,
+		Longitud:            req.Longitud,
+		RadioKm:             req.Radio,
+
+		PrecioPlatoFuerteMax: req.PrecioPlatoFuerteMax,
+		PlatillosConEtiqueta: requisitosEtiquetas(req.PlatillosConEtiqueta),
+	}, leerPaginacion(c))
+	if err != nil {
+		responderErrorListado(c, err, "search_failed", "Error al buscar restaurantes")
+		return
+	}
+
+	// Sin termino la busqueda se registra con el nombre de categoria, si lo hay
+	texto := req.Termino
+	if strings.TrimSpace(texto) == "" {
+		texto = req.Categoria
+	}
+	// Solo se registra la primera pagina para no contar dos veces la misma busqueda
+	if c.Query("cursor") == "" {
+		rh.historialService.RegistrarBusqueda(usuarioOpcional(c), texto, models.ContextoBusquedaRestaurantes,
+			req.IDCiudad, int(resultado.TotalEstimado))
+	}
+
+	respuesta := respuestaPagina(&resultado.PaginaRestaurantes, "Busqueda completada")
+	respuesta["facetas"] = resultado.Facetas
+	respuesta["termino"] = req.Termino
+	respuesta["categoria"] = req.Categoria
+	c.JSON(http.StatusOK, respuesta)
+}
+
+// ReconstruirIndiceBusqueda fuerza la reconstruccion del indice de busqueda en memoria
+func (rh *RestauranteHandler) ReconstruirIndiceBusqueda(c *gin.Context) {
+	total, err := rh.restauranteService.ReconstruirIndiceBusqueda()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "search_failed",
-			Message: "Error al buscar restaurantes: " + err.Error(),
+			Error:   "reindex_failed",
+			Message: "Error al reconstruir el indice: " + err.Error(),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":      restaurantes,
-		"total":     len(restaurantes),
-		"termino":   req.Termino,
-		"categoria": req.Categoria,
-		"message":   "Busqueda completada",
+		"total":   total,
+		"message": "Indice de busqueda reconstruido",
 	})
 }
 
@@ -236,35 +291,120 @@ func (rh *RestauranteHandler) ObtenerRestaurantesPorCategoria(c *gin.Context) {
 		return
 	}
 
-	// Obtener coordenadas opcionales de query params
-	var lat, lng *float64
-	if latStr := c.Query("lat"); latStr != "" {
-		latVal, err := strconv.ParseFloat(latStr, 64)
-		if err == nil && latVal >= -90 && latVal <= 90 {
-			lat = &latVal
-		}
+	lat, lng := leerUbicacionOpcional(c)
+
+	pagina, err := rh.restauranteService.ObtenerRestaurantesPorCategoria(uint(id), lat, lng, leerPaginacion(c))
+	if err != nil {
+		responderErrorListado(c, err, "fetch_failed", "Error al obtener restaurantes")
+		return
 	}
 
-	if lngStr := c.Query("lng"); lngStr != "" {
-		lngVal, err := strconv.ParseFloat(lngStr, 64)
-		if err == nil && lngVal >= -180 && lngVal <= 180 {
-			lng = &lngVal
-		}
+	c.JSON(http.StatusOK, respuestaPagina(pagina, "Restaurantes obtenidos exitosamente"))
+}
+
+// ObtenerCaracteristicas devuelve todas las caracteristicas disponibles, como terraza o pet friendly
+func (rh *RestauranteHandler) ObtenerCaracteristicas(c *gin.Context) {
+	caracteristicas, err := rh.restauranteService.ObtenerCaracteristicas()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "fetch_failed",
+			Message: "Error al obtener caracteristicas: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    caracteristicas,
+		"total":   len(caracteristicas),
+		"message": "Caracteristicas obtenidas exitosamente",
+	})
+}
+
+// ObtenerRestaurantesPorCaracteristica filtra restaurantes por caracteristica
+func (rh *RestauranteHandler) ObtenerRestaurantesPorCaracteristica(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de caracteristica invalido",
+		})
+		return
+	}
+
+	lat, lng := leerUbicacionOpcional(c)
+
+	pagina, err := rh.restauranteService.ObtenerRestaurantesPorCaracteristica(uint(id), lat, lng, leerPaginacion(c))
+	if err != nil {
+		responderErrorListado(c, err, "fetch_failed", "Error al obtener restaurantes")
+		return
 	}
 
-	restaurantes, err := rh.restauranteService.ObtenerRestaurantesPorCategoria(uint(id), lat, lng)
+	c.JSON(http.StatusOK, respuestaPagina(pagina, "Restaurantes obtenidos exitosamente"))
+}
+
+// ObtenerRestaurantesPorCiudad lista los restaurantes de una ciudad con los filtros de la busqueda
+func (rh *RestauranteHandler) ObtenerRestaurantesPorCiudad(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de ciudad invalido",
+		})
+		return
+	}
+
+	resultado, err := rh.restauranteService.ObtenerRestaurantesPorCiudad(uint(id), leerFiltrosListado(c), leerPaginacion(c))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		responderCiudadNoEncontrada(c)
+		return
+	}
+	if err != nil {
+		responderErrorListado(c, err, "fetch_failed", "Error al obtener restaurantes")
+		return
+	}
+
+	respuesta := respuestaPagina(&resultado.PaginaRestaurantes, "Restaurantes obtenidos exitosamente")
+	respuesta["facetas"] = resultado.Facetas
+	c.JSON(http.StatusOK, respuesta)
+}
+
+// ObtenerResumenCiudad devuelve las estadisticas de una ciudad
+func (rh *RestauranteHandler) ObtenerResumenCiudad(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_id",
+			Message: "ID de ciudad invalido",
+		})
+		return
+	}
+
+	resumen, err := rh.restauranteService.ObtenerResumenCiudad(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		responderCiudadNoEncontrada(c)
+		return
+	}
+	if err != nil {
+		log.Printf("Error al obtener resumen de la ciudad %d: %v", id, err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "fetch_failed",
-			Message: "Error al obtener restaurantes: " + err.Error(),
+			Message: "Error al obtener resumen de la ciudad",
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data":    restaurantes,
-		"total":   len(restaurantes),
-		"message": "Restaurantes obtenidos exitosamente",
+		"data":    resumen,
+		"message": "Resumen de la ciudad obtenido exitosamente",
+	})
+}
+
+// responderCiudadNoEncontrada responde 404 cuando la ciudad pedida no existe
+func responderCiudadNoEncontrada(c *gin.Context) {
+	c.JSON(http.StatusNotFound, ErrorResponse{
+		Error:   "not_found",
+		Message: "Ciudad no encontrada",
 	})
 }
 
@@ -366,7 +506,95 @@ func (rh *RestauranteHandler) ObtenerFavoritos(c *gin.Context) {
 		return
 	}
 
-	// Obtener coordenadas opcionales para calcular distancia
+	// Coordenadas opcionales para calcular distancia
+	lat, lng := leerUbicacionOpcional(c)
+
+	pagina, err := rh.restauranteService.ObtenerFavoritos(userID.(uint), lat, lng, leerPaginacion(c))
+	if err != nil {
+		responderErrorListado(c, err, "fetch_failed", "Error al obtener favoritos")
+		return
+	}
+
+	c.JSON(http.StatusOK, respuestaPagina(pagina, "Favoritos obtenidos exitosamente"))
+}
+
+// requisitosEtiquetas convierte los requisitos de platillos etiquetados de la peticion
+func requisitosEtiquetas(requisitos []MinimoPlatillosEtiquetaRequest) []repository.MinimoPlatillosEtiqueta {
+	resultado := make([]repository.MinimoPlatillosEtiqueta, 0, len(requisitos))
+	for _, requisito := range requisitos {
+		resultado = append(resultado, repository.MinimoPlatillosEtiqueta{
+			Etiqueta: requisito.Etiqueta,
+			Minimo:   requisito.Minimo,
+		})
+	}
+	return resultado
+}
+
+// leerFiltrosListado obtiene de los query params los mismos filtros que acepta la busqueda:
+// termino, idCategoria, idCaracteristica y bandaPrecio (repetibles), modoCaracteristicas, precioMin,
+// precioMax, precioPlatoFuerteMax, calificacionMin, abiertoAhora, lat, lng y radio; los valores no numericos se ignoran
+// platillosEtiqueta (repetible) acepta etiqueta o etiqueta:minimo, por ejemplo vegano:3
+func leerFiltrosListado(c *gin.Context) services.FiltrosBusqueda {
+	filtros := services.FiltrosBusqueda{
+		Termino:             c.Query("termino"),
+		ModoCaracteristicas: c.Query("modoCaracteristicas"),
+		BandasPrecio:        c.QueryArray("bandaPrecio"),
+		AbiertoAhora:        c.Query("abiertoAhora") == "true",
+	}
+
+	leerIDs := func(nombre string) []uint {
+		var ids []uint
+		for _, valor := range c.QueryArray(nombre) {
+			if id, err := strconv.ParseUint(valor, 10, 32); err == nil {
+				ids = append(ids, uint(id))
+			}
+		}
+		return ids
+	}
+	filtros.IDCategorias = leerIDs("idCategoria")
+	filtros.IDCaracteristicas = leerIDs("idCaracteristica")
+
+	leerNumero := func(nombre string) *float64 {
+		valor, err := strconv.ParseFloat(c.Query(nombre), 64)
+		if err != nil {
+			return nil
+		}
+		return &valor
+	}
+	filtros.PrecioMin = leerNumero("precioMin")
+	filtros.PrecioMax = leerNumero("precioMax")
+	filtros.PrecioPlatoFuerteMax = leerNumero("precioPlatoFuerteMax")
+	filtros.CalificacionMin = leerNumero("calificacionMin")
+
+	for _, valor := range c.QueryArray("platillosEtiqueta") {
+		etiqueta, minimoStr, _ := strings.Cut(valor, ":")
+		minimo, _ := strconv.Atoi(minimoStr)
+		filtros.PlatillosConEtiqueta = append(filtros.PlatillosConEtiqueta, repository.MinimoPlatillosEtiqueta{
+			Etiqueta: etiqueta,
+			Minimo:   minimo,
+		})
+	}
+
+	filtros.Latitud, filtros.Longitud = leerUbicacionOpcional(c)
+	if radio := leerNumero("radio"); radio != nil && *radio > 0 {
+		filtros.RadioKm = math.Min(*radio, 50)
+	}
+
+	return filtros
+}
+
+// leerPaginacion obtiene limite, cursor y orden de los query params; acepta tambien limit y sort
+func leerPaginacion(c *gin.Context) services.OpcionesPaginacion {
+	limite, _ := strconv.Atoi(c.DefaultQuery("limite", c.Query("limit")))
+	return services.OpcionesPaginacion{
+		Limite: limite,
+		Cursor: c.Query("cursor"),
+		Orden:  c.DefaultQuery("orden", c.Query("sort")),
+	}
+}
+
+// leerUbicacionOpcional obtiene lat y lng de los query params; las coordenadas invalidas se ignoran
+func leerUbicacionOpcional(c *gin.Context) (*float64, *float64) {
 	var lat, lng *float64
 	if latStr := c.Query("lat"); latStr != "" {
 		latVal, err := strconv.ParseFloat(latStr, 64)
@@ -382,18 +610,46 @@ func (rh *RestauranteHandler) ObtenerFavoritos(c *gin.Context) {
 		}
 	}
 
-	restaurantes, err := rh.restauranteService.ObtenerFavoritos(userID.(uint), lat, lng)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "fetch_failed",
-			Message: "Error al obtener favoritos: " + err.Error(),
+	if lat == nil || lng == nil {
+		return nil, nil
+	}
+	return lat, lng
+}
+
+// responderErrorListado responde 400 cuando la peticion del listado es invalida (cursor, orden o
+// filtros) y 500 con un mensaje generico en otro caso, para no exponer detalles de la base de datos
+func responderErrorListado(c *gin.Context, err error, codigo, mensaje string) {
+	if errors.Is(err, repository.ErrCursorInvalido) || errors.Is(err, repository.ErrOrdenInvalido) ||
+		errors.Is(err, services.ErrFiltroInvalido) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_request",
+			Message: err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":    restaurantes,
-		"total":   len(restaurantes),
-		"message": "Favoritos obtenidos exitosamente",
+	log.Printf("%s: %v", mensaje, err)
+	c.JSON(http.StatusInternalServerError, ErrorResponse{
+		Error:   codigo,
+		Message: mensaje,
 	})
 }
+
+// respuestaPagina arma el sobre comun de los listados paginados
+// total es una estimacion: se cuenta en la primera pagina y se conserva en el cursor
+// nextCursor es null en la ultima pagina
+func respuestaPagina(pagina *services.PaginaRestaurantes, mensaje string) gin.H {
+	var siguiente interface{}
+	if pagina.SiguienteCursor != "" {
+		siguiente = pagina.SiguienteCursor
+	}
+
+	return gin.H{
+		"data":       pagina.Restaurantes,
+		"total":      pagina.TotalEstimado,
+		"nextCursor": siguiente,
+		"limite":     pagina.Limite,
+		"orden":      pagina.Orden,
+		"message":    mensaje,
+	}
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tuusuario/quovi/services"
+)
+
+// SugerenciasHandler maneja el autocompletado de la barra de busqueda
+type SugerenciasHandler struct {
+	sugerenciasService *services.SugerenciasService
+}
+
+// NewSugerenciasHandler crea una nueva instancia del handler
+func NewSugerenciasHandler(sugerenciasService *services.SugerenciasService) *SugerenciasHandler {
+	return &SugerenciasHandler{sugerenciasService: sugerenciasService}
+}
+
+// ObtenerSugerencias completa lo que el usuario va escribiendo con busquedas populares,
+// restaurantes, categorias, platillos y ciudades
+func (sh *SugerenciasHandler) ObtenerSugerencias(c *gin.Context) {
+	consulta := c.Query("q")
+	if len(consulta) > 100 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "invalid_query",
+			Message: "La consulta no puede exceder 100 caracteres",
+		})
+		return
+	}
+
+	limite, _ := strconv.Atoi(c.Query("limite"))
+	sugerencias := sh.sugerenciasService.Sugerir(consulta, limite)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    sugerencias,
+		"total":   len(sugerencias),
+		"q":       consulta,
+		"message": "Sugerencias obtenidas exitosamente",
+	})
+}
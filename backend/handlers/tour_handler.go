@@ -1,7 +1,12 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/tuusuario/quovi/algorithms"
@@ -25,17 +30,46 @@ type RequestGenerarTour struct {
 	IDsRestaurantes  []int                 `json:"idsRestaurantes" binding:"required,min=2"`
 	UbicacionUsuario algorithms.Coordenada `json:"ubicacionUsuario" binding:"required"`
 	Preferencias     struct {
-		Optimizar string `json:"optimizar"` // "distancia" o "tiempo"
+		Optimizar string `json:"optimizar"` // "distancia" o "tiempo"; por defecto distancia
+		ModoViaje string `json:"modoViaje"` // caminando, bicicleta, auto o transporte; por defecto auto
 	} `json:"preferencias"`
+	Inicio                 string                 `json:"inicio,omitempty"`                 // RFC 3339; vacio sale en este momento
+	EstanciaMinutos        int                    `json:"estanciaMinutos,omitempty"`        // Permanencia en cada restaurante; por defecto 30
+	EstanciaPorRestaurante map[int]int            `json:"estanciaPorRestaurante,omitempty"` // idRestaurante -> minutos
+	Regresar               bool                   `json:"regresar,omitempty"`               // Cierra el tour en la ubicacion del usuario
+	Destino                *algorithms.Coordenada `json:"destino,omitempty"`                // Punto final, por ejemplo un hotel
+	PrimeraParada          int                    `json:"primeraParada,omitempty"`          // idRestaurante que se visita primero
+	UltimaParada           int                    `json:"ultimaParada,omitempty"`           // idRestaurante que se visita al final
 }
 
+// maxEstanciaMinutos limita la permanencia que se puede pedir en un restaurante
+const maxEstanciaMinutos = 8 * 60
+
 // ResponseTour es la respuesta con el tour optimizado
 type ResponseTour struct {
-	Ruta            []RestauranteEnRuta `json:"ruta"`
-	DistanciaTotal  float64             `json:"distanciaTotalKm"`
-	TiempoEstimado  int                 `json:"tiempoEstimadoMinutos"`
-	Algoritmo       string              `json:"algoritmo"`
-	PasosDetallados []PasoRuta          `json:"pasosDetallados"`
+	Modo              string                    `json:"modo"` // abierto, circular o destino
+	ModoViaje         string                    `json:"modoViaje"`
+	Objetivo          string                    `json:"objetivo"` // distancia o tiempo
+	Ruta              []RestauranteEnRuta       `json:"ruta"`
+	DistanciaTotal    float64                   `json:"distanciaTotalKm"`
+	TiempoEstimado    int                       `json:"tiempoEstimadoMinutos"`
+	Algoritmo         string                    `json:"algoritmo"`
+	Metodo            string                    `json:"metodo"` // held-karp o vecino-mas-cercano+2-opt+or-opt
+	Exacto            bool                      `json:"exacto"`
+	CotaInferiorKm    float64                   `json:"cotaInferiorKm,omitempty"`      // Con objetivo distancia
+	CotaInferiorMin   int                       `json:"cotaInferiorMinutos,omitempty"` // Con objetivo tiempo
+	BrechaOptimalidad float64                   `json:"brechaOptimalidadPct"`          // Porcentaje maximo sobre la ruta optima; 0 si es exacta
+	PasosDetallados   []PasoRuta                `json:"pasosDetallados"`
+	Inicio            time.Time                 `json:"inicio"`
+	Fin               time.Time                 `json:"fin"`
+	NoProgramados     []RestauranteNoProgramado `json:"noProgramados"`
+}
+
+// RestauranteNoProgramado es un restaurante que no se pudo visitar dentro de su horario
+type RestauranteNoProgramado struct {
+	IDRestaurante int    `json:"idRestaurante"`
+	Nombre        string `json:"nombre"`
+	Motivo        string `json:"motivo"`
 }
 
 // RestauranteEnRuta representa un restaurante en la ruta optimizada
@@ -46,6 +80,13 @@ type RestauranteEnRuta struct {
 	Latitud       float64 `json:"latitud"`
 	Longitud      float64 `json:"longitud"`
 	Orden         int     `json:"orden"`
+	// Horas en la zona horaria de la ciudad del restaurante
+	Llegada         time.Time `json:"llegada"`
+	InicioVisita    time.Time `json:"inicioVisita"`
+	Salida          time.Time `json:"salida"`
+	EsperaMinutos   int       `json:"esperaMinutos"`
+	EstanciaMinutos int       `json:"estanciaMinutos"`
+	SinHorario      bool      `json:"sinHorario,omitempty"` // No tiene horarios registrados; no se verifico que este abierto
 }
 
 // PasoRuta representa un segmento del tour
@@ -58,9 +99,14 @@ type PasoRuta struct {
 	LongitudOrigen    float64 `json:"longitudOrigen"`
 	LatitudDestino    float64 `json:"latitudDestino"`
 	LongitudDestino   float64 `json:"longitudDestino"`
+	PorCalles         bool    `json:"porCalles"` // Distancia y polilinea siguen la red vial; si no, son una estimacion en linea recta
+	Polilinea         string  `json:"polilinea"` // Codificada con el algoritmo de polilineas de Google
 }
 
-// GenerarTour genera una ruta optimizada usando A*
+// GenerarTour genera una ruta optimizada que respeta el horario de cada restaurante
+// Optimiza la distancia o la duracion total en el modo de viaje elegido
+// El tour puede regresar al origen o terminar en un destino, con primera y ultima parada fijas
+// En tours grandes la ruta es heuristica y se reporta su brecha
 func (th *TourHandler) GenerarTour(c *gin.Context) {
 	var request RequestGenerarTour
 
@@ -83,6 +129,15 @@ func (th *TourHandler) GenerarTour(c *gin.Context) {
 		return
 	}
 
+	solicitud, err := request.solicitud()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	// Obtener informacion de los restaurantes
 	restaurantes, err := th.tourService.ObtenerRestaurantesPorIDs(request.IDsRestaurantes)
 	if err != nil {
@@ -103,27 +158,10 @@ func (th *TourHandler) GenerarTour(c *gin.Context) {
 		return
 	}
 
-	// Crear optimizador A*
-	optimizador := algorithms.NewAStarOptimizer()
-
-	// Agregar todos los restaurantes al optimizador
-	for _, rest := range restaurantes {
-		optimizador.AgregarNodo(
-			int(rest.IDRestaurante),
-			rest.Nombre,
-			rest.Latitud,
-			rest.Longitud,
-		)
-	}
-
-	// Optimizar la ruta usando A*
-	rutaOptimizada, distanciaTotal, err := optimizador.OptimizarRuta(
-		request.UbicacionUsuario,
-		request.IDsRestaurantes,
-	)
-
+	// Programar el orden de visita segun los horarios de cada restaurante
+	tour, err := th.tourService.OptimizarRuta(restaurantes, solicitud)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "Error al optimizar la ruta",
 			"error":   err.Error(),
@@ -135,87 +173,89 @@ func (th *TourHandler) GenerarTour(c *gin.Context) {
 	rutaDetallada := []RestauranteEnRuta{}
 	pasosDetallados := []PasoRuta{}
 
-	// Primer paso: desde ubicacion del usuario al primer restaurante
-	if len(rutaOptimizada) > 0 {
-		primerRest := optimizador.ObtenerNodo(rutaOptimizada[0])
-		distanciaPrimerPaso := th.calcularDistancia(
-			request.UbicacionUsuario,
-			primerRest.Coordenadas,
-		)
+	desde, origen := "Tu ubicacion", request.UbicacionUsuario
+	for i, visita := range tour.Visitas {
+		rest := visita.Restaurante
+		destino := algorithms.Coordenada{Latitud: rest.Latitud, Longitud: rest.Longitud}
 
 		pasosDetallados = append(pasosDetallados, PasoRuta{
-			Desde:             "Tu ubicacion",
-			Hasta:             primerRest.Nombre,
-			DistanciaKm:       distanciaPrimerPaso,
-			TiempoEstimadoMin: th.calcularTiempo(distanciaPrimerPaso),
-			LatitudOrigen:     request.UbicacionUsuario.Latitud,
-			LongitudOrigen:    request.UbicacionUsuario.Longitud,
-			LatitudDestino:    primerRest.Coordenadas.Latitud,
-			LongitudDestino:   primerRest.Coordenadas.Longitud,
+			Desde:             desde,
+			Hasta:             rest.Nombre,
+			DistanciaKm:       visita.DistanciaKm,
+			TiempoEstimadoMin: int(math.Round(visita.Trayecto.Minutes())),
+			LatitudOrigen:     origen.Latitud,
+			LongitudOrigen:    origen.Longitud,
+			LatitudDestino:    destino.Latitud,
+			LongitudDestino:   destino.Longitud,
+			PorCalles:         visita.PorCalles,
+			Polilinea:         algorithms.CodificarPolilinea(visita.Trazado),
 		})
-	}
 
-	// Construir resto de la ruta
-	for i, idRest := range rutaOptimizada {
-		nodo := optimizador.ObtenerNodo(idRest)
-
-		// Buscar info completa del restaurante
-		var restauranteCompleto *RestauranteEnRuta
-		for _, rest := range restaurantes {
-			if int(rest.IDRestaurante) == idRest {
-				restauranteCompleto = &RestauranteEnRuta{
-					IDRestaurante: int(rest.IDRestaurante),
-					Nombre:        rest.Nombre,
-					Direccion:     rest.Direccion,
-					Latitud:       rest.Latitud,
-					Longitud:      rest.Longitud,
-					Orden:         i + 1,
-				}
-				break
-			}
-		}
+		rutaDetallada = append(rutaDetallada, RestauranteEnRuta{
+			IDRestaurante:   int(rest.IDRestaurante),
+			Nombre:          rest.Nombre,
+			Direccion:       rest.Direccion,
+			Latitud:         rest.Latitud,
+			Longitud:        rest.Longitud,
+			Orden:           i + 1,
+			Llegada:         visita.Llegada,
+			InicioVisita:    visita.InicioVisita,
+			Salida:          visita.Salida,
+			EsperaMinutos:   int(math.Round(visita.Espera.Minutes())),
+			EstanciaMinutos: int(math.Round(visita.Salida.Sub(visita.InicioVisita).Minutes())),
+			SinHorario:      visita.SinHorario,
+		})
 
-		if restauranteCompleto != nil {
-			rutaDetallada = append(rutaDetallada, *restauranteCompleto)
-		}
+		desde, origen = rest.Nombre, destino
+	}
 
-		// Agregar paso si no es el ultimo
-		if i < len(rutaOptimizada)-1 {
-			siguienteNodo := optimizador.ObtenerNodo(rutaOptimizada[i+1])
-			distanciaPaso := th.calcularDistancia(
-				nodo.Coordenadas,
-				siguienteNodo.Coordenadas,
-			)
-
-			pasosDetallados = append(pasosDetallados, PasoRuta{
-				Desde:             nodo.Nombre,
-				Hasta:             siguienteNodo.Nombre,
-				DistanciaKm:       distanciaPaso,
-				TiempoEstimadoMin: th.calcularTiempo(distanciaPaso),
-				LatitudOrigen:     nodo.Coordenadas.Latitud,
-				LongitudOrigen:    nodo.Coordenadas.Longitud,
-				LatitudDestino:    siguienteNodo.Coordenadas.Latitud,
-				LongitudDestino:   siguienteNodo.Coordenadas.Longitud,
-			})
+	// Ultimo tramo hacia el origen o el destino
+	if final := tour.TramoFinal; final != nil {
+		hasta := "Destino"
+		if tour.Modo == services.ModoTourCircular {
+			hasta = "Tu ubicacion"
 		}
+		pasosDetallados = append(pasosDetallados, PasoRuta{
+			Desde:             desde,
+			Hasta:             hasta,
+			DistanciaKm:       final.DistanciaKm,
+			TiempoEstimadoMin: int(math.Round(final.Trayecto.Minutes())),
+			LatitudOrigen:     origen.Latitud,
+			LongitudOrigen:    origen.Longitud,
+			LatitudDestino:    final.Destino.Latitud,
+			LongitudDestino:   final.Destino.Longitud,
+			PorCalles:         final.PorCalles,
+			Polilinea:         algorithms.CodificarPolilinea(final.Trazado),
+		})
 	}
 
-	// Calcular tiempo total estimado
-	tiempoTotal := 0
-	for _, paso := range pasosDetallados {
-		tiempoTotal += paso.TiempoEstimadoMin
+	noProgramados := []RestauranteNoProgramado{}
+	for _, parada := range tour.NoProgramados {
+		noProgramados = append(noProgramados, RestauranteNoProgramado{
+			IDRestaurante: int(parada.Restaurante.IDRestaurante),
+			Nombre:        parada.Restaurante.Nombre,
+			Motivo:        parada.Motivo,
+		})
 	}
 
-	// Agregar tiempo de permanencia estimado en cada restaurante (30 min)
-	tiempoTotal += len(rutaOptimizada) * 30
-
-	// Preparar respuesta
+	// Preparar respuesta; el tiempo total incluye trayectos, esperas y estancias
 	response := ResponseTour{
-		Ruta:            rutaDetallada,
-		DistanciaTotal:  distanciaTotal,
-		TiempoEstimado:  tiempoTotal,
-		Algoritmo:       "A* (A Star)",
-		PasosDetallados: pasosDetallados,
+		Modo:              tour.Modo,
+		ModoViaje:         tour.ModoViaje,
+		Objetivo:          tour.Objetivo,
+		Ruta:              rutaDetallada,
+		DistanciaTotal:    tour.DistanciaKm,
+		TiempoEstimado:    int(math.Round(tour.Fin.Sub(tour.Inicio).Minutes())),
+		Algoritmo:         descripcionMetodo(tour.Metodo),
+		Metodo:            tour.Metodo,
+		Exacto:            tour.Exacto,
+		CotaInferiorKm:    tour.CotaInferiorKm,
+		CotaInferiorMin:   int(math.Round(tour.CotaInferior.Minutes())),
+		BrechaOptimalidad: math.Round(tour.BrechaOptimalidad*10000) / 100,
+		PasosDetallados:   pasosDetallados,
+		Inicio:            tour.Inicio,
+		Fin:               tour.Fin,
+		NoProgramados:     noProgramados,
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -225,81 +265,63 @@ func (th *TourHandler) GenerarTour(c *gin.Context) {
 	})
 }
 
-// calcularDistancia usa Haversine para calcular distancia entre coordenadas
-func (th *TourHandler) calcularDistancia(coord1, coord2 algorithms.Coordenada) float64 {
-	// Reutilizar la funcion del optimizador
-	optimizador := algorithms.NewAStarOptimizer()
-	optimizador.AgregarNodo(1, "", coord1.Latitud, coord1.Longitud)
-	optimizador.AgregarNodo(2, "", coord2.Latitud, coord2.Longitud)
-
-	// Calcular usando la funcion interna (necesitariamos exportarla)
-	// Por ahora, usar una implementacion simple
-	return calcularDistanciaHaversine(coord1, coord2)
-}
-
-// calcularTiempo estima el tiempo de viaje en minutos
-func (th *TourHandler) calcularTiempo(distanciaKm float64) int {
-	// Asumir velocidad promedio de 30 km/h en ciudad
-	velocidadPromedio := 30.0
-	tiempoHoras := distanciaKm / velocidadPromedio
-	tiempoMinutos := int(tiempoHoras * 60)
-
-	// Minimo 5 minutos
-	if tiempoMinutos < 5 {
-		tiempoMinutos = 5
+// solicitud valida la hora de inicio, las estancias y el cierre del request
+func (req RequestGenerarTour) solicitud() (services.SolicitudTour, error) {
+	solicitud := services.SolicitudTour{
+		Origen:    req.UbicacionUsuario,
+		Inicio:    time.Now(),
+		Estancia:  services.EstanciaTourPorDefecto,
+		Estancias: make(map[uint]time.Duration, len(req.EstanciaPorRestaurante)),
+		Regresar:  req.Regresar,
+		Destino:   req.Destino,
+		ModoViaje: req.Preferencias.ModoViaje,
+		Objetivo:  req.Preferencias.Optimizar,
 	}
 
-	return tiempoMinutos
-}
-
-// Funcion auxiliar para calcular distancia Haversine
-func calcularDistanciaHaversine(coord1, coord2 algorithms.Coordenada) float64 {
-	const radioTierraKm = 6371.0
-	const pi = 3.14159265359
-
-	lat1Rad := coord1.Latitud * pi / 180
-	lon1Rad := coord1.Longitud * pi / 180
-	lat2Rad := coord2.Latitud * pi / 180
-	lon2Rad := coord2.Longitud * pi / 180
-
-	dLat := lat2Rad - lat1Rad
-	dLon := lon2Rad - lon1Rad
-
-	a := sinCuadrado(dLat/2) + cosPi(lat1Rad)*cosPi(lat2Rad)*sinCuadrado(dLon/2)
-	c := 2 * asinPi(sqrtPi(a))
+	if req.Regresar && req.Destino != nil {
+		return solicitud, errors.New("elige entre regresar al origen o terminar en un destino")
+	}
 
-	return radioTierraKm * c
-}
+	for _, id := range []int{req.PrimeraParada, req.UltimaParada} {
+		if id != 0 && !slices.Contains(req.IDsRestaurantes, id) {
+			return solicitud, fmt.Errorf("el restaurante %d no esta en el tour", id)
+		}
+	}
+	if req.PrimeraParada != 0 && req.PrimeraParada == req.UltimaParada {
+		return solicitud, errors.New("la primera y la ultima parada deben ser restaurantes distintos")
+	}
+	solicitud.PrimeraParada = uint(max(req.PrimeraParada, 0))
+	solicitud.UltimaParada = uint(max(req.UltimaParada, 0))
 
-// Funciones matematicas auxiliares
-func sinCuadrado(x float64) float64 {
-	s := sinPi(x)
-	return s * s
-}
+	if req.Inicio != "" {
+		inicio, err := time.Parse(time.RFC3339, req.Inicio)
+		if err != nil {
+			return solicitud, errors.New("inicio invalido, usa el formato RFC 3339 como 2026-05-01T14:00:00-06:00")
+		}
+		solicitud.Inicio = inicio
+	}
 
-func sinPi(x float64) float64 {
-	// Aproximacion simple de sin
-	return x - (x*x*x)/6 + (x*x*x*x*x)/120
-}
+	if req.EstanciaMinutos != 0 {
+		if req.EstanciaMinutos < 0 || req.EstanciaMinutos > maxEstanciaMinutos {
+			return solicitud, fmt.Errorf("la estancia debe estar entre 1 y %d minutos", maxEstanciaMinutos)
+		}
+		solicitud.Estancia = time.Duration(req.EstanciaMinutos) * time.Minute
+	}
 
-func cosPi(x float64) float64 {
-	// Aproximacion simple de cos
-	return 1 - (x*x)/2 + (x*x*x*x)/24
-}
+	for id, minutos := range req.EstanciaPorRestaurante {
+		if minutos <= 0 || minutos > maxEstanciaMinutos {
+			return solicitud, fmt.Errorf("la estancia del restaurante %d debe estar entre 1 y %d minutos", id, maxEstanciaMinutos)
+		}
+		solicitud.Estancias[uint(id)] = time.Duration(minutos) * time.Minute
+	}
 
-func asinPi(x float64) float64 {
-	// Aproximacion simple de asin
-	return x + (x*x*x)/6
+	return solicitud, nil
 }
 
-func sqrtPi(x float64) float64 {
-	// Metodo de Newton para raiz cuadrada
-	if x == 0 {
-		return 0
-	}
-	z := x
-	for i := 0; i < 10; i++ {
-		z = (z + x/z) / 2
+// descripcionMetodo da un nombre legible al metodo con el que se resolvio el tour
+func descripcionMetodo(metodo string) string {
+	if metodo == algorithms.MetodoHeldKarp {
+		return "Held-Karp (programacion dinamica exacta)"
 	}
-	return z
+	return "Vecino mas cercano + 2-opt/Or-opt"
 }
@@ -2,13 +2,16 @@ package main
 
 import (
 	"log"
+	"math"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/tuusuario/quovi/algorithms"
 	"github.com/tuusuario/quovi/handlers"
 	"github.com/tuusuario/quovi/middleware"
 	"github.com/tuusuario/quovi/repository"
@@ -49,17 +52,78 @@ func main() {
 	// Inicializar servicios con sus dependencias
 	jwtSecret := getEnv("JWT_SECRET", "mi-secreto-super-seguro-cambiar-en-produccion")
 	authService := services.NewAuthService(dbManager, jwtSecret)
-	restauranteService := services.NewRestauranteService(dbManager)
+	indiceRestaurantes := services.NewIndiceRestaurantes(dbManager)
+	if err := indiceRestaurantes.Reconstruir(); err != nil {
+		log.Printf("Advertencia: no se pudo construir el indice de busqueda: %v", err)
+	}
+	indiceRestaurantes.IniciarRefresco(
+		time.Duration(getEnvInt("INDICE_REFRESCO_SEGUNDOS", 60))*time.Second,
+		time.Duration(getEnvInt("INDICE_RECONSTRUCCION_MINUTOS", 60))*time.Minute,
+	)
+	sugerenciasService := services.NewSugerenciasService(dbManager, indiceRestaurantes)
+	if err := sugerenciasService.Reconstruir(); err != nil {
+		log.Printf("Advertencia: no se pudieron construir las sugerencias de busqueda: %v", err)
+	}
+	sugerenciasService.IniciarRefresco(
+		time.Duration(getEnvInt("INDICE_REFRESCO_SEGUNDOS", 60))*time.Second,
+		time.Duration(getEnvInt("SUGERENCIAS_POPULARES_MINUTOS", 15))*time.Minute,
+	)
+	restauranteService := services.NewRestauranteService(dbManager, indiceRestaurantes)
 	perfilService := services.NewPerfilService(dbManager)
-	platilloService := services.NewPlatilloService(dbManager)
-	tourService := services.NewTourService(dbManager) // NUEVO: Servicio de tours
+	platilloService := services.NewPlatilloService(dbManager, indiceRestaurantes)
+	tourService := services.NewTourService(dbManager, services.ConfiguracionTour{
+		MaxParadasExacto:        getEnvInt("TOUR_MAX_PARADAS_EXACTO", 15),
+		PresupuestoOptimizacion: time.Duration(getEnvInt("TOUR_PRESUPUESTO_MS", 200)) * time.Millisecond,
+		ModosViaje:              getModosViaje(),
+		RedVial:                 cargarRedVial(getEnv("TOUR_RED_VIAL_GEOJSON", "")),
+	})
+	reseñaService := services.NewReseñaService(dbManager, services.ConfiguracionReseñas{
+		RadioCheckinMetros:       getEnvFloat("CHECKIN_RADIO_METROS", 150),
+		DiasVigenciaVisita:       getEnvInt("VISITA_VIGENCIA_DIAS", 30),
+		MaxFotosPorReseña:        getEnvInt("RESENA_MAX_FOTOS", 5),
+		AprobacionesParaPromover: getEnvInt("RESENA_APROBACIONES_PROMOVER", 5),
+		Filtro: services.FiltrosCombinados{
+			services.NewFiltroListaPalabras(services.PalabrasProhibidasPorDefecto),
+			services.FiltroEnlaces{},
+		},
+	})
+	moderacionService := services.NewModeracionService(dbManager, getEnvInt("MODERACION_UMBRAL_REPORTES", 3))
+	respuestaReseñaService := services.NewRespuestaReseñaService(dbManager)
+	notificacionService := services.NewNotificacionService(dbManager)
+	propietarioService := services.NewPropietarioService(dbManager)
+	historialBusquedaService := services.NewHistorialBusquedaService(dbManager)
+	mapaService := services.NewMapaService(dbManager)
+	etiquetasPlatilloService := services.NewEtiquetasPlatilloService(dbManager)
+	if err := etiquetasPlatilloService.Sincronizar(); err != nil {
+		log.Printf("Advertencia: no se pudieron sincronizar las etiquetas de platillos: %v", err)
+	}
+	etiquetasPlatilloService.IniciarRefresco(time.Duration(getEnvInt("INDICE_REFRESCO_SEGUNDOS", 60)) * time.Second)
+	preciosRestauranteService := services.NewPreciosRestauranteService(dbManager)
+	if err := preciosRestauranteService.Reconstruir(); err != nil {
+		log.Printf("Advertencia: no se pudieron calcular los precios de los restaurantes: %v", err)
+	}
+	preciosRestauranteService.IniciarRefresco(
+		time.Duration(getEnvInt("INDICE_REFRESCO_SEGUNDOS", 60))*time.Second,
+		time.Duration(getEnvInt("INDICE_RECONSTRUCCION_MINUTOS", 60))*time.Minute,
+	)
+	excepcionesHorarioService := services.NewExcepcionesHorarioService(dbManager)
 
 	// Inicializar handlers
 	authHandler := handlers.NewAuthHandler(authService)
-	restauranteHandler := handlers.NewRestauranteHandler(restauranteService)
+	restauranteHandler := handlers.NewRestauranteHandler(restauranteService, historialBusquedaService)
 	perfilHandler := handlers.NewPerfilHandler(perfilService)
-	platilloHandler := handlers.NewPlatilloHandler(platilloService)
+	platilloHandler := handlers.NewPlatilloHandler(platilloService, historialBusquedaService)
 	tourHandler := handlers.NewTourHandler(tourService) // NUEVO: Handler de tours
+	reseñaHandler := handlers.NewReseñaHandler(reseñaService)
+	moderacionHandler := handlers.NewModeracionHandler(moderacionService)
+	respuestaReseñaHandler := handlers.NewRespuestaReseñaHandler(respuestaReseñaService)
+	notificacionHandler := handlers.NewNotificacionHandler(notificacionService)
+	propietarioHandler := handlers.NewPropietarioHandler(propietarioService)
+	historialBusquedaHandler := handlers.NewHistorialBusquedaHandler(historialBusquedaService)
+	sugerenciasHandler := handlers.NewSugerenciasHandler(sugerenciasService)
+	mapaHandler := handlers.NewMapaHandler(mapaService)
+	etiquetasPlatilloHandler := handlers.NewEtiquetasPlatilloHandler(etiquetasPlatilloService)
+	excepcionesHorarioHandler := handlers.NewExcepcionesHorarioHandler(excepcionesHorarioService)
 
 	// Configurar modo de Gin según el entorno
 	if getEnv("ENVIRONMENT", "development") == "production" {
@@ -105,13 +169,27 @@ func main() {
 		restaurantes := api.Group("/restaurantes")
 		{
 			restaurantes.GET("", restauranteHandler.ObtenerTodosLosRestaurantes)
+			restaurantes.GET("/sugerencias", sugerenciasHandler.ObtenerSugerencias)
+			restaurantes.GET("/mapa", mapaHandler.ObtenerMapa)
 			restaurantes.GET("/:id", restauranteHandler.ObtenerRestaurantePorID)
 			restaurantes.GET("/:id/platillos", platilloHandler.ObtenerPlatillosPorRestaurante)
 			restaurantes.GET("/:id/platillos/destacados", platilloHandler.ObtenerPlatilloDestacados)
+			restaurantes.GET("/:id/resenas", reseñaHandler.ObtenerReseñas)
+			restaurantes.GET("/:id/excepciones-horario", excepcionesHorarioHandler.ObtenerExcepciones)
 			restaurantes.POST("/cercanos", restauranteHandler.ObtenerRestaurantesCercanos)
-			restaurantes.POST("/buscar", restauranteHandler.BuscarRestaurantes)
+			restaurantes.POST("/buscar", authHandler.IdentificarUsuario, restauranteHandler.BuscarRestaurantes)
+		}
+
+		// Busqueda por platillo (publica)
+		platillos := api.Group("/platillos")
+		{
+			platillos.POST("/buscar", authHandler.IdentificarUsuario, platilloHandler.BuscarPlatillos)
+			platillos.GET("/etiquetas", etiquetasPlatilloHandler.ObtenerCatalogoEtiquetas)
 		}
 
+		// Busquedas populares (publica)
+		api.GET("/busquedas/populares", historialBusquedaHandler.ObtenerBusquedasPopulares)
+
 		// Rutas de categorías (públicas)
 		categorias := api.Group("/categorias")
 		{
@@ -119,8 +197,20 @@ func main() {
 			categorias.GET("/:id/restaurantes", restauranteHandler.ObtenerRestaurantesPorCategoria)
 		}
 
-		// Ruta de ciudades (pública)
-		api.GET("/ciudades", restauranteHandler.ObtenerCiudades)
+		// Rutas de caracteristicas (publicas)
+		caracteristicas := api.Group("/caracteristicas")
+		{
+			caracteristicas.GET("", restauranteHandler.ObtenerCaracteristicas)
+			caracteristicas.GET("/:id/restaurantes", restauranteHandler.ObtenerRestaurantesPorCaracteristica)
+		}
+
+		// Rutas de ciudades (públicas)
+		ciudades := api.Group("/ciudades")
+		{
+			ciudades.GET("", restauranteHandler.ObtenerCiudades)
+			ciudades.GET("/:id/restaurantes", restauranteHandler.ObtenerRestaurantesPorCiudad)
+			ciudades.GET("/:id/resumen", restauranteHandler.ObtenerResumenCiudad)
+		}
 
 		// NUEVO: Rutas de tours (públicas)
 		tours := api.Group("/tours")
@@ -152,6 +242,72 @@ func main() {
 				favoritos.POST("", restauranteHandler.AgregarFavorito)
 				favoritos.DELETE("/:id", restauranteHandler.EliminarFavorito)
 			}
+
+			// Reseñas del usuario (una por restaurante)
+			resenas := protected.Group("/restaurantes/:id/resenas")
+			{
+				resenas.GET("/mia", reseñaHandler.ObtenerMiReseña)
+				resenas.POST("", reseñaHandler.CrearReseña)
+				resenas.PUT("", reseñaHandler.ActualizarReseña)
+				resenas.DELETE("", reseñaHandler.EliminarReseña)
+			}
+			protected.POST("/restaurantes/:id/checkin", reseñaHandler.RegistrarCheckin)
+			protected.POST("/resenas/:id/reportes", moderacionHandler.ReportarReseña)
+			protected.PUT("/resenas/:id/voto", reseñaHandler.VotarReseña)
+			protected.DELETE("/resenas/:id/voto", reseñaHandler.EliminarVotoReseña)
+			protected.POST("/resenas/:id/imagenes", reseñaHandler.SubirImagenesReseña)
+			protected.DELETE("/resenas/:id/imagenes/:idImagen", reseñaHandler.EliminarImagenReseña)
+			protected.POST("/resenas/:id/imagenes/:idImagen/aprobar", reseñaHandler.AprobarImagenReseña)
+
+			// Respuestas de propietarios a reseñas
+			protected.POST("/resenas/:id/respuesta", respuestaReseñaHandler.ResponderReseña)
+			protected.PUT("/resenas/:id/respuesta", respuestaReseñaHandler.EditarRespuestaReseña)
+			protected.DELETE("/resenas/:id/respuesta", respuestaReseñaHandler.EliminarRespuestaReseña)
+
+			// Cierres y horarios especiales que administra el propietario
+			excepcionesHorario := protected.Group("/restaurantes/:id/excepciones-horario")
+			{
+				excepcionesHorario.POST("", excepcionesHorarioHandler.CrearExcepcion)
+				excepcionesHorario.PUT("/:idExcepcion", excepcionesHorarioHandler.ActualizarExcepcion)
+				excepcionesHorario.DELETE("/:idExcepcion", excepcionesHorarioHandler.EliminarExcepcion)
+			}
+
+			// Etiquetas de dieta y alergenos que asigna el propietario
+			protected.PUT("/platillos/:id/etiquetas", etiquetasPlatilloHandler.AsignarEtiquetas)
+			protected.DELETE("/platillos/:id/etiquetas", etiquetasPlatilloHandler.RestablecerEtiquetas)
+
+			// Historial de busquedas del usuario
+			busquedas := protected.Group("/busquedas/recientes")
+			{
+				busquedas.GET("", historialBusquedaHandler.ObtenerBusquedasRecientes)
+				busquedas.DELETE("", historialBusquedaHandler.LimpiarBusquedasRecientes)
+				busquedas.DELETE("/:id", historialBusquedaHandler.EliminarBusquedaReciente)
+			}
+
+			// Notificaciones del usuario
+			notificaciones := protected.Group("/notificaciones")
+			{
+				notificaciones.GET("", notificacionHandler.ObtenerNotificaciones)
+				notificaciones.PUT("/leidas", notificacionHandler.MarcarTodasLeidas)
+				notificaciones.PUT("/:id/leida", notificacionHandler.MarcarNotificacionLeida)
+			}
+
+			// Administracion (requiere rol admin)
+			admin := protected.Group("/admin")
+			admin.Use(authHandler.VerificarAdmin)
+			{
+				moderacion := admin.Group("/moderacion")
+				{
+					moderacion.GET("/resenas", moderacionHandler.ObtenerColaModeracion)
+					moderacion.GET("/resenas/:id/reportes", moderacionHandler.ObtenerReportes)
+					moderacion.PUT("/resenas/:id/estado", moderacionHandler.CambiarEstadoReseña)
+				}
+
+				admin.POST("/busqueda/reindexar", restauranteHandler.ReconstruirIndiceBusqueda)
+				admin.GET("/restaurantes/:id/propietarios", propietarioHandler.ObtenerPropietarios)
+				admin.POST("/restaurantes/:id/propietarios", propietarioHandler.AsignarPropietario)
+				admin.DELETE("/restaurantes/:id/propietarios/:idUsuario", propietarioHandler.QuitarPropietario)
+			}
 		}
 	}
 
@@ -190,6 +346,72 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvFloat obtiene una variable de entorno numerica o retorna un valor por defecto
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvInt obtiene una variable de entorno entera o retorna un valor por defecto
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getModosViaje ajusta la velocidad y el rodeo de cada modo de viaje con variables de entorno,
+// por ejemplo TOUR_VELOCIDAD_BICICLETA_KMH y TOUR_RODEO_BICICLETA
+// Las velocidades deben ser positivas y el rodeo al menos 1; si no, se conserva el valor por defecto
+func getModosViaje() map[string]services.ModoViaje {
+	modos := make(map[string]services.ModoViaje, len(services.ModosViajePorDefecto))
+	for nombre, modo := range services.ModosViajePorDefecto {
+		sufijo := strings.ToUpper(nombre)
+		modo.VelocidadKmH = getEnvFloatMinimo("TOUR_VELOCIDAD_"+sufijo+"_KMH", modo.VelocidadKmH, 0, false)
+		modo.FactorRodeo = getEnvFloatMinimo("TOUR_RODEO_"+sufijo, modo.FactorRodeo, 1, true)
+		modos[nombre] = modo
+	}
+	return modos
+}
+
+// getEnvFloatMinimo obtiene una variable numerica que debe superar minimo, o igualarlo si incluyente
+// Los valores fuera de rango o no finitos se ignoran con una advertencia
+func getEnvFloatMinimo(key string, defaultValue, minimo float64, incluyente bool) float64 {
+	value := getEnvFloat(key, defaultValue)
+	valido := value > minimo || (incluyente && value == minimo)
+	if !valido || math.IsInf(value, 0) {
+		log.Printf("Advertencia: %s=%v fuera de rango, se usa %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// cargarRedVial lee las calles de un extracto de OpenStreetMap en GeoJSON; sin archivo, o si no se
+// puede leer, los tours miden los trayectos en linea recta
+func cargarRedVial(ruta string) *algorithms.RedVial {
+	if ruta == "" {
+		return nil
+	}
+	archivo, err := os.Open(ruta)
+	if err != nil {
+		log.Printf("Advertencia: no se pudo abrir la red vial: %v", err)
+		return nil
+	}
+	defer archivo.Close()
+
+	red, err := algorithms.CargarRedVialGeoJSON(archivo)
+	if err != nil {
+		log.Printf("Advertencia: no se pudo cargar la red vial: %v", err)
+		return nil
+	}
+	log.Printf("Red vial cargada: %d nodos y %d tramos", red.Nodos(), red.Tramos())
+	return red
+}
+
 // getCORSOrigins obtiene los orígenes permitidos para CORS desde variables de entorno
 func getCORSOrigins() []string {
 	corsOriginsStr := getEnv("CORS_ORIGINS", "http://localhost:3000,http://localhost:3001")
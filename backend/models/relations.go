@@ -29,6 +29,7 @@ type Busqueda struct {
 	IDUsuario             *uint     `gorm:"column:idUsuario" json:"idUsuario,omitempty"`
 	Texto                 string    `gorm:"column:texto;type:text;not null" json:"texto"`
 	Contexto              string    `gorm:"column:contexto;size:100" json:"contexto,omitempty"`
+	IDCiudad              *uint     `gorm:"column:idCiudad" json:"idCiudad,omitempty"`
 	ResultadosEncontrados int       `gorm:"column:resultadosEncontrados" json:"resultadosEncontrados"`
 	Fecha                 time.Time `gorm:"column:fecha;not null;default:CURRENT_TIMESTAMP" json:"fecha"`
 
@@ -39,24 +40,202 @@ func (Busqueda) TableName() string {
 	return "busquedas"
 }
 
+// Contextos en los que se registra una busqueda
+const (
+	ContextoBusquedaRestaurantes = "restaurantes"
+	ContextoBusquedaPlatillos    = "platillos"
+)
+
 // Reseña representa una calificacion y comentario sobre un restaurante
 type Reseña struct {
-	IDReseña      uint      `gorm:"column:idReseña;primaryKey;autoIncrement" json:"idReseña"`
+	IDReseña      uint      `gorm:"column:idResena;primaryKey;autoIncrement" json:"idReseña"`
 	IDUsuario     uint      `gorm:"column:idUsuario;not null" json:"idUsuario"`
 	IDRestaurante uint      `gorm:"column:idRestaurante;not null" json:"idRestaurante"`
 	Calificacion  int8      `gorm:"column:calificacion;not null;check:calificacion >= 1 AND calificacion <= 5" json:"calificacion"`
 	Comentario    string    `gorm:"column:comentario;type:text" json:"comentario,omitempty"`
 	Fecha         time.Time `gorm:"column:fecha;not null;default:CURRENT_TIMESTAMP" json:"fecha"`
 	Verificada    bool      `gorm:"column:verificada;default:false" json:"verificada"`
+	Estado        string    `gorm:"column:estado;size:20;not null;default:'publicada'" json:"estado"`
 
-	Usuario     Usuario     `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"usuario,omitempty"`
-	Restaurante Restaurante `gorm:"foreignKey:IDRestaurante;constraint:OnDelete:CASCADE" json:"restaurante,omitempty"`
+	// Votos de utilidad y su limite inferior de Wilson, usado para ordenar por "mas utiles"
+	VotosUtiles        int     `gorm:"column:votosUtiles;default:0" json:"votosUtiles"`
+	VotosNoUtiles      int     `gorm:"column:votosNoUtiles;default:0" json:"votosNoUtiles"`
+	PuntuacionUtilidad float64 `gorm:"column:puntuacionUtilidad;type:decimal(6,5);default:0" json:"puntuacionUtilidad"`
+
+	Usuario     Usuario          `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"usuario,omitempty"`
+	Restaurante Restaurante      `gorm:"foreignKey:IDRestaurante;constraint:OnDelete:CASCADE" json:"restaurante,omitempty"`
+	Imagenes    []ImagenReseña   `gorm:"foreignKey:IDReseña" json:"imagenes,omitempty"`
+	Respuesta   *RespuestaReseña `gorm:"foreignKey:IDReseña" json:"respuesta,omitempty"`
 }
 
 func (Reseña) TableName() string {
 	return "resenas"
 }
 
+// VotoReseña registra si a un usuario le resulto util una reseña
+type VotoReseña struct {
+	IDReseña  uint      `gorm:"column:idResena;primaryKey;not null" json:"idReseña"`
+	IDUsuario uint      `gorm:"column:idUsuario;primaryKey;not null" json:"idUsuario"`
+	Util      bool      `gorm:"column:util;not null" json:"util"`
+	Fecha     time.Time `gorm:"column:fecha;not null;default:CURRENT_TIMESTAMP" json:"fecha"`
+
+	Reseña  Reseña  `gorm:"foreignKey:IDReseña;constraint:OnDelete:CASCADE" json:"-"`
+	Usuario Usuario `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (VotoReseña) TableName() string {
+	return "votos_resena"
+}
+
+// RespuestaReseña es la contestacion publica del propietario del restaurante a una reseña
+// Cada reseña admite una sola respuesta, que puede editarse
+type RespuestaReseña struct {
+	IDRespuesta  uint       `gorm:"column:idRespuesta;primaryKey;autoIncrement" json:"idRespuesta"`
+	IDReseña     uint       `gorm:"column:idResena;not null;unique" json:"idReseña"`
+	IDUsuario    uint       `gorm:"column:idUsuario;not null" json:"idUsuario"`
+	Comentario   string     `gorm:"column:comentario;type:text;not null" json:"comentario"`
+	Fecha        time.Time  `gorm:"column:fecha;not null;default:CURRENT_TIMESTAMP" json:"fecha"`
+	FechaEdicion *time.Time `gorm:"column:fechaEdicion" json:"fechaEdicion,omitempty"`
+
+	Usuario Usuario `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"usuario,omitempty"`
+}
+
+func (RespuestaReseña) TableName() string {
+	return "respuestas_resena"
+}
+
+// PropietarioRestaurante vincula a un usuario con un restaurante que administra
+type PropietarioRestaurante struct {
+	IDRestaurante uint      `gorm:"column:idRestaurante;primaryKey;not null" json:"idRestaurante"`
+	IDUsuario     uint      `gorm:"column:idUsuario;primaryKey;not null" json:"idUsuario"`
+	Fecha         time.Time `gorm:"column:fecha;not null;default:CURRENT_TIMESTAMP" json:"fecha"`
+
+	Usuario     Usuario     `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"-"`
+	Restaurante Restaurante `gorm:"foreignKey:IDRestaurante;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (PropietarioRestaurante) TableName() string {
+	return "propietarios_restaurante"
+}
+
+// ImagenReseña es una foto adjunta por el autor de una reseña
+// Con suficientes aprobaciones puede promoverse a la galeria del restaurante
+type ImagenReseña struct {
+	IDImagen     uint      `gorm:"column:idImagen;primaryKey;autoIncrement" json:"idImagen"`
+	IDReseña     uint      `gorm:"column:idResena;not null" json:"idReseña"`
+	IDUsuario    uint      `gorm:"column:idUsuario;not null" json:"idUsuario"`
+	URL          string    `gorm:"column:url;size:500;not null" json:"url"`
+	Aprobaciones int       `gorm:"column:aprobaciones;default:0" json:"aprobaciones"`
+	Promovida    bool      `gorm:"column:promovida;default:false" json:"promovida"`
+	FechaSubida  time.Time `gorm:"column:fechaSubida;not null;default:CURRENT_TIMESTAMP" json:"fechaSubida"`
+
+	Reseña  Reseña  `gorm:"foreignKey:IDReseña;constraint:OnDelete:CASCADE" json:"-"`
+	Usuario Usuario `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (ImagenReseña) TableName() string {
+	return "imagenes_resena"
+}
+
+// AprobacionImagenReseña registra que un usuario aprobo una foto de reseña
+type AprobacionImagenReseña struct {
+	IDImagen  uint      `gorm:"column:idImagen;primaryKey;not null" json:"idImagen"`
+	IDUsuario uint      `gorm:"column:idUsuario;primaryKey;not null" json:"idUsuario"`
+	Fecha     time.Time `gorm:"column:fecha;not null;default:CURRENT_TIMESTAMP" json:"fecha"`
+
+	Imagen  ImagenReseña `gorm:"foreignKey:IDImagen;constraint:OnDelete:CASCADE" json:"-"`
+	Usuario Usuario      `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (AprobacionImagenReseña) TableName() string {
+	return "aprobaciones_imagen_resena"
+}
+
+// Estados de moderacion de una reseña
+const (
+	EstadoReseñaPendiente = "pendiente"
+	EstadoReseñaPublicada = "publicada"
+	EstadoReseñaOculta    = "oculta"
+	EstadoReseñaEliminada = "eliminada"
+)
+
+// ReporteReseña representa la denuncia de un usuario sobre una reseña
+type ReporteReseña struct {
+	IDReporte       uint       `gorm:"column:idReporte;primaryKey;autoIncrement" json:"idReporte"`
+	IDReseña        uint       `gorm:"column:idResena;not null" json:"idReseña"`
+	IDUsuario       uint       `gorm:"column:idUsuario;not null" json:"idUsuario"`
+	Motivo          string     `gorm:"column:motivo;size:20;not null" json:"motivo"`
+	Descripcion     string     `gorm:"column:descripcion;size:500" json:"descripcion,omitempty"`
+	Estado          string     `gorm:"column:estado;size:20;not null;default:'pendiente'" json:"estado"`
+	Fecha           time.Time  `gorm:"column:fecha;not null;default:CURRENT_TIMESTAMP" json:"fecha"`
+	IDModerador     *uint      `gorm:"column:idModerador" json:"idModerador,omitempty"`
+	FechaResolucion *time.Time `gorm:"column:fechaResolucion" json:"fechaResolucion,omitempty"`
+
+	Reseña  Reseña  `gorm:"foreignKey:IDReseña;constraint:OnDelete:CASCADE" json:"-"`
+	Usuario Usuario `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (ReporteReseña) TableName() string {
+	return "reportes_resena"
+}
+
+// Motivos validos para reportar una reseña
+const (
+	MotivoReporteSpam        = "spam"
+	MotivoReporteOfensivo    = "ofensivo"
+	MotivoReporteFalso       = "falso"
+	MotivoReporteIrrelevante = "irrelevante"
+	MotivoReporteOtro        = "otro"
+)
+
+// Estados de un reporte
+const (
+	EstadoReportePendiente  = "pendiente"
+	EstadoReporteResuelto   = "resuelto"
+	EstadoReporteDescartado = "descartado"
+)
+
+// Visita registra un check-in del usuario dentro del radio de un restaurante
+type Visita struct {
+	IDVisita        uint      `gorm:"column:idVisita;primaryKey;autoIncrement" json:"idVisita"`
+	IDUsuario       uint      `gorm:"column:idUsuario;not null" json:"idUsuario"`
+	IDRestaurante   uint      `gorm:"column:idRestaurante;not null" json:"idRestaurante"`
+	Latitud         float64   `gorm:"column:latitud;type:decimal(10,8);not null" json:"latitud"`
+	Longitud        float64   `gorm:"column:longitud;type:decimal(11,8);not null" json:"longitud"`
+	DistanciaMetros float64   `gorm:"column:distanciaMetros;type:decimal(8,2);not null" json:"distanciaMetros"`
+	Fecha           time.Time `gorm:"column:fecha;not null;default:CURRENT_TIMESTAMP" json:"fecha"`
+
+	Usuario     Usuario     `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"-"`
+	Restaurante Restaurante `gorm:"foreignKey:IDRestaurante;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (Visita) TableName() string {
+	return "visitas"
+}
+
+// Notificacion es un aviso dentro de la aplicacion para un usuario
+type Notificacion struct {
+	IDNotificacion uint      `gorm:"column:idNotificacion;primaryKey;autoIncrement" json:"idNotificacion"`
+	IDUsuario      uint      `gorm:"column:idUsuario;not null" json:"idUsuario"`
+	Tipo           string    `gorm:"column:tipo;size:30;not null" json:"tipo"`
+	Mensaje        string    `gorm:"column:mensaje;size:500;not null" json:"mensaje"`
+	IDRestaurante  *uint     `gorm:"column:idRestaurante" json:"idRestaurante,omitempty"`
+	IDReseña       *uint     `gorm:"column:idResena" json:"idReseña,omitempty"`
+	Leida          bool      `gorm:"column:leida;default:false" json:"leida"`
+	Fecha          time.Time `gorm:"column:fecha;not null;default:CURRENT_TIMESTAMP" json:"fecha"`
+
+	Usuario Usuario `gorm:"foreignKey:IDUsuario;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (Notificacion) TableName() string {
+	return "notificaciones"
+}
+
+// Tipos de notificacion
+const (
+	TipoNotificacionRespuestaReseña = "respuesta_resena"
+)
+
 // Favorito representa la relacion entre usuarios y sus restaurantes favoritos
 type Favorito struct {
 	IDUsuario     uint      `gorm:"column:idUsuario;primaryKey;not null" json:"idUsuario"`
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -20,6 +21,7 @@ type Restaurante struct {
 	TotalResenas         int       `gorm:"column:totalResenas;default:0" json:"totalResenas"`
 	Activo               bool      `gorm:"column:activo;default:true" json:"activo"`
 	FechaRegistro        time.Time `gorm:"column:fechaRegistro;not null;default:CURRENT_TIMESTAMP" json:"fechaRegistro"`
+	FechaActualizacion   time.Time `gorm:"column:fechaActualizacion;autoUpdateTime" json:"-"`
 
 	// Relaciones
 	Ciudad          Ciudad                      `gorm:"foreignKey:IDCiudad" json:"ciudad,omitempty"`
@@ -27,7 +29,9 @@ type Restaurante struct {
 	Caracteristicas []CaracteristicaRestaurante `gorm:"many2many:restaurante_caracteristicas;foreignKey:IDRestaurante;joinForeignKey:idRestaurante;References:IDCaracteristica;joinReferences:idCaracteristica" json:"caracteristicas,omitempty"`
 	Platillos       []Platillo                  `gorm:"foreignKey:IDRestaurante" json:"platillos,omitempty"`
 	Horarios        []Horario                   `gorm:"foreignKey:IDRestaurante" json:"horarios,omitempty"`
+	Excepciones     []ExcepcionHorario          `gorm:"foreignKey:IDRestaurante" json:"excepcionesHorario,omitempty"`
 	Imagenes        []ImagenRestaurante         `gorm:"foreignKey:IDRestaurante" json:"imagenes,omitempty"`
+	Precios         *PreciosRestaurante         `gorm:"foreignKey:IDRestaurante" json:"precios,omitempty"`
 }
 
 func (Restaurante) TableName() string {
@@ -42,6 +46,7 @@ type Ciudad struct {
 	Pais         string  `gorm:"column:pais;size:50;default:'México'" json:"pais"`
 	Latitud      float64 `gorm:"column:latitud;type:decimal(10,8)" json:"latitud,omitempty"`
 	Longitud     float64 `gorm:"column:longitud;type:decimal(11,8)" json:"longitud,omitempty"`
+	ZonaHoraria  string  `gorm:"column:zonaHoraria;size:64;default:'America/Mexico_City'" json:"zonaHoraria"` // Nombre IANA, como America/Mexico_City
 }
 
 func (Ciudad) TableName() string {
@@ -83,16 +88,68 @@ type Platillo struct {
 	Imagen        string  `gorm:"column:imagen;size:500" json:"imagen,omitempty"`
 	Disponible    bool    `gorm:"column:disponible;default:true" json:"disponible"`
 	Destacado     bool    `gorm:"column:destacado;default:false" json:"destacado"`
+
+	// EtiquetasManuales indica que el propietario asigno etiquetas; los alergenos se siguen infiriendo
+	EtiquetasManuales bool `gorm:"column:etiquetasManuales;default:false" json:"etiquetasManuales"`
+
+	FechaActualizacion time.Time `gorm:"column:fechaActualizacion;autoUpdateTime" json:"-"`
+
+	// Relaciones
+	Etiquetas []PlatilloEtiqueta `gorm:"foreignKey:IDPlatillo" json:"etiquetas,omitempty"`
 }
 
 func (Platillo) TableName() string {
 	return "platillos"
 }
 
-// Horario define dias y horas de operacion
+// Etiquetas de dieta y alergenos de un platillo
+const (
+	EtiquetaVegetariano       = "vegetariano"
+	EtiquetaVegano            = "vegano"
+	EtiquetaSinGluten         = "sin_gluten"
+	EtiquetaContieneNueces    = "contiene_nueces"
+	EtiquetaContieneCacahuate = "contiene_cacahuate"
+	EtiquetaContieneMariscos  = "contiene_mariscos"
+	EtiquetaContieneLacteos   = "contiene_lacteos"
+)
+
+// PlatilloEtiqueta es una etiqueta de dieta o alergeno asignada a un platillo
+// Inferida es false si la asigno el propietario
+type PlatilloEtiqueta struct {
+	IDPlatillo uint   `gorm:"column:idPlatillo;primaryKey" json:"-"`
+	Etiqueta   string `gorm:"column:etiqueta;primaryKey;size:30" json:"etiqueta"`
+	Inferida   bool   `gorm:"column:inferida" json:"inferida"`
+}
+
+func (PlatilloEtiqueta) TableName() string {
+	return "platillo_etiquetas"
+}
+
+// PreciosRestaurante resume los precios del menu disponible de un restaurante
+// Es un dato derivado de platillos que se recalcula en segundo plano
+type PreciosRestaurante struct {
+	IDRestaurante       uint      `gorm:"column:idRestaurante;primaryKey" json:"-"`
+	TotalPlatillos      int       `gorm:"column:totalPlatillos" json:"totalPlatillos"`
+	PrecioMinimo        float64   `gorm:"column:precioMinimo;type:decimal(8,2)" json:"precioMinimo"`
+	PrecioMaximo        float64   `gorm:"column:precioMaximo;type:decimal(8,2)" json:"precioMaximo"`
+	PrecioMediano       float64   `gorm:"column:precioMediano;type:decimal(8,2)" json:"precioMediano"`
+	PrecioMinimoFuerte  *float64  `gorm:"column:precioMinimoFuerte;type:decimal(8,2)" json:"precioMinimoFuerte,omitempty"`
+	PrecioMedianoFuerte *float64  `gorm:"column:precioMedianoFuerte;type:decimal(8,2)" json:"precioMedianoFuerte,omitempty"`
+	PrecioReferencia    float64   `gorm:"column:precioReferencia;type:decimal(8,2)" json:"precioReferencia"`
+	FechaCalculo        time.Time `gorm:"column:fechaCalculo" json:"-"`
+}
+
+func (PreciosRestaurante) TableName() string {
+	return "precios_restaurante"
+}
+
+// Horario es un intervalo de atencion de un dia; un dia puede tener varios, como comida y cena
+// Si Cierre no es mayor que Apertura el intervalo termina al dia siguiente (iguales: 24 horas)
+// Las horas son locales de la zona horaria de la ciudad del restaurante
 type Horario struct {
-	IDRestaurante uint   `gorm:"column:idRestaurante;primaryKey;not null" json:"idRestaurante"`
-	Dia           int8   `gorm:"column:dia;primaryKey;not null;check:dia >= 1 AND dia <= 7" json:"dia"`
+	IDHorario     uint   `gorm:"column:idHorario;primaryKey;autoIncrement" json:"idHorario"`
+	IDRestaurante uint   `gorm:"column:idRestaurante;not null" json:"idRestaurante"`
+	Dia           int8   `gorm:"column:dia;not null;check:dia >= 1 AND dia <= 7" json:"dia"`
 	Apertura      string `gorm:"column:apertura;type:time;not null" json:"apertura"`
 	Cierre        string `gorm:"column:cierre;type:time;not null" json:"cierre"`
 	Cerrado       bool   `gorm:"column:cerrado;default:false" json:"cerrado"`
@@ -102,6 +159,40 @@ func (Horario) TableName() string {
 	return "horarios"
 }
 
+// ExcepcionHorario reemplaza los horarios semanales en una fecha o rango de fechas, como dias
+// festivos o eventos privados. Si Cerrado es false, Apertura y Cierre son el horario especial;
+// varias excepciones del mismo dia suman sus intervalos. Con RecurrenteAnual se ignora el año
+type ExcepcionHorario struct {
+	IDExcepcion     uint      `gorm:"column:idExcepcion;primaryKey;autoIncrement" json:"idExcepcion"`
+	IDRestaurante   uint      `gorm:"column:idRestaurante;not null" json:"idRestaurante"`
+	FechaInicio     time.Time `gorm:"column:fechaInicio;type:date;not null" json:"fechaInicio"`
+	FechaFin        time.Time `gorm:"column:fechaFin;type:date;not null" json:"fechaFin"`
+	Cerrado         bool      `gorm:"column:cerrado" json:"cerrado"`
+	Apertura        *string   `gorm:"column:apertura;type:time" json:"apertura,omitempty"`
+	Cierre          *string   `gorm:"column:cierre;type:time" json:"cierre,omitempty"`
+	RecurrenteAnual bool      `gorm:"column:recurrenteAnual" json:"recurrenteAnual"`
+	Motivo          string    `gorm:"column:motivo;size:100" json:"motivo,omitempty"`
+	FechaCreacion   time.Time `gorm:"column:fechaCreacion;autoCreateTime" json:"fechaCreacion"`
+}
+
+func (ExcepcionHorario) TableName() string {
+	return "excepciones_horario"
+}
+
+// MarshalJSON escribe las fechas como YYYY-MM-DD, el mismo formato con que se reciben
+func (e ExcepcionHorario) MarshalJSON() ([]byte, error) {
+	type excepcion ExcepcionHorario // Sin el metodo, para no llamarlo de nuevo
+	return json.Marshal(struct {
+		excepcion
+		FechaInicio string `json:"fechaInicio"`
+		FechaFin    string `json:"fechaFin"`
+	}{
+		excepcion:   excepcion(e),
+		FechaInicio: e.FechaInicio.Format(time.DateOnly),
+		FechaFin:    e.FechaFin.Format(time.DateOnly),
+	})
+}
+
 // ImagenRestaurante almacena fotos del establecimiento
 type ImagenRestaurante struct {
 	IDImagen      uint      `gorm:"column:idImagen;primaryKey;autoIncrement" json:"idImagen"`
@@ -110,6 +201,11 @@ type ImagenRestaurante struct {
 	EsPrincipal   bool      `gorm:"column:esPrincipal;default:false" json:"esPrincipal"`
 	Orden         int       `gorm:"column:orden;default:0" json:"orden"`
 	FechaSubida   time.Time `gorm:"column:fechaSubida;not null;default:CURRENT_TIMESTAMP" json:"fechaSubida"`
+
+	// Atribucion de fotos promovidas desde reseñas
+	IDUsuarioAutor *uint  `gorm:"column:idUsuarioAutor" json:"idUsuarioAutor,omitempty"`
+	Credito        string `gorm:"column:credito;size:150" json:"credito,omitempty"`
+	IDImagenReseña *uint  `gorm:"column:idImagenResena" json:"idImagenReseña,omitempty"`
 }
 
 func (ImagenRestaurante) TableName() string {
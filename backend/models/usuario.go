@@ -17,6 +17,7 @@ type Usuario struct {
 	GoogleID        *string `gorm:"column:googleId;size:255;unique" json:"googleId,omitempty"`
 	Provider        string  `gorm:"column:provider;size:20;default:'local'" json:"provider"`
 	EmailVerificado bool    `gorm:"column:emailVerificado;default:false" json:"emailVerificado"`
+	Rol             string  `gorm:"column:rol;size:20;default:'usuario'" json:"rol"`
 
 	FechaRegistro time.Time  `gorm:"column:fechaRegistro;not null;default:CURRENT_TIMESTAMP" json:"fechaRegistro"`
 	UltimoAcceso  *time.Time `gorm:"column:ultimoAcceso" json:"ultimoAcceso,omitempty"`
@@ -31,3 +32,9 @@ type Usuario struct {
 func (Usuario) TableName() string {
 	return "usuarios"
 }
+
+// Roles de usuario
+const (
+	RolUsuario = "usuario"
+	RolAdmin   = "admin"
+)
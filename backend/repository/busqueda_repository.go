@@ -0,0 +1,459 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/quovi/algorithms"
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm"
+)
+
+// BandaPrecio agrupa restaurantes por su precio de referencia; Max 0 significa sin limite superior
+type BandaPrecio struct {
+	Clave string  `json:"clave"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max,omitempty"`
+}
+
+// BandasPrecio son los rangos de precio usados en filtros y facetas; se comparan con la mediana
+// de los platos fuertes del menu o, si el restaurante no tiene platillos con precio, con su precio promedio
+var BandasPrecio = []BandaPrecio{
+	{Clave: "$", Min: 0, Max: 150},
+	{Clave: "$$", Min: 150, Max: 300},
+	{Clave: "$$$", Min: 300, Max: 500},
+	{Clave: "$$$$", Min: 500},
+}
+
+// ObtenerBandaPrecio busca una banda por su clave
+func ObtenerBandaPrecio(clave string) (BandaPrecio, bool) {
+	for _, banda := range BandasPrecio {
+		if banda.Clave == clave {
+			return banda, true
+		}
+	}
+	return BandaPrecio{}, false
+}
+
+// Modos de combinar las caracteristicas pedidas en una busqueda
+const (
+	ModoCaracteristicasTodas  = "todas"  // El restaurante debe tener todas
+	ModoCaracteristicasAlguna = "alguna" // Basta con tener una
+)
+
+// EsModoCaracteristicasValido indica si el modo es uno de los soportados; vacio equivale a todas
+func EsModoCaracteristicasValido(modo string) bool {
+	return modo == "" || modo == ModoCaracteristicasTodas || modo == ModoCaracteristicasAlguna
+}
+
+// FiltroBusquedaRestaurantes combina todos los criterios de busqueda; los criterios vacios se ignoran
+// Dentro de categorias y bandas de precio basta con coincidir una; entre criterios se exigen todos
+type FiltroBusquedaRestaurantes struct {
+	Termino             string // Coincidencia LIKE; se usa solo si no hay indice de busqueda disponible
+	IDRestaurantes      []uint // Si no es nil restringe a estos IDs; un slice vacio no devuelve resultados
+	IDCategorias        []uint
+	NombreCategoria     string
+	IDCaracteristicas   []uint
+	ModoCaracteristicas string   // todas (por defecto) o alguna
+	PrecioMin           *float64 // Sobre el precio de referencia, igual que las bandas
+	PrecioMax           *float64
+	BandasPrecio        []string
+	CalificacionMin     *float64
+	IDCiudad            *uint
+	IDUsuarioFavorito   *uint      // Solo restaurantes marcados como favoritos por este usuario
+	AbiertoEn           *time.Time // Si no es nil, solo restaurantes abiertos en ese momento
+	ZonasHorarias       []string   // Zonas de las ciudades en que se evalua AbiertoEn; vacio usa la zona por defecto
+	Latitud             *float64
+	Longitud            *float64
+	RadioKm             float64          // Solo aplica con ubicacion; 0 no limita la distancia
+	Caja                *CajaCoordenadas // Solo restaurantes dentro del rectangulo
+
+	// PrecioPlatoFuerteMax exige al menos un plato fuerte disponible a ese precio o menos
+	PrecioPlatoFuerteMax *float64
+
+	// PlatillosConEtiqueta exige un minimo de platillos con cada etiqueta, como 3 platillos veganos
+	PlatillosConEtiqueta []MinimoPlatillosEtiqueta
+}
+
+// FacetaConteo es el numero de resultados para un valor de una faceta
+type FacetaConteo struct {
+	ID     uint   `gorm:"column:id" json:"id"`
+	Nombre string `gorm:"column:nombre" json:"nombre"`
+	Total  int64  `gorm:"column:total" json:"total"`
+}
+
+// FacetaBandaPrecio es el numero de resultados en una banda de precio
+type FacetaBandaPrecio struct {
+	BandaPrecio
+	Total int64 `json:"total"`
+}
+
+// FacetasBusqueda agrupa los conteos por faceta de una busqueda
+type FacetasBusqueda struct {
+	Categorias      []FacetaConteo      `json:"categorias"`
+	Caracteristicas []FacetaConteo      `json:"caracteristicas"`
+	BandasPrecio    []FacetaBandaPrecio `json:"bandasPrecio"`
+}
+
+// Facetas que pueden omitirse al aplicar filtros
+const (
+	facetaNinguna         = ""
+	facetaCategorias      = "categorias"
+	facetaCaracteristicas = "caracteristicas"
+	facetaPrecio          = "precio"
+)
+
+// BuscarRestaurantesFiltrados ejecuta la busqueda combinada en una sola consulta
+func (dm *DBManager) BuscarRestaurantesFiltrados(filtro FiltroBusquedaRestaurantes) ([]models.Restaurante, error) {
+	var restaurantes []models.Restaurante
+
+	query := dm.db.
+		Preload("Ciudad").
+		Preload("Categorias").
+		Preload("Caracteristicas").
+		Preload("Horarios").
+		Preload("Excepciones", excepcionesVigentes).
+		Preload("Precios").
+		Preload("Imagenes")
+
+	result := aplicarFiltroBusqueda(query, filtro, facetaNinguna).
+		Order("restaurantes.calificacionPromedio DESC, restaurantes.totalResenas DESC, restaurantes.idRestaurante ASC").
+		Find(&restaurantes)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return restaurantes, nil
+}
+
+// ContarFacetasBusqueda calcula los conteos por categoria, caracteristica y banda de precio
+// Las facetas de seleccion multiple (categorias, precio y caracteristicas en modo alguna) se cuentan
+// sin su propio filtro para que el frontend pueda mostrar cuantos resultados agregaria cada opcion
+func (dm *DBManager) ContarFacetasBusqueda(filtro FiltroBusquedaRestaurantes) (*FacetasBusqueda, error) {
+	facetas := &FacetasBusqueda{
+		Categorias:      []FacetaConteo{},
+		Caracteristicas: []FacetaConteo{},
+		BandasPrecio:    make([]FacetaBandaPrecio, 0, len(BandasPrecio)),
+	}
+
+	queryCategorias := dm.db.Table("restaurantes").
+		Select("cc.idCategoria AS id, cc.nombreCategoria AS nombre, COUNT(DISTINCT restaurantes.idRestaurante) AS total").
+		Joins("INNER JOIN restaurante_categorias rc ON rc.idRestaurante = restaurantes.idRestaurante").
+		Joins("INNER JOIN categorias_cocina cc ON cc.idCategoria = rc.idCategoria")
+	err := aplicarFiltroBusqueda(queryCategorias, filtro, facetaCategorias).
+		Group("cc.idCategoria, cc.nombreCategoria").
+		Order("total DESC, nombre ASC").
+		Scan(&facetas.Categorias).Error
+	if err != nil {
+		return nil, err
+	}
+
+	queryCaracteristicas := dm.db.Table("restaurantes").
+		Select("ca.idCaracteristica AS id, ca.nombreCaracteristica AS nombre, COUNT(DISTINCT restaurantes.idRestaurante) AS total").
+		Joins("INNER JOIN restaurante_caracteristicas rca ON rca.idRestaurante = restaurantes.idRestaurante").
+		Joins("INNER JOIN caracteristicas ca ON ca.idCaracteristica = rca.idCaracteristica")
+	omitirCaracteristicas := facetaNinguna
+	if filtro.ModoCaracteristicas == ModoCaracteristicasAlguna {
+		omitirCaracteristicas = facetaCaracteristicas
+	}
+	err = aplicarFiltroBusqueda(queryCaracteristicas, filtro, omitirCaracteristicas).
+		Group("ca.idCaracteristica, ca.nombreCaracteristica").
+		Order("total DESC, nombre ASC").
+		Scan(&facetas.Caracteristicas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var conteoBandas []struct {
+		Banda string `gorm:"column:banda"`
+		Total int64  `gorm:"column:total"`
+	}
+	queryBandas := dm.db.Table("restaurantes").
+		Select(expresionBandaPrecio() + " AS banda, COUNT(*) AS total").
+		Where(expresionPrecioReferencia + " IS NOT NULL")
+	err = aplicarFiltroBusqueda(queryBandas, filtro, facetaPrecio).
+		Group("banda").
+		Scan(&conteoBandas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	totales := make(map[string]int64, len(conteoBandas))
+	for _, conteo := range conteoBandas {
+		totales[conteo.Banda] = conteo.Total
+	}
+	for _, banda := range BandasPrecio {
+		facetas.BandasPrecio = append(facetas.BandasPrecio, FacetaBandaPrecio{
+			BandaPrecio: banda,
+			Total:       totales[banda.Clave],
+		})
+	}
+
+	return facetas, nil
+}
+
+// aplicarFiltroBusqueda agrega a la consulta las condiciones del filtro, opcionalmente sin una faceta
+// Las relaciones se filtran con EXISTS para no duplicar filas ni interferir con los JOIN de las facetas
+func aplicarFiltroBusqueda(query *gorm.DB, filtro FiltroBusquedaRestaurantes, omitir string) *gorm.DB {
+	query = query.Where("restaurantes.activo = ?", true)
+
+	if filtro.IDRestaurantes != nil {
+		if len(filtro.IDRestaurantes) == 0 {
+			query = query.Where("1 = 0")
+		} else {
+			query = query.Where("restaurantes.idRestaurante IN ?", filtro.IDRestaurantes)
+		}
+	}
+
+	if termino := strings.TrimSpace(filtro.Termino); termino != "" {
+		like := "%" + termino + "%"
+		query = query.Where(
+			"(restaurantes.nombre LIKE ? OR restaurantes.descripcion LIKE ? OR EXISTS ("+
+				"SELECT 1 FROM restaurante_categorias rct "+
+				"INNER JOIN categorias_cocina cct ON cct.idCategoria = rct.idCategoria "+
+				"WHERE rct.idRestaurante = restaurantes.idRestaurante AND cct.nombreCategoria LIKE ?))",
+			like, like, like,
+		)
+	}
+
+	if omitir != facetaCategorias {
+		if len(filtro.IDCategorias) > 0 {
+			query = query.Where(
+				"EXISTS (SELECT 1 FROM restaurante_categorias rcf "+
+					"WHERE rcf.idRestaurante = restaurantes.idRestaurante AND rcf.idCategoria IN ?)",
+				filtro.IDCategorias,
+			)
+		}
+		if nombre := strings.TrimSpace(filtro.NombreCategoria); nombre != "" {
+			query = query.Where(
+				"EXISTS (SELECT 1 FROM restaurante_categorias rcn "+
+					"INNER JOIN categorias_cocina ccn ON ccn.idCategoria = rcn.idCategoria "+
+					"WHERE rcn.idRestaurante = restaurantes.idRestaurante AND ccn.nombreCategoria = ?)",
+				nombre,
+			)
+		}
+	}
+
+	if ids := idsUnicos(filtro.IDCaracteristicas); len(ids) > 0 && omitir != facetaCaracteristicas {
+		if filtro.ModoCaracteristicas == ModoCaracteristicasAlguna {
+			query = query.Where(
+				"EXISTS (SELECT 1 FROM restaurante_caracteristicas rcaf "+
+					"WHERE rcaf.idRestaurante = restaurantes.idRestaurante AND rcaf.idCaracteristica IN ?)",
+				ids,
+			)
+		} else {
+			query = query.Where(
+				"(SELECT COUNT(DISTINCT rcaf.idCaracteristica) FROM restaurante_caracteristicas rcaf "+
+					"WHERE rcaf.idRestaurante = restaurantes.idRestaurante AND rcaf.idCaracteristica IN ?) = ?",
+				ids, len(ids),
+			)
+		}
+	}
+
+	if omitir != facetaPrecio {
+		if filtro.PrecioMin != nil {
+			query = query.Where(expresionPrecioReferencia+" >= ?", *filtro.PrecioMin)
+		}
+		if filtro.PrecioMax != nil {
+			query = query.Where(expresionPrecioReferencia+" <= ?", *filtro.PrecioMax)
+		}
+		if condicion, args := condicionBandasPrecio(filtro.BandasPrecio); condicion != "" {
+			query = query.Where(condicion, args...)
+		}
+	}
+
+	if filtro.PrecioPlatoFuerteMax != nil {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM precios_restaurante prf WHERE prf.idRestaurante = restaurantes.idRestaurante "+
+				"AND prf.precioMinimoFuerte <= ?)",
+			*filtro.PrecioPlatoFuerteMax,
+		)
+	}
+
+	if filtro.CalificacionMin != nil {
+		query = query.Where("restaurantes.calificacionPromedio >= ?", *filtro.CalificacionMin)
+	}
+
+	if filtro.IDCiudad != nil {
+		query = query.Where("restaurantes.idCiudad = ?", *filtro.IDCiudad)
+	}
+
+	if filtro.IDUsuarioFavorito != nil {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM favoritos ff WHERE ff.idRestaurante = restaurantes.idRestaurante AND ff.idUsuario = ?)",
+			*filtro.IDUsuarioFavorito,
+		)
+	}
+
+	if filtro.AbiertoEn != nil {
+		condicion, args := condicionAbiertoEn(*filtro.AbiertoEn, filtro.ZonasHorarias)
+		query = query.Where(condicion, args...)
+	}
+
+	if filtro.Latitud != nil && filtro.Longitud != nil && filtro.RadioKm > 0 {
+		lat, lng, radio := *filtro.Latitud, *filtro.Longitud, filtro.RadioKm
+
+		// La caja envolvente usa el indice espacial; la distancia exacta descarta las esquinas
+		minLat, minLng, maxLat, maxLng := algorithms.CajaEnvolvente(lat, lng, radio)
+		query = query.
+			Where("MBRContains(ST_MakeEnvelope(POINT(?, ?), POINT(?, ?)), restaurantes.ubicacion)",
+				minLng, minLat, maxLng, maxLat).
+			Where(expresionDistancia+" <= ?", lng, lat, radio)
+	}
+
+	for _, requisito := range filtro.PlatillosConEtiqueta {
+		query = query.Where(
+			"(SELECT COUNT(*) FROM platillos pet "+
+				"INNER JOIN platillo_etiquetas eet ON eet.idPlatillo = pet.idPlatillo "+
+				"WHERE pet.idRestaurante = restaurantes.idRestaurante AND pet.disponible = TRUE "+
+				"AND eet.etiqueta = ?) >= ?",
+			requisito.Etiqueta, requisito.Minimo,
+		)
+	}
+
+	if filtro.Caja != nil {
+		query = query.Where("MBRContains(ST_MakeEnvelope(POINT(?, ?), POINT(?, ?)), restaurantes.ubicacion)",
+			filtro.Caja.MinLng, filtro.Caja.MinLat, filtro.Caja.MaxLng, filtro.Caja.MaxLat)
+	}
+
+	return query
+}
+
+// condicionBandasPrecio arma la condicion OR de las bandas seleccionadas; ignora claves desconocidas
+func condicionBandasPrecio(claves []string) (string, []interface{}) {
+	var partes []string
+	var args []interface{}
+
+	for _, clave := range claves {
+		banda, ok := ObtenerBandaPrecio(clave)
+		if !ok {
+			continue
+		}
+		if banda.Max > 0 {
+			partes = append(partes, "("+expresionPrecioReferencia+" >= ? AND "+expresionPrecioReferencia+" < ?)")
+			args = append(args, banda.Min, banda.Max)
+		} else {
+			partes = append(partes, expresionPrecioReferencia+" >= ?")
+			args = append(args, banda.Min)
+		}
+	}
+
+	if len(partes) == 0 {
+		return "", nil
+	}
+
+	return "(" + strings.Join(partes, " OR ") + ")", args
+}
+
+// expresionBandaPrecio genera el CASE que asigna cada restaurante a su banda de precio
+func expresionBandaPrecio() string {
+	var sb strings.Builder
+	sb.WriteString("CASE")
+	for _, banda := range BandasPrecio {
+		if banda.Max > 0 {
+			fmt.Fprintf(&sb, " WHEN %s < %g THEN '%s'", expresionPrecioReferencia, banda.Max, banda.Clave)
+		} else {
+			fmt.Fprintf(&sb, " ELSE '%s'", banda.Clave)
+		}
+	}
+	sb.WriteString(" END")
+	return sb.String()
+}
+
+// idsUnicos elimina IDs repetidos conservando el orden
+func idsUnicos(ids []uint) []uint {
+	vistos := make(map[uint]bool, len(ids))
+	unicos := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !vistos[id] {
+			vistos[id] = true
+			unicos = append(unicos, id)
+		}
+	}
+	return unicos
+}
+
+// ObtenerRestaurantesParaIndice carga los datos que alimentan el indice de busqueda
+// Con ids nil carga todos los restaurantes activos; con ids solo esos, incluidos los inactivos
+func (dm *DBManager) ObtenerRestaurantesParaIndice(ids []uint) ([]models.Restaurante, error) {
+	var restaurantes []models.Restaurante
+
+	query := dm.db.
+		Select("idRestaurante", "nombre", "descripcion", "activo").
+		Preload("Categorias").
+		Preload("Platillos", func(db *gorm.DB) *gorm.DB {
+			return db.Select("idPlatillo", "idRestaurante", "nombre", "descripcion", "ingredientes").
+				Where("disponible = ?", true)
+		})
+
+	if ids == nil {
+		query = query.Where("activo = ?", true)
+	} else {
+		if len(ids) == 0 {
+			return []models.Restaurante{}, nil
+		}
+		query = query.Where("idRestaurante IN ?", ids)
+	}
+
+	if err := query.Find(&restaurantes).Error; err != nil {
+		return nil, err
+	}
+
+	return restaurantes, nil
+}
+
+// ObtenerRestaurantesModificadosDesde retorna los IDs de restaurantes cuyo registro o platillos cambiaron
+func (dm *DBManager) ObtenerRestaurantesModificadosDesde(desde time.Time) ([]uint, error) {
+	var ids []uint
+
+	err := dm.db.Raw(
+		"SELECT idRestaurante FROM restaurantes WHERE fechaActualizacion > ? "+
+			"UNION SELECT idRestaurante FROM platillos WHERE fechaActualizacion > ?",
+		desde, desde,
+	).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// ObtenerPlatillosPorIDs carga los platillos disponibles indicados que cumplen el filtro
+func (dm *DBManager) ObtenerPlatillosPorIDs(ids []uint, filtro FiltroPlatillos) ([]models.Platillo, error) {
+	var platillos []models.Platillo
+
+	if len(ids) == 0 {
+		return platillos, nil
+	}
+
+	query := dm.db.Preload("Etiquetas").Where("idPlatillo IN ? AND disponible = ?", ids, true)
+
+	if err := aplicarFiltroPlatillos(query, filtro).Find(&platillos).Error; err != nil {
+		return nil, err
+	}
+
+	return platillos, nil
+}
+
+// BuscarPlatillosPorTexto busca platillos disponibles por nombre, descripcion o ingredientes con LIKE
+// Se usa solo mientras el indice de busqueda no esta disponible
+func (dm *DBManager) BuscarPlatillosPorTexto(termino string, filtro FiltroPlatillos, limite int) ([]models.Platillo, error) {
+	var platillos []models.Platillo
+	like := "%" + strings.TrimSpace(termino) + "%"
+
+	query := dm.db.
+		Preload("Etiquetas").
+		Where("disponible = ? AND (nombre LIKE ? OR descripcion LIKE ? OR ingredientes LIKE ?)", true, like, like, like)
+
+	err := aplicarFiltroPlatillos(query, filtro).
+		Order("destacado DESC, precio ASC").
+		Limit(limite).
+		Find(&platillos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return platillos, nil
+}
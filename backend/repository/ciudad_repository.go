@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"math"
+
+	"github.com/tuusuario/quovi/models"
+)
+
+// EstadisticasCiudad resume los restaurantes activos de una ciudad
+// Los promedios son nil si ningun restaurante tiene el dato
+type EstadisticasCiudad struct {
+	TotalRestaurantes    int64    `gorm:"column:totalRestaurantes"`
+	PrecioPromedio       *float64 `gorm:"column:precioPromedio"`
+	CalificacionPromedio *float64 `gorm:"column:calificacionPromedio"`
+	LatitudPromedio      *float64 `gorm:"column:latitudPromedio"`
+	LongitudPromedio     *float64 `gorm:"column:longitudPromedio"`
+}
+
+// ObtenerEstadisticasCiudad calcula conteo y promedios de los restaurantes activos de la ciudad
+// El precio es el de referencia del menu; precio y calificacion en 0 se consideran sin dato y no bajan el promedio
+func (dm *DBManager) ObtenerEstadisticasCiudad(idCiudad uint) (*EstadisticasCiudad, error) {
+	var estadisticas EstadisticasCiudad
+
+	filtro := FiltroBusquedaRestaurantes{IDCiudad: &idCiudad}
+	err := aplicarFiltroBusqueda(dm.db.Model(&models.Restaurante{}), filtro, facetaNinguna).
+		Select("COUNT(*) AS totalRestaurantes, " +
+			"AVG(" + expresionPrecioReferencia + ") AS precioPromedio, " +
+			"AVG(NULLIF(restaurantes.calificacionPromedio, 0)) AS calificacionPromedio, " +
+			"AVG(restaurantes.latitud) AS latitudPromedio, AVG(restaurantes.longitud) AS longitudPromedio").
+		Scan(&estadisticas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, valor := range []*float64{estadisticas.PrecioPromedio, estadisticas.CalificacionPromedio} {
+		if valor != nil {
+			*valor = math.Round(*valor*100) / 100
+		}
+	}
+
+	return &estadisticas, nil
+}
@@ -38,6 +38,9 @@ func (dm *DBManager) VerificarTablas() error {
 		"usuarios", "sesiones", "busquedas", "restaurantes",
 		"resenas", "favoritos", "ciudades", "categorias_cocina",
 		"caracteristicas", "platillos", "horarios", "imagenes_restaurante",
+		"visitas", "reportes_resena", "votos_resena", "imagenes_resena",
+		"aprobaciones_imagen_resena", "respuestas_resena", "propietarios_restaurante",
+		"notificaciones", "platillo_etiquetas", "precios_restaurante", "excepciones_horario",
 	}
 
 	for _, tabla := range tablas {
@@ -153,6 +156,8 @@ func (dm *DBManager) ObtenerRestaurantesPorIDs(ids []uint) ([]models.Restaurante
 		Preload("Ciudad").
 		Preload("Categorias").
 		Preload("Caracteristicas").
+		Preload("Horarios").
+		Preload("Excepciones", excepcionesVigentes).
 		Find(&restaurantes)
 
 	if result.Error != nil {
@@ -161,3 +166,9 @@ func (dm *DBManager) ObtenerRestaurantesPorIDs(ids []uint) ([]models.Restaurante
 
 	return restaurantes, nil
 }
+
+// esClaveDuplicada indica si el error de MySQL se debe a un indice unico, como el de una fila que
+// otra peticion inserto entre la verificacion y la escritura
+func esClaveDuplicada(err error) bool {
+	return errors.Is(mysql.Dialector{}.Translate(err), gorm.ErrDuplicatedKey)
+}
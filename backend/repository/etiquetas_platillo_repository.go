@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FiltroPlatillos restringe los platillos por precio y etiquetas de dieta o alergenos
+type FiltroPlatillos struct {
+	PrecioMax        *float64
+	Etiquetas        []string // El platillo debe tener todas
+	ExcluirEtiquetas []string // El platillo no debe tener ninguna, por ejemplo contiene_cacahuate
+}
+
+// MinimoPlatillosEtiqueta exige que el restaurante tenga al menos Minimo platillos disponibles con la etiqueta
+type MinimoPlatillosEtiqueta struct {
+	Etiqueta string
+	Minimo   int
+}
+
+// aplicarFiltroPlatillos agrega las condiciones del filtro a una consulta sobre platillos
+func aplicarFiltroPlatillos(query *gorm.DB, filtro FiltroPlatillos) *gorm.DB {
+	if filtro.PrecioMax != nil {
+		query = query.Where("platillos.precio <= ?", *filtro.PrecioMax)
+	}
+
+	if etiquetas := textosUnicos(filtro.Etiquetas); len(etiquetas) > 0 {
+		query = query.Where(
+			"(SELECT COUNT(*) FROM platillo_etiquetas pe "+
+				"WHERE pe.idPlatillo = platillos.idPlatillo AND pe.etiqueta IN ?) = ?",
+			etiquetas, len(etiquetas),
+		)
+	}
+
+	if excluir := textosUnicos(filtro.ExcluirEtiquetas); len(excluir) > 0 {
+		query = query.Where(
+			"NOT EXISTS (SELECT 1 FROM platillo_etiquetas px "+
+				"WHERE px.idPlatillo = platillos.idPlatillo AND px.etiqueta IN ?)",
+			excluir,
+		)
+	}
+
+	return query
+}
+
+func textosUnicos(textos []string) []string {
+	vistos := make(map[string]bool, len(textos))
+	unicos := make([]string, 0, len(textos))
+	for _, texto := range textos {
+		if !vistos[texto] {
+			vistos[texto] = true
+			unicos = append(unicos, texto)
+		}
+	}
+	return unicos
+}
+
+// ObtenerPlatilloPorID busca un platillo con sus etiquetas
+func (dm *DBManager) ObtenerPlatilloPorID(id uint) (*models.Platillo, error) {
+	var platillo models.Platillo
+
+	result := dm.db.Preload("Etiquetas").First(&platillo, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("platillo no encontrado")
+		}
+		return nil, result.Error
+	}
+
+	return &platillo, nil
+}
+
+// ObtenerPlatillosParaEtiquetar carga los platillos con sus etiquetas actuales para volver a inferir sus alergenos
+// Con desde nil carga todos; si no, solo los modificados despues de esa fecha
+func (dm *DBManager) ObtenerPlatillosParaEtiquetar(desde *time.Time) ([]models.Platillo, error) {
+	var platillos []models.Platillo
+
+	query := dm.db.
+		Select("idPlatillo", "nombre", "descripcion", "ingredientes").
+		Preload("Etiquetas")
+	if desde != nil {
+		query = query.Where("fechaActualizacion > ?", *desde)
+	}
+
+	if err := query.Find(&platillos).Error; err != nil {
+		return nil, err
+	}
+
+	return platillos, nil
+}
+
+// ReemplazarEtiquetasInferidas sustituye las etiquetas inferidas de un platillo y conserva las manuales
+// Una etiqueta que el propietario ya asigno sigue siendo manual. No modifica platillos eliminados
+func (dm *DBManager) ReemplazarEtiquetasInferidas(idPlatillo uint, etiquetas []string) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		var platillo models.Platillo
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("idPlatillo").
+			First(&platillo, idPlatillo).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("idPlatillo = ? AND inferida = ?", idPlatillo, true).
+			Delete(&models.PlatilloEtiqueta{}).Error; err != nil {
+			return err
+		}
+
+		return agregarEtiquetas(tx, idPlatillo, etiquetas, true)
+	})
+}
+
+// AsignarEtiquetasManuales reemplaza las etiquetas manuales del platillo y guarda de nuevo las inferidas,
+// de modo que el propietario agrega etiquetas pero no puede quitar los alergenos inferidos
+func (dm *DBManager) AsignarEtiquetasManuales(idPlatillo uint, manuales, inferidas []string) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Platillo{}).
+			Where("idPlatillo = ?", idPlatillo).
+			Update("etiquetasManuales", len(manuales) > 0).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("idPlatillo = ?", idPlatillo).Delete(&models.PlatilloEtiqueta{}).Error; err != nil {
+			return err
+		}
+
+		if err := agregarEtiquetas(tx, idPlatillo, manuales, false); err != nil {
+			return err
+		}
+		return agregarEtiquetas(tx, idPlatillo, inferidas, true)
+	})
+}
+
+// RestablecerEtiquetasInferidas descarta las etiquetas manuales y guarda las inferidas
+func (dm *DBManager) RestablecerEtiquetasInferidas(idPlatillo uint, etiquetas []string) error {
+	return dm.AsignarEtiquetasManuales(idPlatillo, nil, etiquetas)
+}
+
+// agregarEtiquetas inserta las etiquetas que el platillo aun no tiene dentro de la transaccion
+func agregarEtiquetas(tx *gorm.DB, idPlatillo uint, etiquetas []string, inferidas bool) error {
+	etiquetas = textosUnicos(etiquetas)
+	if len(etiquetas) == 0 {
+		return nil
+	}
+
+	registros := make([]models.PlatilloEtiqueta, 0, len(etiquetas))
+	for _, etiqueta := range etiquetas {
+		registros = append(registros, models.PlatilloEtiqueta{
+			IDPlatillo: idPlatillo,
+			Etiqueta:   etiqueta,
+			Inferida:   inferidas,
+		})
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&registros).Error
+}
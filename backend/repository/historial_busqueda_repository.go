@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm/clause"
+)
+
+// BusquedaPopular resume cuantas veces se busco un texto en un periodo
+type BusquedaPopular struct {
+	Texto       string    `gorm:"column:texto" json:"texto"`
+	Total       int64     `gorm:"column:total" json:"total"`
+	UltimaFecha time.Time `gorm:"column:ultimaFecha" json:"ultimaFecha"`
+}
+
+// CrearBusqueda registra una busqueda en el historial
+func (dm *DBManager) CrearBusqueda(busqueda *models.Busqueda) error {
+	return dm.db.Omit(clause.Associations).Create(busqueda).Error
+}
+
+// ObtenerBusquedasRecientes lista las ultimas busquedas de un usuario sin repetir texto
+// De cada texto se retorna el registro mas reciente
+func (dm *DBManager) ObtenerBusquedasRecientes(idUsuario uint, limite int) ([]models.Busqueda, error) {
+	var busquedas []models.Busqueda
+
+	ultimas := dm.db.Model(&models.Busqueda{}).
+		Select("MAX(idBusqueda)").
+		Where("idUsuario = ? AND texto <> ''", idUsuario).
+		Group("texto")
+
+	result := dm.db.
+		Where("idBusqueda IN (?)", ultimas).
+		Order("fecha DESC, idBusqueda DESC").
+		Limit(limite).
+		Find(&busquedas)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return busquedas, nil
+}
+
+// EliminarBusqueda borra del historial del usuario todas sus busquedas con el mismo texto que la indicada
+// Los registros se eliminan, de modo que dejan de contar en las busquedas populares y en las sugerencias
+func (dm *DBManager) EliminarBusqueda(idUsuario, idBusqueda uint) (int64, error) {
+	var busqueda models.Busqueda
+
+	result := dm.db.
+		Where("idBusqueda = ? AND idUsuario = ?", idBusqueda, idUsuario).
+		Limit(1).
+		Find(&busqueda)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, errors.New("busqueda no encontrada")
+	}
+
+	result = dm.db.
+		Where("idUsuario = ? AND texto = ?", idUsuario, busqueda.Texto).
+		Delete(&models.Busqueda{})
+
+	return result.RowsAffected, result.Error
+}
+
+// EliminarHistorialBusquedas borra todas las busquedas del usuario
+func (dm *DBManager) EliminarHistorialBusquedas(idUsuario uint) (int64, error) {
+	result := dm.db.
+		Where("idUsuario = ?", idUsuario).
+		Delete(&models.Busqueda{})
+
+	return result.RowsAffected, result.Error
+}
+
+// ObtenerBusquedasPopulares agrupa los textos mas buscados desde una fecha, opcionalmente en una ciudad
+// Solo cuentan las busquedas que encontraron resultados. Los textos se agrupan en minusculas; la
+// intercalacion de la columna ya ignora acentos. Con minUsuarios > 0 solo se incluyen los textos que
+// buscaron al menos esa cantidad de usuarios distintos; las busquedas anonimas no cuentan como usuario
+func (dm *DBManager) ObtenerBusquedasPopulares(desde time.Time, idCiudad *uint, minUsuarios, limite int) ([]BusquedaPopular, error) {
+	var populares []BusquedaPopular
+
+	query := dm.db.Model(&models.Busqueda{}).
+		Select("LOWER(texto) AS texto, COUNT(*) AS total, MAX(fecha) AS ultimaFecha").
+		Where("fecha >= ? AND texto <> '' AND resultadosEncontrados > 0", desde)
+
+	if idCiudad != nil {
+		query = query.Where("idCiudad = ?", *idCiudad)
+	}
+
+	query = query.Group("LOWER(texto)")
+	if minUsuarios > 0 {
+		query = query.Having("COUNT(DISTINCT idUsuario) >= ?", minUsuarios)
+	}
+
+	result := query.
+		Order("total DESC, ultimaFecha DESC").
+		Limit(limite).
+		Scan(&populares)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return populares, nil
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/utils"
+	"gorm.io/gorm"
+)
+
+// FormatoFecha es el formato de las fechas de excepciones de horario
+const FormatoFecha = "2006-01-02"
+
+// ObtenerZonasHorarias lista las zonas horarias distintas de las ciudades registradas
+func (dm *DBManager) ObtenerZonasHorarias() ([]string, error) {
+	var zonas []string
+
+	err := dm.db.Table("ciudades").Distinct("zonaHoraria").Pluck("zonaHoraria", &zonas).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return zonas, nil
+}
+
+// ObtenerExcepcionesHorario lista las excepciones de un restaurante ordenadas por fecha
+// Si soloVigentes es true omite las que ya terminaron, salvo las que se repiten cada año
+func (dm *DBManager) ObtenerExcepcionesHorario(idRestaurante uint, soloVigentes bool) ([]models.ExcepcionHorario, error) {
+	var excepciones []models.ExcepcionHorario
+
+	query := dm.db.Where("idRestaurante = ?", idRestaurante)
+	if soloVigentes {
+		query = excepcionesVigentes(query)
+	}
+
+	if err := query.Order("fechaInicio ASC, apertura ASC").Find(&excepciones).Error; err != nil {
+		return nil, err
+	}
+
+	return excepciones, nil
+}
+
+// ObtenerExcepcionHorario busca una excepcion del restaurante indicado
+func (dm *DBManager) ObtenerExcepcionHorario(idRestaurante, idExcepcion uint) (*models.ExcepcionHorario, error) {
+	var excepcion models.ExcepcionHorario
+
+	err := dm.db.Where("idExcepcion = ? AND idRestaurante = ?", idExcepcion, idRestaurante).First(&excepcion).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("excepcion de horario no encontrada")
+		}
+		return nil, err
+	}
+
+	return &excepcion, nil
+}
+
+// CrearExcepcionHorario registra una nueva excepcion
+func (dm *DBManager) CrearExcepcionHorario(excepcion *models.ExcepcionHorario) error {
+	return dm.db.Create(excepcion).Error
+}
+
+// ActualizarExcepcionHorario guarda todos los campos de la excepcion, incluidos los vacios
+func (dm *DBManager) ActualizarExcepcionHorario(excepcion *models.ExcepcionHorario) error {
+	return dm.db.Save(excepcion).Error
+}
+
+// EliminarExcepcionHorario borra una excepcion del restaurante indicado
+func (dm *DBManager) EliminarExcepcionHorario(idRestaurante, idExcepcion uint) error {
+	result := dm.db.Where("idExcepcion = ? AND idRestaurante = ?", idExcepcion, idRestaurante).
+		Delete(&models.ExcepcionHorario{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("excepcion de horario no encontrada")
+	}
+	return nil
+}
+
+// excepcionesVigentes deja las excepciones que pueden aplicar de ayer en adelante; sirve tambien para Preload
+// Se toma un margen de dos dias porque la fecha local depende de la zona de cada ciudad
+func excepcionesVigentes(query *gorm.DB) *gorm.DB {
+	desde := time.Now().AddDate(0, 0, -2).Format(FormatoFecha)
+	return query.Where("recurrenteAnual = ? OR fechaFin >= ?", true, desde)
+}
+
+// DiaSemana convierte el dia de Go a la numeracion de horarios, de lunes 1 a domingo 7
+func DiaSemana(momento time.Time) int {
+	dia := int(momento.Weekday())
+	if dia == 0 {
+		dia = 7 // Domingo como 7
+	}
+	return dia
+}
+
+// DiaAnterior retorna el dia previo en la numeracion de horarios
+func DiaAnterior(dia int) int {
+	if dia == 1 {
+		return 7
+	}
+	return dia - 1
+}
+
+// MesDia resume una fecha como mes*100+dia para comparar excepciones que se repiten cada año
+func MesDia(fecha time.Time) int {
+	return int(fecha.Month())*100 + fecha.Day()
+}
+
+// condicionAbiertoEn arma la condicion de restaurantes abiertos en el momento dado
+// El momento se traduce al dia y hora local de cada zona, ya que cada ciudad puede tener la suya;
+// un intervalo que cruza la medianoche cuenta para el dia en que abre y para la madrugada siguiente
+func condicionAbiertoEn(momento time.Time, zonas []string) (string, []interface{}) {
+	if len(zonas) == 0 {
+		zonas = []string{utils.ZonaHorariaPorDefecto}
+	}
+
+	partes := make([]string, 0, len(zonas))
+	var args []interface{}
+	for _, zona := range zonas {
+		local := momento.In(utils.CargarZonaHoraria(zona))
+		hora := local.Format("15:04:05")
+
+		// Intervalos que abren hoy y siguen abiertos a esta hora
+		hoy := func(alias string) (string, []interface{}) {
+			return fmt.Sprintf("%[1]s.apertura <= ? AND (%[1]s.cierre > ? OR %[1]s.cierre <= %[1]s.apertura)", alias),
+				[]interface{}{hora, hora}
+		}
+		// Intervalos que abrieron ayer, cruzan la medianoche y no han cerrado
+		madrugada := func(alias string) (string, []interface{}) {
+			return fmt.Sprintf("%[1]s.cierre <= %[1]s.apertura AND ? < %[1]s.cierre", alias),
+				[]interface{}{hora}
+		}
+
+		condicionHoy, argsHoy := condicionIntervaloEnFecha(local, hoy)
+		condicionAyer, argsAyer := condicionIntervaloEnFecha(local.AddDate(0, 0, -1), madrugada)
+
+		partes = append(partes, "((SELECT czh.zonaHoraria FROM ciudades czh WHERE czh.idCiudad = restaurantes.idCiudad) = ? "+
+			"AND ("+condicionHoy+" OR "+condicionAyer+"))")
+		args = append(args, zona)
+		args = append(args, argsHoy...)
+		args = append(args, argsAyer...)
+	}
+
+	return "(" + strings.Join(partes, " OR ") + ")", args
+}
+
+// condicionIntervaloEnFecha exige un intervalo que abra en la fecha y cumpla la condicion dada
+// Si el restaurante tiene excepciones ese dia reemplazan al horario semanal: una de cierre lo cierra
+// todo el dia y, si no, solo cuentan los horarios especiales
+func condicionIntervaloEnFecha(fecha time.Time, intervalo func(alias string) (string, []interface{})) (string, []interface{}) {
+	var args []interface{}
+	agregar := func(condicion string, nuevos []interface{}) string {
+		args = append(args, nuevos...)
+		return condicion
+	}
+
+	var sb strings.Builder
+	sb.WriteString("(CASE WHEN EXISTS (SELECT 1 FROM excepciones_horario ea WHERE ea.idRestaurante = restaurantes.idRestaurante AND ")
+	sb.WriteString(agregar(condicionExcepcionEnFecha("ea", fecha)))
+	sb.WriteString(") THEN NOT EXISTS (SELECT 1 FROM excepciones_horario eb WHERE eb.idRestaurante = restaurantes.idRestaurante AND eb.cerrado = TRUE AND ")
+	sb.WriteString(agregar(condicionExcepcionEnFecha("eb", fecha)))
+	sb.WriteString(") AND EXISTS (SELECT 1 FROM excepciones_horario ec WHERE ec.idRestaurante = restaurantes.idRestaurante AND ec.cerrado = FALSE AND ")
+	sb.WriteString(agregar(condicionExcepcionEnFecha("ec", fecha)))
+	sb.WriteString(" AND ")
+	sb.WriteString(agregar(intervalo("ec")))
+	sb.WriteString(") ELSE EXISTS (SELECT 1 FROM horarios hf WHERE hf.idRestaurante = restaurantes.idRestaurante AND hf.cerrado = FALSE AND hf.dia = ? AND ")
+	args = append(args, DiaSemana(fecha))
+	sb.WriteString(agregar(intervalo("hf")))
+	sb.WriteString(") END)")
+
+	return sb.String(), args
+}
+
+// condicionExcepcionEnFecha indica si la excepcion con el alias dado aplica en la fecha
+// Las recurrentes comparan solo mes y dia, y su rango puede cruzar el fin de año
+func condicionExcepcionEnFecha(alias string, fecha time.Time) (string, []interface{}) {
+	inicio := fmt.Sprintf("(MONTH(%[1]s.fechaInicio) * 100 + DAY(%[1]s.fechaInicio))", alias)
+	fin := fmt.Sprintf("(MONTH(%[1]s.fechaFin) * 100 + DAY(%[1]s.fechaFin))", alias)
+	mesDia := MesDia(fecha)
+
+	condicion := fmt.Sprintf("((%[1]s.recurrenteAnual = FALSE AND ? BETWEEN %[1]s.fechaInicio AND %[1]s.fechaFin) "+
+		"OR (%[1]s.recurrenteAnual = TRUE AND IF(%[2]s <= %[3]s, ? BETWEEN %[2]s AND %[3]s, ? >= %[2]s OR ? <= %[3]s)))",
+		alias, inicio, fin)
+
+	return condicion, []interface{}{fecha.Format(FormatoFecha), mesDia, mesDia, mesDia}
+}
@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ContarImagenesReseña retorna cuantas fotos tiene adjuntas una reseña
+func (dm *DBManager) ContarImagenesReseña(idReseña uint) (int64, error) {
+	var count int64
+
+	result := dm.db.Model(&models.ImagenReseña{}).
+		Where("idResena = ?", idReseña).
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+// CrearImagenesReseña inserta las fotos de una reseña en una sola transaccion sin superar maximo fotos
+// La reseña se bloquea mientras se cuentan sus fotos para que dos subidas simultaneas no rebasen el limite
+func (dm *DBManager) CrearImagenesReseña(idReseña uint, imagenes []models.ImagenReseña, maximo int) error {
+	if len(imagenes) == 0 {
+		return nil
+	}
+
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		var reseña models.Reseña
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("idResena").
+			First(&reseña, idReseña).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("reseña no encontrada")
+			}
+			return err
+		}
+
+		var existentes int64
+		if err := tx.Model(&models.ImagenReseña{}).
+			Where("idResena = ?", idReseña).
+			Count(&existentes).Error; err != nil {
+			return err
+		}
+
+		if int(existentes)+len(imagenes) > maximo {
+			return fmt.Errorf("una reseña admite como maximo %d fotos", maximo)
+		}
+
+		return tx.Omit(clause.Associations).Create(&imagenes).Error
+	})
+}
+
+// ObtenerImagenesReseña lista las fotos de una reseña en orden de subida
+func (dm *DBManager) ObtenerImagenesReseña(idReseña uint) ([]models.ImagenReseña, error) {
+	var imagenes []models.ImagenReseña
+
+	result := dm.db.
+		Where("idResena = ?", idReseña).
+		Order("fechaSubida ASC, idImagen ASC").
+		Find(&imagenes)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return imagenes, nil
+}
+
+// ObtenerImagenReseña busca una foto de una reseña concreta
+func (dm *DBManager) ObtenerImagenReseña(idReseña, idImagen uint) (*models.ImagenReseña, error) {
+	var imagen models.ImagenReseña
+
+	result := dm.db.
+		Where("idImagen = ? AND idResena = ?", idImagen, idReseña).
+		First(&imagen)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("imagen no encontrada")
+		}
+		return nil, result.Error
+	}
+
+	return &imagen, nil
+}
+
+// EliminarImagenReseña borra el registro de una foto de reseña
+func (dm *DBManager) EliminarImagenReseña(idImagen uint) error {
+	result := dm.db.Delete(&models.ImagenReseña{}, idImagen)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("imagen no encontrada")
+	}
+	return nil
+}
+
+// AprobarImagenReseña registra la aprobacion de un usuario y recalcula el contador de la foto
+func (dm *DBManager) AprobarImagenReseña(idImagen, idUsuario uint) (*models.ImagenReseña, error) {
+	var imagen models.ImagenReseña
+
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&imagen, idImagen).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("imagen no encontrada")
+			}
+			return err
+		}
+
+		aprobacion := models.AprobacionImagenReseña{
+			IDImagen:  idImagen,
+			IDUsuario: idUsuario,
+			Fecha:     time.Now(),
+		}
+
+		err := tx.Omit(clause.Associations).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&aprobacion).Error
+		if err != nil {
+			return err
+		}
+
+		var total int64
+		if err := tx.Model(&models.AprobacionImagenReseña{}).
+			Where("idImagen = ?", idImagen).
+			Count(&total).Error; err != nil {
+			return err
+		}
+
+		imagen.Aprobaciones = int(total)
+		return tx.Model(&models.ImagenReseña{}).
+			Where("idImagen = ?", idImagen).
+			Update("aprobaciones", imagen.Aprobaciones).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &imagen, nil
+}
+
+// PromoverImagenReseña copia una foto de reseña a la galeria del restaurante con atribucion al autor
+// La foto queda marcada como promovida para no duplicarla
+func (dm *DBManager) PromoverImagenReseña(idImagen uint) (*models.ImagenRestaurante, error) {
+	var galeria models.ImagenRestaurante
+
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		var imagen models.ImagenReseña
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Reseña", func(db *gorm.DB) *gorm.DB {
+				return db.Select("idResena", "idRestaurante")
+			}).
+			Preload("Usuario", func(db *gorm.DB) *gorm.DB {
+				return db.Select("idUsuario", "nombreUsuario", "nombre")
+			}).
+			First(&imagen, idImagen).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("imagen no encontrada")
+			}
+			return err
+		}
+
+		if imagen.Promovida {
+			return errors.New("la imagen ya forma parte de la galeria")
+		}
+
+		var ultimoOrden int
+		if err := tx.Model(&models.ImagenRestaurante{}).
+			Select("COALESCE(MAX(orden), 0)").
+			Where("idRestaurante = ?", imagen.Reseña.IDRestaurante).
+			Scan(&ultimoOrden).Error; err != nil {
+			return err
+		}
+
+		galeria = models.ImagenRestaurante{
+			IDRestaurante:  imagen.Reseña.IDRestaurante,
+			URL:            imagen.URL,
+			Orden:          ultimoOrden + 1,
+			FechaSubida:    time.Now(),
+			IDUsuarioAutor: &imagen.IDUsuario,
+			Credito:        creditoImagen(imagen.Usuario),
+			IDImagenReseña: &imagen.IDImagen,
+		}
+
+		if err := tx.Create(&galeria).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.ImagenReseña{}).
+			Where("idImagen = ?", idImagen).
+			Update("promovida", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &galeria, nil
+}
+
+// creditoImagen arma el texto de atribucion de una foto promovida
+func creditoImagen(autor models.Usuario) string {
+	if autor.NombreUsuario != "" {
+		return fmt.Sprintf("Foto de @%s", autor.NombreUsuario)
+	}
+	if autor.Nombre != "" {
+		return fmt.Sprintf("Foto de %s", autor.Nombre)
+	}
+	return "Foto de la comunidad"
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/tuusuario/quovi/models"
+)
+
+// CajaCoordenadas es un rectangulo de latitud y longitud, como el area visible de un mapa
+type CajaCoordenadas struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// PuntoMapa es la informacion minima de un restaurante para pintarlo en el mapa
+type PuntoMapa struct {
+	IDRestaurante        uint    `gorm:"column:idRestaurante" json:"idRestaurante"`
+	Nombre               string  `gorm:"column:nombre" json:"nombre"`
+	Latitud              float64 `gorm:"column:latitud" json:"latitud"`
+	Longitud             float64 `gorm:"column:longitud" json:"longitud"`
+	CalificacionPromedio float64 `gorm:"column:calificacionPromedio" json:"calificacionPromedio"`
+	PrecioPromedio       float64 `gorm:"column:precioPromedio" json:"precioPromedio,omitempty"`
+	ImagenPrincipal      string  `gorm:"column:imagenPrincipal" json:"imagenPrincipal,omitempty"`
+}
+
+// GrupoMapa resume los restaurantes de una celda de la cuadricula
+// Si el grupo tiene un solo restaurante, IDRestaurante lo identifica
+type GrupoMapa struct {
+	Total                int     `gorm:"column:total" json:"total"`
+	Latitud              float64 `gorm:"column:latitud" json:"latitud"`   // Centroide
+	Longitud             float64 `gorm:"column:longitud" json:"longitud"` // Centroide
+	CalificacionPromedio float64 `gorm:"column:calificacionPromedio" json:"calificacionPromedio"`
+	MinLat               float64 `gorm:"column:minLat" json:"minLat"`
+	MinLng               float64 `gorm:"column:minLng" json:"minLng"`
+	MaxLat               float64 `gorm:"column:maxLat" json:"maxLat"`
+	MaxLng               float64 `gorm:"column:maxLng" json:"maxLng"`
+	IDRestaurante        *uint   `gorm:"column:idRestaurante" json:"idRestaurante,omitempty"`
+}
+
+// ObtenerPuntosMapa retorna hasta limite restaurantes del filtro, mejor calificados primero
+func (dm *DBManager) ObtenerPuntosMapa(filtro FiltroBusquedaRestaurantes, limite int) ([]PuntoMapa, error) {
+	var puntos []PuntoMapa
+
+	err := aplicarFiltroBusqueda(dm.db.Model(&models.Restaurante{}), filtro, "").
+		Select("restaurantes.idRestaurante, restaurantes.nombre, restaurantes.latitud, restaurantes.longitud, " +
+			"restaurantes.calificacionPromedio, restaurantes.precioPromedio, " +
+			"(SELECT im.url FROM imagenes_restaurante im WHERE im.idRestaurante = restaurantes.idRestaurante " +
+			"ORDER BY im.esPrincipal DESC, im.orden ASC, im.idImagen ASC LIMIT 1) AS imagenPrincipal").
+		Order("restaurantes.calificacionPromedio DESC, restaurantes.idRestaurante ASC").
+		Limit(limite).
+		Scan(&puntos).Error
+
+	return puntos, err
+}
+
+// AgruparRestaurantesMapa agrupa los restaurantes del filtro en una cuadricula de celdas de los grados dados
+// La cuadricula parte del origen de coordenadas para que las celdas no cambien al desplazar el mapa
+func (dm *DBManager) AgruparRestaurantesMapa(filtro FiltroBusquedaRestaurantes, celdaLat, celdaLng float64) ([]GrupoMapa, error) {
+	var grupos []GrupoMapa
+
+	err := aplicarFiltroBusqueda(dm.db.Model(&models.Restaurante{}), filtro, "").
+		Select("COUNT(*) AS total, " +
+			"AVG(restaurantes.latitud) AS latitud, AVG(restaurantes.longitud) AS longitud, " +
+			"COALESCE(AVG(NULLIF(restaurantes.calificacionPromedio, 0)), 0) AS calificacionPromedio, " +
+			"MIN(restaurantes.latitud) AS minLat, MIN(restaurantes.longitud) AS minLng, " +
+			"MAX(restaurantes.latitud) AS maxLat, MAX(restaurantes.longitud) AS maxLng, " +
+			"IF(COUNT(*) = 1, MIN(restaurantes.idRestaurante), NULL) AS idRestaurante").
+		Group("FLOOR(restaurantes.latitud / " + formatearGrados(celdaLat) + "), " +
+			"FLOOR(restaurantes.longitud / " + formatearGrados(celdaLng) + ")").
+		Order("total DESC").
+		Scan(&grupos).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range grupos {
+		grupos[i].Latitud = math.Round(grupos[i].Latitud*1e6) / 1e6
+		grupos[i].Longitud = math.Round(grupos[i].Longitud*1e6) / 1e6
+		grupos[i].CalificacionPromedio = math.Round(grupos[i].CalificacionPromedio*100) / 100
+	}
+
+	return grupos, nil
+}
+
+// formatearGrados escribe un tamaño de celda calculado en el servidor para incrustarlo en GROUP BY,
+// que no acepta parametros
+func formatearGrados(grados float64) string {
+	return strconv.FormatFloat(grados, 'f', -1, 64)
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ElementoColaModeracion es una reseña pendiente de revision junto con sus reportes abiertos
+type ElementoColaModeracion struct {
+	Reseña             models.Reseña
+	ReportesPendientes int64
+}
+
+// ExisteReporte verifica si el usuario ya reporto la reseña
+func (dm *DBManager) ExisteReporte(idUsuario, idReseña uint) (bool, error) {
+	var count int64
+
+	result := dm.db.Model(&models.ReporteReseña{}).
+		Where("idUsuario = ? AND idResena = ?", idUsuario, idReseña).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// ObtenerReportesPorReseña lista todos los reportes de una reseña
+func (dm *DBManager) ObtenerReportesPorReseña(idReseña uint) ([]models.ReporteReseña, error) {
+	var reportes []models.ReporteReseña
+
+	result := dm.db.
+		Where("idResena = ?", idReseña).
+		Order("fecha DESC").
+		Find(&reportes)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return reportes, nil
+}
+
+// CrearReporte registra la denuncia de un usuario sobre una reseña y, si la reseña acumula umbral
+// reportes abiertos, la regresa a revision en la misma transaccion; con umbral 0 no se retiene
+func (dm *DBManager) CrearReporte(reporte *models.ReporteReseña, umbral int) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		// Bloquear la reseña serializa los reportes simultaneos sobre ella
+		var reseña models.Reseña
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&reseña, reporte.IDReseña).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("reseña no encontrada")
+			}
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Create(reporte).Error; err != nil {
+			return err
+		}
+
+		if umbral <= 0 || reseña.Estado != models.EstadoReseñaPublicada {
+			return nil
+		}
+
+		var pendientes int64
+		if err := tx.Model(&models.ReporteReseña{}).
+			Where("idResena = ? AND estado = ?", reporte.IDReseña, models.EstadoReportePendiente).
+			Count(&pendientes).Error; err != nil {
+			return err
+		}
+		if pendientes < int64(umbral) {
+			return nil
+		}
+
+		return cambiarEstadoReseña(tx, reseña, models.EstadoReseñaPendiente, "", nil)
+	})
+}
+
+// CambiarEstadoReseña actualiza el estado de moderacion, cierra los reportes abiertos y recalcula estadisticas
+// Si estadoReportes esta vacio los reportes pendientes no se modifican
+func (dm *DBManager) CambiarEstadoReseña(idReseña uint, estado, estadoReportes string, idModerador *uint) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		var reseña models.Reseña
+		if err := tx.First(&reseña, idReseña).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("reseña no encontrada")
+			}
+			return err
+		}
+
+		return cambiarEstadoReseña(tx, reseña, estado, estadoReportes, idModerador)
+	})
+}
+
+// cambiarEstadoReseña aplica el cambio de estado dentro de una transaccion abierta
+func cambiarEstadoReseña(tx *gorm.DB, reseña models.Reseña, estado, estadoReportes string, idModerador *uint) error {
+	if err := tx.Model(&models.Reseña{}).
+		Where("idResena = ?", reseña.IDReseña).
+		Update("estado", estado).Error; err != nil {
+		return err
+	}
+
+	if estadoReportes != "" {
+		ahora := time.Now()
+		err := tx.Model(&models.ReporteReseña{}).
+			Where("idResena = ? AND estado = ?", reseña.IDReseña, models.EstadoReportePendiente).
+			Updates(map[string]interface{}{
+				"estado":          estadoReportes,
+				"idModerador":     idModerador,
+				"fechaResolucion": &ahora,
+			}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return recalcularEstadisticasRestaurante(tx, reseña.IDRestaurante)
+}
+
+// ObtenerColaModeracion lista reseñas por estado; sin estado devuelve las pendientes o con reportes abiertos
+func (dm *DBManager) ObtenerColaModeracion(estado string, offset, limite int) ([]ElementoColaModeracion, int64, error) {
+	var filas []struct {
+		IDReseña           uint  `gorm:"column:idResena"`
+		ReportesPendientes int64 `gorm:"column:reportesPendientes"`
+	}
+	var total int64
+
+	base := dm.db.Table("resenas r").
+		Select("r.idResena AS idResena, COUNT(rr.idReporte) AS reportesPendientes").
+		Joins("LEFT JOIN reportes_resena rr ON rr.idResena = r.idResena AND rr.estado = ?", models.EstadoReportePendiente).
+		Group("r.idResena")
+
+	if estado != "" {
+		base = base.Where("r.estado = ?", estado)
+	} else {
+		base = base.Having("r.estado = ? OR COUNT(rr.idReporte) > 0", models.EstadoReseñaPendiente)
+	}
+
+	if err := dm.db.Table("(?) AS cola", base).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := base.
+		Order("reportesPendientes DESC, r.fecha ASC").
+		Offset(offset).
+		Limit(limite).
+		Scan(&filas)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	if len(filas) == 0 {
+		return []ElementoColaModeracion{}, total, nil
+	}
+
+	ids := make([]uint, len(filas))
+	for i, fila := range filas {
+		ids[i] = fila.IDReseña
+	}
+
+	var reseñas []models.Reseña
+	result = dm.db.
+		Preload("Usuario", func(db *gorm.DB) *gorm.DB {
+			return db.Select("idUsuario", "nombreUsuario", "nombre", "apellido", "foto")
+		}).
+		Where("idResena IN ?", ids).
+		Find(&reseñas)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	porID := make(map[uint]models.Reseña, len(reseñas))
+	for _, reseña := range reseñas {
+		porID[reseña.IDReseña] = reseña
+	}
+
+	cola := make([]ElementoColaModeracion, 0, len(filas))
+	for _, fila := range filas {
+		if reseña, ok := porID[fila.IDReseña]; ok {
+			cola = append(cola, ElementoColaModeracion{
+				Reseña:             reseña,
+				ReportesPendientes: fila.ReportesPendientes,
+			})
+		}
+	}
+
+	return cola, total, nil
+}
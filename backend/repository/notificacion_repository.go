@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CrearNotificacion guarda un aviso para un usuario
+func (dm *DBManager) CrearNotificacion(notificacion *models.Notificacion) error {
+	return dm.db.Omit(clause.Associations).Create(notificacion).Error
+}
+
+// ObtenerNotificacionesUsuario lista las notificaciones de un usuario, las mas recientes primero
+func (dm *DBManager) ObtenerNotificacionesUsuario(idUsuario uint, soloNoLeidas bool, offset, limite int) ([]models.Notificacion, int64, error) {
+	var notificaciones []models.Notificacion
+	var total int64
+
+	query := dm.db.Model(&models.Notificacion{}).Where("idUsuario = ?", idUsuario)
+	if soloNoLeidas {
+		query = query.Where("leida = ?", false)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query.
+		Order("fecha DESC, idNotificacion DESC").
+		Offset(offset).
+		Limit(limite).
+		Find(&notificaciones)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return notificaciones, total, nil
+}
+
+// ContarNotificacionesNoLeidas retorna cuantos avisos pendientes tiene el usuario
+func (dm *DBManager) ContarNotificacionesNoLeidas(idUsuario uint) (int64, error) {
+	var count int64
+
+	result := dm.db.Model(&models.Notificacion{}).
+		Where("idUsuario = ? AND leida = ?", idUsuario, false).
+		Count(&count)
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return count, nil
+}
+
+// MarcarNotificacionLeida marca como leida una notificacion del usuario
+func (dm *DBManager) MarcarNotificacionLeida(idUsuario, idNotificacion uint) (*models.Notificacion, error) {
+	var notificacion models.Notificacion
+
+	result := dm.db.
+		Where("idNotificacion = ? AND idUsuario = ?", idNotificacion, idUsuario).
+		First(&notificacion)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("notificacion no encontrada")
+		}
+		return nil, result.Error
+	}
+
+	if notificacion.Leida {
+		return &notificacion, nil
+	}
+
+	if err := dm.db.Model(&notificacion).Update("leida", true).Error; err != nil {
+		return nil, err
+	}
+
+	return &notificacion, nil
+}
+
+// MarcarTodasNotificacionesLeidas marca como leidas todas las notificaciones del usuario
+func (dm *DBManager) MarcarTodasNotificacionesLeidas(idUsuario uint) (int64, error) {
+	result := dm.db.Model(&models.Notificacion{}).
+		Where("idUsuario = ? AND leida = ?", idUsuario, false).
+		Update("leida", true)
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ordenes disponibles para los listados de restaurantes
+const (
+	OrdenRestaurantesRelevancia   = "relevancia"
+	OrdenRestaurantesDistancia    = "distancia"
+	OrdenRestaurantesCalificacion = "calificacion"
+	OrdenRestaurantesPrecio       = "precio"
+	OrdenRestaurantesNombre       = "nombre"
+	OrdenRestaurantesRecientes    = "recientes"
+)
+
+// EsOrdenRestaurantesValido indica si el orden solicitado esta soportado
+func EsOrdenRestaurantesValido(orden string) bool {
+	switch orden {
+	case OrdenRestaurantesRelevancia, OrdenRestaurantesDistancia, OrdenRestaurantesCalificacion,
+		OrdenRestaurantesPrecio, OrdenRestaurantesNombre, OrdenRestaurantesRecientes:
+		return true
+	}
+	return false
+}
+
+// Errores de paginacion causados por la peticion; se comparan con errors.Is
+var (
+	ErrCursorInvalido = errors.New("cursor invalido")
+	ErrOrdenInvalido  = errors.New("orden invalido")
+)
+
+// expresionDistancia calcula en SQL la distancia en km desde la columna espacial a un punto; recibe lng, lat
+// Usa el mismo radio terrestre que el calculo en Go para que distancias y filtros coincidan
+const expresionDistancia = "ST_Distance_Sphere(restaurantes.ubicacion, POINT(?, ?), 6371000) / 1000"
+
+// precioSinDato manda al final del orden por precio a los restaurantes sin precio promedio
+const precioSinDato = 999999.99
+
+// PaginacionRestaurantes define que pagina de un listado se pide y en que orden
+type PaginacionRestaurantes struct {
+	Limite   int
+	Orden    string
+	Cursor   string   // Opaco; vacio pide la primera pagina
+	Latitud  *float64 // Requeridos para ordenar por distancia
+	Longitud *float64
+	OrdenIDs []uint // Requerido para ordenar por relevancia: IDs de mayor a menor relevancia
+}
+
+// PaginaRestaurantes es una pagina de resultados con el cursor para pedir la siguiente
+type PaginaRestaurantes struct {
+	Restaurantes    []models.Restaurante
+	SiguienteCursor string // Vacio en la ultima pagina
+	TotalEstimado   int64  // Se cuenta en la primera pagina y viaja en el cursor
+}
+
+// cursorRestaurantes es el contenido del cursor: la clave de orden y el ID del ultimo elemento entregado
+type cursorRestaurantes struct {
+	Orden string `json:"o"`
+	Valor string `json:"v"`
+	ID    uint   `json:"i"`
+	Total int64  `json:"t"`
+}
+
+// claveOrden describe como ordenar y como continuar despues de un elemento
+type claveOrden struct {
+	expresion   string        // Expresion SQL del valor por el que se ordena
+	args        []interface{} // Argumentos de la expresion
+	seleccion   string        // Expresion que se guarda en el cursor; por defecto la misma
+	descendente bool
+}
+
+// PaginarRestaurantes aplica el filtro de busqueda y retorna una pagina en el orden pedido
+// Usa paginacion por llave (keyset): primero obtiene solo IDs y claves de orden y despues
+// carga las relaciones unicamente de los restaurantes de la pagina
+func (dm *DBManager) PaginarRestaurantes(filtro FiltroBusquedaRestaurantes, paginacion PaginacionRestaurantes) (*PaginaRestaurantes, error) {
+	clave, err := obtenerClaveOrden(paginacion)
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *cursorRestaurantes
+	if paginacion.Cursor != "" {
+		cursor, err = decodificarCursor(paginacion.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Orden != paginacion.Orden {
+			return nil, fmt.Errorf("%w: no corresponde al orden solicitado", ErrCursorInvalido)
+		}
+	}
+
+	pagina := &PaginaRestaurantes{Restaurantes: []models.Restaurante{}}
+
+	// El total se cuenta una vez; las paginas siguientes reutilizan el del cursor
+	if cursor == nil {
+		if err := aplicarFiltroBusqueda(dm.db.Model(&models.Restaurante{}), filtro, facetaNinguna).
+			Count(&pagina.TotalEstimado).Error; err != nil {
+			return nil, err
+		}
+	} else {
+		pagina.TotalEstimado = cursor.Total
+	}
+
+	seleccion := clave.seleccion
+	if seleccion == "" {
+		seleccion = clave.expresion
+	}
+
+	query := aplicarFiltroBusqueda(dm.db.Model(&models.Restaurante{}), filtro, facetaNinguna).
+		Select("restaurantes.idRestaurante AS id, CAST("+seleccion+" AS CHAR) AS clave", clave.args...)
+
+	if cursor != nil {
+		comparador := ">"
+		if clave.descendente {
+			comparador = "<"
+		}
+		args := append(append(append([]interface{}{}, clave.args...), cursor.Valor), clave.args...)
+		args = append(args, cursor.Valor, cursor.ID)
+		query = query.Where(
+			"("+clave.expresion+" "+comparador+" ? OR ("+clave.expresion+" = ? AND restaurantes.idRestaurante > ?))",
+			args...,
+		)
+	}
+
+	direccion := " ASC"
+	if clave.descendente {
+		direccion = " DESC"
+	}
+
+	var claves []struct {
+		ID    uint   `gorm:"column:id"`
+		Clave string `gorm:"column:clave"`
+	}
+	err = query.
+		Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:  clave.expresion + direccion + ", restaurantes.idRestaurante ASC",
+			Vars: clave.args,
+		}}).
+		Limit(paginacion.Limite + 1).
+		Scan(&claves).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(claves) > paginacion.Limite {
+		claves = claves[:paginacion.Limite]
+		ultimo := claves[len(claves)-1]
+		pagina.SiguienteCursor = codificarCursor(cursorRestaurantes{
+			Orden: paginacion.Orden,
+			Valor: ultimo.Clave,
+			ID:    ultimo.ID,
+			Total: pagina.TotalEstimado,
+		})
+	}
+
+	if len(claves) == 0 {
+		return pagina, nil
+	}
+
+	ids := make([]uint, 0, len(claves))
+	for _, c := range claves {
+		ids = append(ids, c.ID)
+	}
+
+	var restaurantes []models.Restaurante
+	err = dm.db.
+		Preload("Ciudad").
+		Preload("Categorias").
+		Preload("Caracteristicas").
+		Preload("Horarios").
+		Preload("Excepciones", excepcionesVigentes).
+		Preload("Precios").
+		Preload("Imagenes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("orden ASC")
+		}).
+		Where("idRestaurante IN ?", ids).
+		Find(&restaurantes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Se respeta el orden de la primera consulta
+	porID := make(map[uint]models.Restaurante, len(restaurantes))
+	for _, rest := range restaurantes {
+		porID[rest.IDRestaurante] = rest
+	}
+	for _, id := range ids {
+		if rest, ok := porID[id]; ok {
+			pagina.Restaurantes = append(pagina.Restaurantes, rest)
+		}
+	}
+
+	return pagina, nil
+}
+
+// obtenerClaveOrden traduce el orden pedido a su expresion SQL
+func obtenerClaveOrden(paginacion PaginacionRestaurantes) (claveOrden, error) {
+	switch paginacion.Orden {
+	case OrdenRestaurantesDistancia:
+		if paginacion.Latitud == nil || paginacion.Longitud == nil {
+			return claveOrden{}, fmt.Errorf("%w: el orden por distancia requiere latitud y longitud", ErrOrdenInvalido)
+		}
+		lat, lng := *paginacion.Latitud, *paginacion.Longitud
+		return claveOrden{expresion: expresionDistancia, args: []interface{}{lng, lat}}, nil
+	case OrdenRestaurantesCalificacion:
+		return claveOrden{expresion: "restaurantes.calificacionPromedio", descendente: true}, nil
+	case OrdenRestaurantesPrecio:
+		return claveOrden{
+			expresion: "COALESCE(" + expresionPrecioReferencia + ", ?)",
+			args:      []interface{}{precioSinDato},
+		}, nil
+	case OrdenRestaurantesNombre:
+		return claveOrden{expresion: "restaurantes.nombre"}, nil
+	case OrdenRestaurantesRecientes:
+		// El cursor guarda la fecha como texto que MySQL vuelve a interpretar como fecha
+		return claveOrden{
+			expresion:   "restaurantes.fechaRegistro",
+			seleccion:   "DATE_FORMAT(restaurantes.fechaRegistro, '%Y-%m-%d %H:%i:%s')",
+			descendente: true,
+		}, nil
+	case OrdenRestaurantesRelevancia:
+		if paginacion.OrdenIDs == nil {
+			return claveOrden{}, fmt.Errorf("%w: el orden por relevancia requiere un termino de busqueda", ErrOrdenInvalido)
+		}
+		if len(paginacion.OrdenIDs) == 0 {
+			return claveOrden{expresion: "0"}, nil // Sin coincidencias el filtro ya no devuelve filas
+		}
+		// Los IDs son enteros, se escriben directamente para poder repetir la expresion
+		ids := make([]string, 0, len(paginacion.OrdenIDs))
+		for _, id := range paginacion.OrdenIDs {
+			ids = append(ids, strconv.FormatUint(uint64(id), 10))
+		}
+		return claveOrden{expresion: "FIELD(restaurantes.idRestaurante, " + strings.Join(ids, ",") + ")"}, nil
+	}
+	return claveOrden{}, fmt.Errorf("%w, usa: distancia, calificacion, precio, nombre, recientes o relevancia", ErrOrdenInvalido)
+}
+
+func codificarCursor(cursor cursorRestaurantes) string {
+	datos, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(datos)
+}
+
+func decodificarCursor(valor string) (*cursorRestaurantes, error) {
+	datos, err := base64.RawURLEncoding.DecodeString(valor)
+	if err != nil {
+		return nil, ErrCursorInvalido
+	}
+
+	var cursor cursorRestaurantes
+	if err := json.Unmarshal(datos, &cursor); err != nil {
+		return nil, ErrCursorInvalido
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm"
+)
+
+// expresionPrecioReferencia es el precio con que se filtra, ordena y agrupa en bandas a un restaurante
+// Se toma de las estadisticas del menu; sin platillos con precio se usa el precio promedio capturado
+const expresionPrecioReferencia = "COALESCE((SELECT prr.precioReferencia FROM precios_restaurante prr " +
+	"WHERE prr.idRestaurante = restaurantes.idRestaurante), NULLIF(restaurantes.precioPromedio, 0))"
+
+// ObtenerPlatillosParaPrecios carga nombre y precio de los platillos disponibles con precio
+// Si ids es nil se cargan los de todos los restaurantes
+func (dm *DBManager) ObtenerPlatillosParaPrecios(ids []uint) ([]models.Platillo, error) {
+	var platillos []models.Platillo
+
+	if ids != nil && len(ids) == 0 {
+		return platillos, nil
+	}
+
+	query := dm.db.
+		Select("idPlatillo, idRestaurante, nombre, precio").
+		Where("disponible = ? AND precio > 0", true)
+	if ids != nil {
+		query = query.Where("idRestaurante IN ?", ids)
+	}
+
+	if err := query.Find(&platillos).Error; err != nil {
+		return nil, err
+	}
+
+	return platillos, nil
+}
+
+// GuardarPreciosRestaurantes reemplaza las estadisticas de precio de los restaurantes recalculados
+// Si ids es nil se reemplazan todas; los restaurantes sin estadisticas quedan sin registro
+func (dm *DBManager) GuardarPreciosRestaurantes(ids []uint, precios []models.PreciosRestaurante) error {
+	if ids != nil && len(ids) == 0 {
+		return nil
+	}
+
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		borrado := tx.Session(&gorm.Session{AllowGlobalUpdate: true})
+		if ids != nil {
+			borrado = borrado.Where("idRestaurante IN ?", ids)
+		}
+		if err := borrado.Delete(&models.PreciosRestaurante{}).Error; err != nil {
+			return err
+		}
+
+		if len(precios) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(precios, 500).Error
+	})
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm/clause"
+)
+
+// EsPropietarioRestaurante indica si el usuario administra el restaurante
+func (dm *DBManager) EsPropietarioRestaurante(idUsuario, idRestaurante uint) (bool, error) {
+	var count int64
+
+	result := dm.db.Model(&models.PropietarioRestaurante{}).
+		Where("idUsuario = ? AND idRestaurante = ?", idUsuario, idRestaurante).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// AsignarPropietario registra a un usuario como propietario de un restaurante
+func (dm *DBManager) AsignarPropietario(idUsuario, idRestaurante uint) error {
+	propietario := models.PropietarioRestaurante{
+		IDRestaurante: idRestaurante,
+		IDUsuario:     idUsuario,
+		Fecha:         time.Now(),
+	}
+
+	return dm.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&propietario).Error
+}
+
+// QuitarPropietario elimina la relacion de propiedad entre un usuario y un restaurante
+func (dm *DBManager) QuitarPropietario(idUsuario, idRestaurante uint) error {
+	result := dm.db.
+		Where("idUsuario = ? AND idRestaurante = ?", idUsuario, idRestaurante).
+		Delete(&models.PropietarioRestaurante{})
+
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("el usuario no es propietario de este restaurante")
+	}
+
+	return nil
+}
+
+// ObtenerPropietariosRestaurante lista los usuarios que administran un restaurante
+func (dm *DBManager) ObtenerPropietariosRestaurante(idRestaurante uint) ([]models.Usuario, error) {
+	var usuarios []models.Usuario
+
+	result := dm.db.
+		Select("usuarios.idUsuario", "usuarios.nombreUsuario", "usuarios.nombre", "usuarios.apellido", "usuarios.email").
+		Joins("JOIN propietarios_restaurante ON propietarios_restaurante.idUsuario = usuarios.idUsuario").
+		Where("propietarios_restaurante.idRestaurante = ?", idRestaurante).
+		Order("propietarios_restaurante.fecha ASC").
+		Find(&usuarios)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return usuarios, nil
+}
@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/tuusuario/quovi/algorithms"
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrReseñaDuplicada indica que el usuario ya tiene una reseña del restaurante
+var ErrReseñaDuplicada = errors.New("ya escribiste una reseña para este restaurante")
+
+// CrearReseña inserta una reseña y recalcula las estadisticas del restaurante en la misma transaccion
+// Si otra peticion ya inserto la reseña del usuario retorna ErrReseñaDuplicada
+func (dm *DBManager) CrearReseña(reseña *models.Reseña) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(reseña).Error; err != nil {
+			if esClaveDuplicada(err) {
+				return ErrReseñaDuplicada
+			}
+			return err
+		}
+		return recalcularEstadisticasRestaurante(tx, reseña.IDRestaurante)
+	})
+}
+
+// ActualizarReseña guarda los cambios de una reseña y recalcula las estadisticas del restaurante
+// No modifica la fecha de publicacion ni los contadores de votos, para no pisar votos concurrentes
+func (dm *DBManager) ActualizarReseña(reseña *models.Reseña) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(reseña).
+			Select("calificacion", "comentario", "verificada", "estado").
+			Updates(reseña).Error
+		if err != nil {
+			return err
+		}
+		return recalcularEstadisticasRestaurante(tx, reseña.IDRestaurante)
+	})
+}
+
+// EliminarReseña borra la reseña de un usuario y recalcula las estadisticas del restaurante
+func (dm *DBManager) EliminarReseña(idUsuario, idRestaurante uint) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Where("idUsuario = ? AND idRestaurante = ?", idUsuario, idRestaurante).
+			Delete(&models.Reseña{})
+
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("reseña no encontrada")
+		}
+
+		return recalcularEstadisticasRestaurante(tx, idRestaurante)
+	})
+}
+
+// ObtenerReseñaPorID busca una reseña por su ID
+func (dm *DBManager) ObtenerReseñaPorID(id uint) (*models.Reseña, error) {
+	var reseña models.Reseña
+
+	result := dm.db.First(&reseña, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("reseña no encontrada")
+		}
+		return nil, result.Error
+	}
+
+	return &reseña, nil
+}
+
+// ExisteReseñaUsuario verifica si el usuario ya escribio una reseña del restaurante
+func (dm *DBManager) ExisteReseñaUsuario(idUsuario, idRestaurante uint) (bool, error) {
+	var count int64
+
+	result := dm.db.Model(&models.Reseña{}).
+		Where("idUsuario = ? AND idRestaurante = ?", idUsuario, idRestaurante).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// ObtenerReseñaUsuario busca la reseña que un usuario dejo en un restaurante
+func (dm *DBManager) ObtenerReseñaUsuario(idUsuario, idRestaurante uint) (*models.Reseña, error) {
+	var reseña models.Reseña
+
+	result := dm.db.
+		Preload("Imagenes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("fechaSubida ASC, idImagen ASC")
+		}).
+		Preload("Respuesta").
+		Where("idUsuario = ? AND idRestaurante = ?", idUsuario, idRestaurante).
+		First(&reseña)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("reseña no encontrada")
+		}
+		return nil, result.Error
+	}
+
+	return &reseña, nil
+}
+
+// Ordenes disponibles para listar reseñas
+const (
+	OrdenReseñasRecientes = "recientes"
+	OrdenReseñasMejores   = "mejores"
+	OrdenReseñasPeores    = "peores"
+	OrdenReseñasUtiles    = "utiles"
+)
+
+var ordenesReseñas = map[string]string{
+	OrdenReseñasRecientes: "fecha DESC",
+	OrdenReseñasMejores:   "calificacion DESC, fecha DESC",
+	OrdenReseñasPeores:    "calificacion ASC, fecha DESC",
+	OrdenReseñasUtiles:    "puntuacionUtilidad DESC, votosUtiles DESC, fecha DESC",
+}
+
+// EsOrdenReseñasValido indica si el orden solicitado esta soportado
+func EsOrdenReseñasValido(orden string) bool {
+	_, ok := ordenesReseñas[orden]
+	return ok
+}
+
+// FiltroReseñas agrupa las opciones de listado de reseñas
+type FiltroReseñas struct {
+	Offset               int
+	Limite               int
+	Orden                string
+	SoloVerificadas      bool
+	PriorizarVerificadas bool
+}
+
+// ObtenerReseñasPorRestaurante lista las reseñas de un restaurante de forma paginada
+func (dm *DBManager) ObtenerReseñasPorRestaurante(idRestaurante uint, filtro FiltroReseñas) ([]models.Reseña, int64, error) {
+	var reseñas []models.Reseña
+	var total int64
+
+	query := dm.db.Model(&models.Reseña{}).
+		Where("idRestaurante = ? AND estado = ?", idRestaurante, models.EstadoReseñaPublicada)
+	if filtro.SoloVerificadas {
+		query = query.Where("verificada = ?", true)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filtro.PriorizarVerificadas {
+		query = query.Order("verificada DESC")
+	}
+
+	orden, ok := ordenesReseñas[filtro.Orden]
+	if !ok {
+		orden = ordenesReseñas[OrdenReseñasRecientes]
+	}
+
+	result := query.
+		Preload("Usuario", func(db *gorm.DB) *gorm.DB {
+			return db.Select("idUsuario", "nombreUsuario", "nombre", "apellido", "foto")
+		}).
+		Preload("Imagenes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("fechaSubida ASC, idImagen ASC")
+		}).
+		Preload("Respuesta").
+		Preload("Respuesta.Usuario", func(db *gorm.DB) *gorm.DB {
+			return db.Select("idUsuario", "nombreUsuario", "nombre")
+		}).
+		Order(orden).
+		Offset(filtro.Offset).
+		Limit(filtro.Limite).
+		Find(&reseñas)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return reseñas, total, nil
+}
+
+// VotarReseña registra o cambia el voto de utilidad de un usuario y recalcula la puntuacion
+func (dm *DBManager) VotarReseña(idReseña, idUsuario uint, util bool) (*models.Reseña, error) {
+	var reseña models.Reseña
+
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		voto := models.VotoReseña{
+			IDReseña:  idReseña,
+			IDUsuario: idUsuario,
+			Util:      util,
+			Fecha:     time.Now(),
+		}
+
+		err := tx.Omit(clause.Associations).
+			Clauses(clause.OnConflict{DoUpdates: clause.AssignmentColumns([]string{"util", "fecha"})}).
+			Create(&voto).Error
+		if err != nil {
+			return err
+		}
+
+		return recalcularVotosReseña(tx, idReseña, &reseña)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &reseña, nil
+}
+
+// EliminarVotoReseña retira el voto de un usuario y recalcula la puntuacion
+func (dm *DBManager) EliminarVotoReseña(idReseña, idUsuario uint) (*models.Reseña, error) {
+	var reseña models.Reseña
+
+	err := dm.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Where("idResena = ? AND idUsuario = ?", idReseña, idUsuario).
+			Delete(&models.VotoReseña{})
+
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("no has votado esta reseña")
+		}
+
+		return recalcularVotosReseña(tx, idReseña, &reseña)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &reseña, nil
+}
+
+// recalcularVotosReseña actualiza los contadores de votos y el limite inferior de Wilson de la reseña
+func recalcularVotosReseña(tx *gorm.DB, idReseña uint, reseña *models.Reseña) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(reseña, idReseña).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("reseña no encontrada")
+		}
+		return err
+	}
+
+	var conteo struct {
+		Utiles   int64 `gorm:"column:utiles"`
+		NoUtiles int64 `gorm:"column:noUtiles"`
+	}
+
+	err := tx.Model(&models.VotoReseña{}).
+		Select("COALESCE(SUM(util = TRUE), 0) AS utiles, COALESCE(SUM(util = FALSE), 0) AS noUtiles").
+		Where("idResena = ?", idReseña).
+		Scan(&conteo).Error
+	if err != nil {
+		return err
+	}
+
+	reseña.VotosUtiles = int(conteo.Utiles)
+	reseña.VotosNoUtiles = int(conteo.NoUtiles)
+	reseña.PuntuacionUtilidad = math.Round(algorithms.LimiteInferiorWilson(conteo.Utiles, conteo.Utiles+conteo.NoUtiles)*100000) / 100000
+
+	return tx.Model(&models.Reseña{}).
+		Where("idResena = ?", idReseña).
+		Updates(map[string]interface{}{
+			"votosUtiles":        reseña.VotosUtiles,
+			"votosNoUtiles":      reseña.VotosNoUtiles,
+			"puntuacionUtilidad": reseña.PuntuacionUtilidad,
+		}).Error
+}
+
+// CrearVisita registra un check-in valido de un usuario en un restaurante
+func (dm *DBManager) CrearVisita(visita *models.Visita) error {
+	return dm.db.Omit(clause.Associations).Create(visita).Error
+}
+
+// ExisteVisitaDesde verifica si el usuario hizo check-in en el restaurante a partir de una fecha
+func (dm *DBManager) ExisteVisitaDesde(idUsuario, idRestaurante uint, desde time.Time) (bool, error) {
+	var count int64
+
+	result := dm.db.Model(&models.Visita{}).
+		Where("idUsuario = ? AND idRestaurante = ? AND fecha >= ?", idUsuario, idRestaurante, desde).
+		Count(&count)
+
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// recalcularEstadisticasRestaurante actualiza calificacionPromedio y totalResenas a partir de las reseñas publicadas
+func recalcularEstadisticasRestaurante(tx *gorm.DB, idRestaurante uint) error {
+	// Bloquear la fila del restaurante para serializar recalculos concurrentes
+	var restaurante models.Restaurante
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("idRestaurante").
+		First(&restaurante, idRestaurante).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("restaurante no encontrado")
+		}
+		return err
+	}
+
+	var estadisticas struct {
+		Promedio float64 `gorm:"column:promedio"`
+		Total    int64   `gorm:"column:total"`
+	}
+
+	err := tx.Model(&models.Reseña{}).
+		Select("COALESCE(AVG(calificacion), 0) AS promedio, COUNT(*) AS total").
+		Where("idRestaurante = ? AND estado = ?", idRestaurante, models.EstadoReseñaPublicada).
+		Scan(&estadisticas).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Restaurante{}).
+		Where("idRestaurante = ?", idRestaurante).
+		Updates(map[string]interface{}{
+			"calificacionPromedio": math.Round(estadisticas.Promedio*100) / 100,
+			"totalResenas":         estadisticas.Total,
+		}).Error
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/tuusuario/quovi/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ObtenerRespuestaReseña busca la respuesta del propietario a una reseña
+func (dm *DBManager) ObtenerRespuestaReseña(idReseña uint) (*models.RespuestaReseña, error) {
+	var respuesta models.RespuestaReseña
+
+	result := dm.db.Where("idResena = ?", idReseña).First(&respuesta)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, errors.New("la reseña no tiene respuesta")
+		}
+		return nil, result.Error
+	}
+
+	return &respuesta, nil
+}
+
+// CrearRespuestaReseña guarda la respuesta y la notificacion al autor en la misma transaccion
+func (dm *DBManager) CrearRespuestaReseña(respuesta *models.RespuestaReseña, notificacion *models.Notificacion) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(respuesta).Error; err != nil {
+			return err
+		}
+		if notificacion == nil {
+			return nil
+		}
+		return tx.Omit(clause.Associations).Create(notificacion).Error
+	})
+}
+
+// ActualizarRespuestaReseña guarda el nuevo texto de una respuesta y notifica al autor
+func (dm *DBManager) ActualizarRespuestaReseña(respuesta *models.RespuestaReseña, notificacion *models.Notificacion) error {
+	return dm.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(respuesta).
+			Select("comentario", "idUsuario", "fechaEdicion").
+			Updates(respuesta).Error
+		if err != nil {
+			return err
+		}
+		if notificacion == nil {
+			return nil
+		}
+		return tx.Omit(clause.Associations).Create(notificacion).Error
+	})
+}
+
+// EliminarRespuestaReseña borra la respuesta de una reseña
+func (dm *DBManager) EliminarRespuestaReseña(idReseña uint) error {
+	result := dm.db.Where("idResena = ?", idReseña).Delete(&models.RespuestaReseña{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("la reseña no tiene respuesta")
+	}
+	return nil
+}
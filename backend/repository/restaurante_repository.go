@@ -2,32 +2,11 @@ package repository
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/tuusuario/quovi/models"
 	"gorm.io/gorm"
 )
 
-// ObtenerTodosLosRestaurantes retorna todos los restaurantes activos con sus relaciones
-func (dm *DBManager) ObtenerTodosLosRestaurantes() ([]models.Restaurante, error) {
-	var restaurantes []models.Restaurante
-
-	result := dm.db.
-		Preload("Ciudad").
-		Preload("Categorias").
-		Preload("Caracteristicas").
-		Preload("Horarios").
-		Preload("Imagenes").
-		Where("activo = ?", true).
-		Find(&restaurantes)
-
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return restaurantes, nil
-}
-
 // ObtenerRestaurantePorID busca un restaurante especifico con toda su informacion
 func (dm *DBManager) ObtenerRestaurantePorID(id uint) (*models.Restaurante, error) {
 	var restaurante models.Restaurante
@@ -37,7 +16,10 @@ func (dm *DBManager) ObtenerRestaurantePorID(id uint) (*models.Restaurante, erro
 		Preload("Categorias").
 		Preload("Caracteristicas").
 		Preload("Platillos", "disponible = ?", true).
+		Preload("Platillos.Etiquetas").
 		Preload("Horarios").
+		Preload("Excepciones", excepcionesVigentes).
+		Preload("Precios").
 		Preload("Imagenes", func(db *gorm.DB) *gorm.DB {
 			return db.Order("orden ASC")
 		}).
@@ -54,120 +36,6 @@ func (dm *DBManager) ObtenerRestaurantePorID(id uint) (*models.Restaurante, erro
 	return &restaurante, nil
 }
 
-// ObtenerRestaurantesPorCiudad filtra restaurantes por ciudad
-func (dm *DBManager) ObtenerRestaurantesPorCiudad(idCiudad uint) ([]models.Restaurante, error) {
-	var restaurantes []models.Restaurante
-
-	result := dm.db.
-		Preload("Ciudad").
-		Preload("Categorias").
-		Preload("Caracteristicas").
-		Preload("Horarios").
-		Preload("Imagenes").
-		Where("id_ciudad = ? AND activo = ?", idCiudad, true).
-		Find(&restaurantes)
-
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return restaurantes, nil
-}
-
-// ObtenerRestaurantesPorCategoria filtra restaurantes por tipo de cocina
-func (dm *DBManager) ObtenerRestaurantesPorCategoria(idCategoria uint) ([]models.Restaurante, error) {
-	var restaurantes []models.Restaurante
-
-	result := dm.db.
-		Distinct("restaurantes.*").
-		Preload("Ciudad").
-		Preload("Categorias").
-		Preload("Caracteristicas").
-		Preload("Horarios").
-		Preload("Imagenes").
-		Joins("INNER JOIN restaurante_categorias ON restaurante_categorias.idRestaurante = restaurantes.idRestaurante").
-		Where("restaurante_categorias.idCategoria = ? AND restaurantes.activo = ?", idCategoria, true).
-		Find(&restaurantes)
-
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return restaurantes, nil
-}
-
-// BuscarRestaurantes busca por termino en nombre o descripcion
-func (dm *DBManager) BuscarRestaurantes(termino string) ([]models.Restaurante, error) {
-	var restaurantes []models.Restaurante
-	searchTerm := fmt.Sprintf("%%%s%%", termino)
-
-	result := dm.db.
-		Preload("Ciudad").
-		Preload("Categorias").
-		Preload("Caracteristicas").
-		Preload("Horarios").
-		Preload("Imagenes").
-		Where("activo = ? AND (nombre LIKE ? OR descripcion LIKE ?)", true, searchTerm, searchTerm).
-		Find(&restaurantes)
-
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return restaurantes, nil
-}
-
-// BuscarRestaurantesPorCategoriaNombre busca por nombre de categoria
-func (dm *DBManager) BuscarRestaurantesPorCategoriaNombre(nombreCategoria string) ([]models.Restaurante, error) {
-	var restaurantes []models.Restaurante
-
-	result := dm.db.
-		Distinct("restaurantes.*").
-		Preload("Ciudad").
-		Preload("Categorias").
-		Preload("Caracteristicas").
-		Preload("Horarios").
-		Preload("Imagenes").
-		Joins("INNER JOIN restaurante_categorias rc ON rc.idRestaurante = restaurantes.idRestaurante").
-		Joins("INNER JOIN categorias_cocina cc ON cc.idCategoria = rc.idCategoria").
-		Where("cc.nombreCategoria = ? AND restaurantes.activo = ?", nombreCategoria, true).
-		Find(&restaurantes)
-
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return restaurantes, nil
-}
-
-// ObtenerRestaurantesCercanos busca restaurantes dentro de un radio aproximado
-func (dm *DBManager) ObtenerRestaurantesCercanos(lat, lng, radioKm float64) ([]models.Restaurante, error) {
-	var restaurantes []models.Restaurante
-
-	// Calculo aproximado de delta de coordenadas
-	deltaLat := radioKm / 111.0
-	deltaLng := radioKm / (111.0 * 0.8)
-
-	result := dm.db.
-		Preload("Ciudad").
-		Preload("Categorias").
-		Preload("Caracteristicas").
-		Preload("Horarios").
-		Preload("Imagenes").
-		Where("activo = ? AND latitud BETWEEN ? AND ? AND longitud BETWEEN ? AND ?",
-			true,
-			lat-deltaLat, lat+deltaLat,
-			lng-deltaLng, lng+deltaLng,
-		).
-		Find(&restaurantes)
-
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return restaurantes, nil
-}
-
 // ObtenerTodasLasCiudades lista todas las ciudades disponibles
 func (dm *DBManager) ObtenerTodasLasCiudades() ([]models.Ciudad, error) {
 	var ciudades []models.Ciudad
@@ -178,14 +46,11 @@ func (dm *DBManager) ObtenerTodasLasCiudades() ([]models.Ciudad, error) {
 	return ciudades, nil
 }
 
-// ObtenerCiudadPorID busca una ciudad especifica
+// ObtenerCiudadPorID busca una ciudad especifica; si no existe retorna gorm.ErrRecordNotFound
 func (dm *DBManager) ObtenerCiudadPorID(id uint) (*models.Ciudad, error) {
 	var ciudad models.Ciudad
 	result := dm.db.First(&ciudad, id)
 	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, errors.New("ciudad no encontrada")
-		}
 		return nil, result.Error
 	}
 	return &ciudad, nil
@@ -204,19 +69,22 @@ func (dm *DBManager) ObtenerTodasLasCategorias() ([]models.CategoriaRestaurante,
 // ObtenerTodasLasCaracteristicas lista todas las caracteristicas disponibles
 func (dm *DBManager) ObtenerTodasLasCaracteristicas() ([]models.CaracteristicaRestaurante, error) {
 	var caracteristicas []models.CaracteristicaRestaurante
-	result := dm.db.Find(&caracteristicas)
+	result := dm.db.Order("nombreCaracteristica ASC").Find(&caracteristicas)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return caracteristicas, nil
 }
 
-// ObtenerPlatillosPorRestaurante lista platillos disponibles de un restaurante
-func (dm *DBManager) ObtenerPlatillosPorRestaurante(idRestaurante uint) ([]models.Platillo, error) {
+// ObtenerPlatillosPorRestaurante lista platillos disponibles de un restaurante con sus etiquetas
+func (dm *DBManager) ObtenerPlatillosPorRestaurante(idRestaurante uint, filtro FiltroPlatillos) ([]models.Platillo, error) {
 	var platillos []models.Platillo
 
-	result := dm.db.
-		Where("idRestaurante = ? AND disponible = ?", idRestaurante, true).
+	query := dm.db.
+		Preload("Etiquetas").
+		Where("idRestaurante = ? AND disponible = ?", idRestaurante, true)
+
+	result := aplicarFiltroPlatillos(query, filtro).
 		Order("destacado DESC, nombre ASC").
 		Find(&platillos)
 
@@ -243,27 +111,6 @@ func (dm *DBManager) EliminarFavorito(idUsuario, idRestaurante uint) error {
 		Delete(&models.Favorito{}).Error
 }
 
-// ObtenerFavoritosUsuario lista todos los favoritos de un usuario
-func (dm *DBManager) ObtenerFavoritosUsuario(idUsuario uint) ([]models.Restaurante, error) {
-	var restaurantes []models.Restaurante
-
-	result := dm.db.
-		Preload("Ciudad").
-		Preload("Categorias").
-		Preload("Caracteristicas").
-		Preload("Horarios").
-		Preload("Imagenes").
-		Joins("JOIN favoritos ON favoritos.idRestaurante = restaurantes.idRestaurante").
-		Where("favoritos.idUsuario = ? AND restaurantes.activo = ?", idUsuario, true).
-		Find(&restaurantes)
-
-	if result.Error != nil {
-		return nil, result.Error
-	}
-
-	return restaurantes, nil
-}
-
 // VerificarFavorito verifica si un restaurante esta en favoritos
 func (dm *DBManager) VerificarFavorito(idUsuario, idRestaurante uint) (bool, error) {
 	var count int64
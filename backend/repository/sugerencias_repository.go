@@ -0,0 +1,56 @@
+package repository
+
+// ElementoCatalogo es un nombre del catalogo con una medida de su popularidad
+type ElementoCatalogo struct {
+	ID          uint    `gorm:"column:id"`
+	Nombre      string  `gorm:"column:nombre"`
+	Popularidad float64 `gorm:"column:popularidad"`
+}
+
+// CatalogoSugerencias agrupa los nombres que alimentan el autocompletado
+type CatalogoSugerencias struct {
+	Restaurantes []ElementoCatalogo // Popularidad: numero de reseñas
+	Categorias   []ElementoCatalogo // Popularidad: restaurantes activos de la categoria
+	Platillos    []ElementoCatalogo // Agrupados por nombre; popularidad: restaurantes que lo sirven
+	Ciudades     []ElementoCatalogo // Popularidad: restaurantes activos en la ciudad
+}
+
+// ObtenerCatalogoSugerencias carga los nombres de restaurantes, categorias, platillos y ciudades
+func (dm *DBManager) ObtenerCatalogoSugerencias() (*CatalogoSugerencias, error) {
+	var catalogo CatalogoSugerencias
+
+	if err := dm.db.Table("restaurantes").
+		Select("idRestaurante AS id, nombre, totalResenas AS popularidad").
+		Where("activo = ?", true).
+		Scan(&catalogo.Restaurantes).Error; err != nil {
+		return nil, err
+	}
+
+	if err := dm.db.Table("categorias_cocina c").
+		Select("c.idCategoria AS id, c.nombreCategoria AS nombre, COUNT(r.idRestaurante) AS popularidad").
+		Joins("LEFT JOIN restaurante_categorias rc ON rc.idCategoria = c.idCategoria").
+		Joins("LEFT JOIN restaurantes r ON r.idRestaurante = rc.idRestaurante AND r.activo = ?", true).
+		Group("c.idCategoria, c.nombreCategoria").
+		Scan(&catalogo.Categorias).Error; err != nil {
+		return nil, err
+	}
+
+	if err := dm.db.Table("platillos p").
+		Select("0 AS id, p.nombre AS nombre, COUNT(DISTINCT p.idRestaurante) AS popularidad").
+		Joins("JOIN restaurantes r ON r.idRestaurante = p.idRestaurante AND r.activo = ?", true).
+		Where("p.disponible = ?", true).
+		Group("p.nombre").
+		Scan(&catalogo.Platillos).Error; err != nil {
+		return nil, err
+	}
+
+	if err := dm.db.Table("ciudades ci").
+		Select("ci.idCiudad AS id, ci.nombreCiudad AS nombre, COUNT(r.idRestaurante) AS popularidad").
+		Joins("LEFT JOIN restaurantes r ON r.idCiudad = ci.idCiudad AND r.activo = ?", true).
+		Group("ci.idCiudad, ci.nombreCiudad").
+		Scan(&catalogo.Ciudades).Error; err != nil {
+		return nil, err
+	}
+
+	return &catalogo, nil
+}
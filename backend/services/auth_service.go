@@ -146,6 +146,15 @@ func (as *AuthService) ValidarToken(tokenString string) (uint, error) {
 	return 0, errors.New("token invalido")
 }
 
+// EsAdministrador indica si el usuario tiene rol de administrador
+func (as *AuthService) EsAdministrador(userID uint) (bool, error) {
+	usuario, err := as.dbManager.ObtenerUsuarioPorID(userID)
+	if err != nil {
+		return false, err
+	}
+	return usuario.Activo && usuario.Rol == models.RolAdmin, nil
+}
+
 // CerrarSesion invalida un token de sesion
 func (as *AuthService) CerrarSesion(token string) error {
 	return as.dbManager.EliminarSesion(token)
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/utils"
+)
+
+// Tipos de etiqueta de platillo
+const (
+	TipoEtiquetaDieta    = "dieta"
+	TipoEtiquetaAlergeno = "alergeno"
+)
+
+// InfoEtiqueta describe una etiqueta de platillo para mostrarla como insignia
+type InfoEtiqueta struct {
+	Clave  string `json:"clave"`
+	Nombre string `json:"nombre"`
+	Tipo   string `json:"tipo"`
+}
+
+// CatalogoEtiquetas lista las etiquetas disponibles en el orden en que se muestran
+var CatalogoEtiquetas = []InfoEtiqueta{
+	{Clave: models.EtiquetaVegetariano, Nombre: "Vegetariano", Tipo: TipoEtiquetaDieta},
+	{Clave: models.EtiquetaVegano, Nombre: "Vegano", Tipo: TipoEtiquetaDieta},
+	{Clave: models.EtiquetaSinGluten, Nombre: "Sin gluten", Tipo: TipoEtiquetaDieta},
+	{Clave: models.EtiquetaContieneNueces, Nombre: "Contiene nueces", Tipo: TipoEtiquetaAlergeno},
+	{Clave: models.EtiquetaContieneCacahuate, Nombre: "Contiene cacahuate", Tipo: TipoEtiquetaAlergeno},
+	{Clave: models.EtiquetaContieneMariscos, Nombre: "Contiene mariscos", Tipo: TipoEtiquetaAlergeno},
+	{Clave: models.EtiquetaContieneLacteos, Nombre: "Contiene lacteos", Tipo: TipoEtiquetaAlergeno},
+}
+
+// EsEtiquetaValida indica si la clave pertenece al catalogo de etiquetas
+func EsEtiquetaValida(clave string) bool {
+	for _, info := range CatalogoEtiquetas {
+		if info.Clave == clave {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrEtiquetaInvalida indica que se recibio una etiqueta fuera del catalogo; se compara con errors.Is
+var ErrEtiquetaInvalida = errors.New("etiqueta invalida")
+
+// validarEtiquetas verifica que todas las claves recibidas esten en el catalogo
+func validarEtiquetas(listas ...[]string) error {
+	for _, etiquetas := range listas {
+		for _, etiqueta := range etiquetas {
+			if !EsEtiquetaValida(etiqueta) {
+				return fmt.Errorf("%w: %s", ErrEtiquetaInvalida, etiqueta)
+			}
+		}
+	}
+	return nil
+}
+
+// Grupos de ingredientes que reconoce el diccionario; cada uno corresponde a una etiqueta de alergeno
+const (
+	grupoMariscos  = "mariscos"
+	grupoLacteos   = "lacteos"
+	grupoNueces    = "nueces"
+	grupoCacahuate = "cacahuate"
+)
+
+// etiquetaPorGrupo es la etiqueta de alergeno que se agrega cuando se menciona un grupo
+var etiquetaPorGrupo = map[string]string{
+	grupoMariscos:  models.EtiquetaContieneMariscos,
+	grupoLacteos:   models.EtiquetaContieneLacteos,
+	grupoNueces:    models.EtiquetaContieneNueces,
+	grupoCacahuate: models.EtiquetaContieneCacahuate,
+}
+
+// palabrasPorGrupo es el diccionario que se mantiene a mano: palabras o frases en minusculas y sin acentos
+// Basta con la forma singular; los plurales terminados en s, es o ces se reconocen solos
+var palabrasPorGrupo = map[string][]string{
+	grupoMariscos: {
+		"marisco", "camaron", "langosta", "langostino", "cangrejo", "jaiba", "pulpo", "calamar", "ostion",
+		"ostra", "almeja", "mejillon", "callo", "caracol", "percebe", "abulon", "surimi",
+	},
+	grupoLacteos: {
+		"leche", "queso", "crema", "mantequilla", "yogur", "yogurt", "nata", "requeson", "jocoque", "panela",
+		"mozzarella", "parmesano", "manchego", "gouda", "cheddar", "ricotta", "mascarpone", "cajeta", "helado",
+		"suero de leche", "lechera", "chantilly", "ghee",
+	},
+	grupoNueces: {
+		"nuez", "almendra", "pistache", "pistacho", "avellana", "macadamia", "pecana", "pinon", "castana",
+		"nuez de la india", "maranon",
+	},
+	grupoCacahuate: {
+		"cacahuate", "cacahuete", "mani",
+	},
+}
+
+// frasesCompuestas tienen prioridad sobre las palabras que contienen
+// Una lista vacia neutraliza la frase, como "leche de coco" que no es lacteo
+var frasesCompuestas = map[string][]string{
+	"leche de coco":            {},
+	"leche de soya":            {},
+	"leche de arroz":           {},
+	"leche de avena":           {},
+	"leche de almendra":        {grupoNueces},
+	"crema de coco":            {},
+	"crema de cacahuate":       {grupoCacahuate},
+	"mantequilla de cacahuate": {grupoCacahuate},
+	"mantequilla de mani":      {grupoCacahuate},
+	"mantequilla vegetal":      {},
+	"queso vegano":             {},
+	"harina de almendra":       {grupoNueces},
+	"nuez moscada":             {},
+}
+
+// diccionarioEtiquetas une palabras y frases con los grupos que indican; se arma al iniciar
+var diccionarioEtiquetas = construirDiccionarioEtiquetas()
+
+type diccionarioIngredientes struct {
+	frases         map[string][]string
+	vocabulario    map[string]bool // Palabras sueltas que aparecen en alguna frase, para reconocer plurales
+	maximoPalabras int
+}
+
+func construirDiccionarioEtiquetas() diccionarioIngredientes {
+	diccionario := diccionarioIngredientes{
+		frases:      make(map[string][]string),
+		vocabulario: make(map[string]bool),
+	}
+
+	agregar := func(frase string, grupos []string) {
+		palabras := strings.Fields(frase)
+		for _, palabra := range palabras {
+			diccionario.vocabulario[palabra] = true
+		}
+		if len(palabras) > diccionario.maximoPalabras {
+			diccionario.maximoPalabras = len(palabras)
+		}
+		diccionario.frases[strings.Join(palabras, " ")] = grupos
+	}
+
+	for grupo, palabras := range palabrasPorGrupo {
+		for _, palabra := range palabras {
+			agregar(palabra, append(diccionario.frases[palabra], grupo))
+		}
+	}
+	for frase, grupos := range frasesCompuestas {
+		agregar(frase, grupos)
+	}
+
+	return diccionario
+}
+
+// singular lleva una palabra a la forma que aparece en el diccionario, si alguna coincide
+func (d diccionarioIngredientes) singular(palabra string) string {
+	if d.vocabulario[palabra] {
+		return palabra
+	}
+	candidatos := []string{strings.TrimSuffix(palabra, "s"), strings.TrimSuffix(palabra, "es")}
+	if strings.HasSuffix(palabra, "ces") {
+		candidatos = append(candidatos, strings.TrimSuffix(palabra, "ces")+"z")
+	}
+	for _, candidato := range candidatos {
+		if candidato != palabra && d.vocabulario[candidato] {
+			return candidato
+		}
+	}
+	return palabra
+}
+
+// gruposEnTexto detecta los grupos de ingredientes mencionados en el texto
+// Se prefiere la frase mas larga y se ignora la frase que sigue a "sin", como en "sin nueces"; en una
+// lista como "sin nuez ni cacahuate" el resto si se detecta, para no omitir una advertencia por error
+func (d diccionarioIngredientes) gruposEnTexto(texto string, grupos map[string]bool) {
+	tokens := utils.Tokenizar(texto)
+	for i := range tokens {
+		tokens[i] = d.singular(tokens[i])
+	}
+
+	negado := false
+	for i := 0; i < len(tokens); {
+		if tokens[i] == "sin" {
+			negado = true
+			i++
+			continue
+		}
+
+		avance := 1
+		for n := min(d.maximoPalabras, len(tokens)-i); n >= 1; n-- {
+			encontrados, ok := d.frases[strings.Join(tokens[i:i+n], " ")]
+			if !ok {
+				continue
+			}
+			if !negado {
+				for _, grupo := range encontrados {
+					grupos[grupo] = true
+				}
+			}
+			avance = n
+			break
+		}
+
+		// La negacion solo aplica a la palabra o frase inmediata
+		negado = false
+		i += avance
+	}
+}
+
+// InferirEtiquetas deduce las advertencias de alergenos de un platillo a partir de su nombre, descripcion
+// e ingredientes. Solo agrega etiquetas contiene_*: que el diccionario no reconozca un ingrediente no
+// prueba su ausencia, asi que vegetariano, vegano y sin gluten solo los asigna el propietario
+func InferirEtiquetas(platillo models.Platillo) []string {
+	grupos := make(map[string]bool)
+	for _, texto := range []string{platillo.Nombre, platillo.Descripcion, platillo.Ingredientes} {
+		diccionarioEtiquetas.gruposEnTexto(texto, grupos)
+	}
+
+	presentes := make(map[string]bool, len(grupos))
+	for grupo := range grupos {
+		presentes[etiquetaPorGrupo[grupo]] = true
+	}
+	return etiquetasEnOrden(presentes)
+}
+
+// etiquetasEnOrden retorna las claves presentes en el orden del catalogo
+func etiquetasEnOrden(presentes map[string]bool) []string {
+	etiquetas := make([]string, 0, len(presentes))
+	for _, info := range CatalogoEtiquetas {
+		if presentes[info.Clave] {
+			etiquetas = append(etiquetas, info.Clave)
+		}
+	}
+	return etiquetas
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+)
+
+// EtiquetasPlatilloService mantiene las etiquetas de dieta y alergenos de los platillos
+// Solo se infieren advertencias de alergenos; las de dieta las asigna el propietario
+// Las inferidas se recalculan en todos los platillos al iniciar, para aplicar cambios del diccionario,
+// y cuando un platillo se modifica; las que asigna el propietario se conservan junto a ellas
+type EtiquetasPlatilloService struct {
+	dbManager *repository.DBManager
+
+	mu                   sync.Mutex // Serializa sincronizaciones
+	ultimaSincronizacion time.Time
+}
+
+// NewEtiquetasPlatilloService crea una nueva instancia del servicio
+func NewEtiquetasPlatilloService(dbManager *repository.DBManager) *EtiquetasPlatilloService {
+	return &EtiquetasPlatilloService{dbManager: dbManager}
+}
+
+// ObtenerCatalogo retorna las etiquetas disponibles para mostrar insignias y filtros
+func (es *EtiquetasPlatilloService) ObtenerCatalogo() []InfoEtiqueta {
+	return CatalogoEtiquetas
+}
+
+// Sincronizar vuelve a inferir los alergenos de todos los platillos
+func (es *EtiquetasPlatilloService) Sincronizar() error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	inicio := time.Now()
+
+	platillos, err := es.dbManager.ObtenerPlatillosParaEtiquetar(nil)
+	if err != nil {
+		return err
+	}
+
+	actualizados, err := es.actualizarInferidas(platillos)
+	if err != nil {
+		return err
+	}
+
+	es.ultimaSincronizacion = inicio
+	log.Printf("Etiquetas de platillos sincronizadas: %d platillos, %d actualizados", len(platillos), actualizados)
+	return nil
+}
+
+// Refrescar vuelve a inferir solo las etiquetas de los platillos modificados desde la ultima sincronizacion
+func (es *EtiquetasPlatilloService) Refrescar() error {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	inicio := time.Now()
+
+	// Sin sincronizacion previa se revisan todos los platillos
+	var desde *time.Time
+	if !es.ultimaSincronizacion.IsZero() {
+		limite := es.ultimaSincronizacion.Add(-margenSincronizacion)
+		desde = &limite
+	}
+
+	platillos, err := es.dbManager.ObtenerPlatillosParaEtiquetar(desde)
+	if err != nil {
+		return err
+	}
+
+	if _, err := es.actualizarInferidas(platillos); err != nil {
+		return err
+	}
+
+	es.ultimaSincronizacion = inicio
+	return nil
+}
+
+// IniciarRefresco lanza en segundo plano el refresco incremental de las etiquetas
+func (es *EtiquetasPlatilloService) IniciarRefresco(intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		// Si la sincronizacion inicial fallo, el primer refresco abarca todos los platillos
+		for range ticker.C {
+			if err := es.Refrescar(); err != nil {
+				log.Printf("Error al refrescar etiquetas de platillos: %v", err)
+			}
+		}
+	}()
+}
+
+// actualizarInferidas guarda las etiquetas de los platillos cuya inferencia cambio y retorna cuantos fueron
+func (es *EtiquetasPlatilloService) actualizarInferidas(platillos []models.Platillo) (int, error) {
+	actualizados := 0
+	for _, platillo := range platillos {
+		inferidas := InferirEtiquetas(platillo)
+		if mismasEtiquetas(platillo.Etiquetas, inferidas) {
+			continue
+		}
+
+		if err := es.dbManager.ReemplazarEtiquetasInferidas(platillo.IDPlatillo, inferidas); err != nil {
+			return actualizados, err
+		}
+		actualizados++
+	}
+	return actualizados, nil
+}
+
+// AsignarEtiquetas reemplaza las etiquetas manuales de un platillo; los alergenos inferidos se conservan
+// Solo pueden hacerlo los propietarios del restaurante o un administrador
+func (es *EtiquetasPlatilloService) AsignarEtiquetas(idUsuario, idPlatillo uint, etiquetas []string) (*models.Platillo, error) {
+	for i := range etiquetas {
+		etiquetas[i] = strings.TrimSpace(etiquetas[i])
+	}
+	if err := validarEtiquetas(etiquetas); err != nil {
+		return nil, err
+	}
+
+	platillo, err := es.platilloEditable(idUsuario, idPlatillo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := es.dbManager.AsignarEtiquetasManuales(platillo.IDPlatillo, etiquetas, InferirEtiquetas(*platillo)); err != nil {
+		return nil, err
+	}
+
+	return es.dbManager.ObtenerPlatilloPorID(platillo.IDPlatillo)
+}
+
+// RestablecerEtiquetas descarta las etiquetas manuales, incluidas las de dieta, y vuelve a inferir
+// los alergenos de los ingredientes
+func (es *EtiquetasPlatilloService) RestablecerEtiquetas(idUsuario, idPlatillo uint) (*models.Platillo, error) {
+	platillo, err := es.platilloEditable(idUsuario, idPlatillo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := es.dbManager.RestablecerEtiquetasInferidas(platillo.IDPlatillo, InferirEtiquetas(*platillo)); err != nil {
+		return nil, err
+	}
+
+	return es.dbManager.ObtenerPlatilloPorID(platillo.IDPlatillo)
+}
+
+// platilloEditable carga el platillo y verifica que el usuario administre su restaurante
+func (es *EtiquetasPlatilloService) platilloEditable(idUsuario, idPlatillo uint) (*models.Platillo, error) {
+	platillo, err := es.dbManager.ObtenerPlatilloPorID(idPlatillo)
+	if err != nil {
+		return nil, err
+	}
+
+	usuario, err := es.dbManager.ObtenerUsuarioPorID(idUsuario)
+	if err != nil {
+		return nil, errors.New("usuario no encontrado")
+	}
+	if usuario.Activo && usuario.Rol == models.RolAdmin {
+		return platillo, nil
+	}
+
+	esPropietario, err := es.dbManager.EsPropietarioRestaurante(idUsuario, platillo.IDRestaurante)
+	if err != nil {
+		return nil, err
+	}
+	if !esPropietario {
+		return nil, errors.New("solo los propietarios del restaurante pueden editar las etiquetas de sus platillos")
+	}
+
+	return platillo, nil
+}
+
+// mismasEtiquetas indica si las etiquetas guardadas ya reflejan las inferidas: cada inferida esta
+// presente, como inferida o asignada por el propietario, y no sobra ninguna inferida
+func mismasEtiquetas(actuales []models.PlatilloEtiqueta, inferidas []string) bool {
+	esperadas := make(map[string]bool, len(inferidas))
+	for _, etiqueta := range inferidas {
+		esperadas[etiqueta] = true
+	}
+
+	presentes := make(map[string]bool, len(actuales))
+	for _, etiqueta := range actuales {
+		if etiqueta.Inferida && !esperadas[etiqueta.Etiqueta] {
+			return false
+		}
+		presentes[etiqueta.Etiqueta] = true
+	}
+	for etiqueta := range esperadas {
+		if !presentes[etiqueta] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+)
+
+// maxDiasExcepcionHorario limita el rango de una excepcion; para cierres mas largos se desactiva el restaurante
+const maxDiasExcepcionHorario = 366
+
+// ExcepcionesHorarioService administra los cierres y horarios especiales por fecha de los restaurantes
+type ExcepcionesHorarioService struct {
+	dbManager *repository.DBManager
+}
+
+// NewExcepcionesHorarioService crea una nueva instancia del servicio
+func NewExcepcionesHorarioService(dbManager *repository.DBManager) *ExcepcionesHorarioService {
+	return &ExcepcionesHorarioService{dbManager: dbManager}
+}
+
+// DatosExcepcionHorario son los campos que captura el propietario
+// Las fechas usan el formato 2006-01-02; FechaFin vacia equivale a un solo dia
+// Apertura y Cierre (15:04 o 15:04:05) se exigen si no es un cierre
+type DatosExcepcionHorario struct {
+	FechaInicio     string
+	FechaFin        string
+	Cerrado         bool
+	Apertura        string
+	Cierre          string
+	RecurrenteAnual bool
+	Motivo          string
+}
+
+// ObtenerExcepciones lista las excepciones del restaurante; por defecto solo las vigentes
+func (es *ExcepcionesHorarioService) ObtenerExcepciones(idRestaurante uint, incluirPasadas bool) ([]models.ExcepcionHorario, error) {
+	if _, err := es.dbManager.ObtenerRestaurantePorID(idRestaurante); err != nil {
+		return nil, errors.New("restaurante no encontrado")
+	}
+
+	return es.dbManager.ObtenerExcepcionesHorario(idRestaurante, !incluirPasadas)
+}
+
+// CrearExcepcion registra un cierre u horario especial; solo propietarios o administradores
+func (es *ExcepcionesHorarioService) CrearExcepcion(idUsuario, idRestaurante uint, datos DatosExcepcionHorario) (*models.ExcepcionHorario, error) {
+	if err := es.verificarPermiso(idUsuario, idRestaurante); err != nil {
+		return nil, err
+	}
+
+	excepcion := &models.ExcepcionHorario{IDRestaurante: idRestaurante}
+	if err := aplicarDatosExcepcion(excepcion, datos); err != nil {
+		return nil, err
+	}
+
+	if err := es.dbManager.CrearExcepcionHorario(excepcion); err != nil {
+		return nil, err
+	}
+
+	return excepcion, nil
+}
+
+// ActualizarExcepcion reemplaza los datos de una excepcion existente
+func (es *ExcepcionesHorarioService) ActualizarExcepcion(idUsuario, idRestaurante, idExcepcion uint, datos DatosExcepcionHorario) (*models.ExcepcionHorario, error) {
+	if err := es.verificarPermiso(idUsuario, idRestaurante); err != nil {
+		return nil, err
+	}
+
+	excepcion, err := es.dbManager.ObtenerExcepcionHorario(idRestaurante, idExcepcion)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := aplicarDatosExcepcion(excepcion, datos); err != nil {
+		return nil, err
+	}
+
+	if err := es.dbManager.ActualizarExcepcionHorario(excepcion); err != nil {
+		return nil, err
+	}
+
+	return excepcion, nil
+}
+
+// EliminarExcepcion borra una excepcion del restaurante
+func (es *ExcepcionesHorarioService) EliminarExcepcion(idUsuario, idRestaurante, idExcepcion uint) error {
+	if err := es.verificarPermiso(idUsuario, idRestaurante); err != nil {
+		return err
+	}
+
+	return es.dbManager.EliminarExcepcionHorario(idRestaurante, idExcepcion)
+}
+
+// verificarPermiso comprueba que el usuario sea propietario del restaurante o administrador
+func (es *ExcepcionesHorarioService) verificarPermiso(idUsuario, idRestaurante uint) error {
+	if _, err := es.dbManager.ObtenerRestaurantePorID(idRestaurante); err != nil {
+		return errors.New("restaurante no encontrado")
+	}
+
+	usuario, err := es.dbManager.ObtenerUsuarioPorID(idUsuario)
+	if err != nil {
+		return errors.New("usuario no encontrado")
+	}
+	if usuario.Activo && usuario.Rol == models.RolAdmin {
+		return nil
+	}
+
+	esPropietario, err := es.dbManager.EsPropietarioRestaurante(idUsuario, idRestaurante)
+	if err != nil {
+		return err
+	}
+	if !esPropietario {
+		return errors.New("solo los propietarios del restaurante pueden administrar sus horarios especiales")
+	}
+
+	return nil
+}
+
+// aplicarDatosExcepcion valida los datos capturados y los copia a la excepcion
+func aplicarDatosExcepcion(excepcion *models.ExcepcionHorario, datos DatosExcepcionHorario) error {
+	// Se interpretan en la zona de la conexion (loc=Local) para que MySQL guarde el mismo dia
+	inicio, err := time.ParseInLocation(repository.FormatoFecha, strings.TrimSpace(datos.FechaInicio), time.Local)
+	if err != nil {
+		return errors.New("fecha de inicio invalida, usa el formato AAAA-MM-DD")
+	}
+
+	fin := inicio
+	if strings.TrimSpace(datos.FechaFin) != "" {
+		fin, err = time.ParseInLocation(repository.FormatoFecha, strings.TrimSpace(datos.FechaFin), time.Local)
+		if err != nil {
+			return errors.New("fecha de fin invalida, usa el formato AAAA-MM-DD")
+		}
+	}
+	if fin.Before(inicio) {
+		return errors.New("la fecha de fin no puede ser anterior a la de inicio")
+	}
+	if !fin.Before(inicio.AddDate(0, 0, maxDiasExcepcionHorario)) {
+		return errors.New("una excepcion de horario no puede abarcar mas de un año")
+	}
+
+	motivo := strings.TrimSpace(datos.Motivo)
+	if len([]rune(motivo)) > 100 {
+		return errors.New("el motivo no puede exceder 100 caracteres")
+	}
+
+	var apertura, cierre *string
+	if !datos.Cerrado {
+		a, errApertura := normalizarHora(datos.Apertura)
+		c, errCierre := normalizarHora(datos.Cierre)
+		if errApertura != nil || errCierre != nil {
+			return errors.New("un horario especial requiere apertura y cierre validos, como 10:00 y 18:00")
+		}
+		apertura, cierre = &a, &c
+	}
+
+	excepcion.FechaInicio = inicio
+	excepcion.FechaFin = fin
+	excepcion.Cerrado = datos.Cerrado
+	excepcion.Apertura = apertura
+	excepcion.Cierre = cierre
+	excepcion.RecurrenteAnual = datos.RecurrenteAnual
+	excepcion.Motivo = motivo
+	return nil
+}
+
+// normalizarHora acepta 15:04 o 15:04:05 y retorna el formato de las columnas TIME
+func normalizarHora(texto string) (string, error) {
+	texto = strings.TrimSpace(texto)
+	for _, formato := range []string{"15:04", "15:04:05"} {
+		if hora, err := time.Parse(formato, texto); err == nil {
+			return hora.Format("15:04:05"), nil
+		}
+	}
+	return "", errors.New("hora invalida")
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/tuusuario/quovi/utils"
+)
+
+// FiltroContenido decide si un texto debe retenerse para moderacion antes de publicarse
+type FiltroContenido interface {
+	Evaluar(texto string) ResultadoFiltro
+}
+
+// ResultadoFiltro indica si el texto se retiene y por que
+type ResultadoFiltro struct {
+	Retener bool
+	Motivo  string
+}
+
+// PalabrasProhibidasPorDefecto es la lista base en español e ingles
+var PalabrasProhibidasPorDefecto = []string{
+	// Español
+	"pendejo", "pendeja", "pendejos", "cabron", "cabrona", "cabrones",
+	"chingar", "chingada", "chingado", "chinga", "verga", "mierda",
+	"puta", "puto", "putos", "culero", "culera", "mamon", "pinche",
+	"imbecil", "idiota", "estupido", "estupida", "naco", "joto",
+	// Ingles
+	"fuck", "fucking", "shit", "bitch", "asshole", "bastard",
+	"dick", "cunt", "motherfucker", "retard", "idiot",
+}
+
+// FiltroListaPalabras retiene textos que contienen alguna palabra de la lista
+type FiltroListaPalabras struct {
+	palabras map[string]bool
+}
+
+// NewFiltroListaPalabras crea un filtro normalizando acentos y mayusculas de la lista
+func NewFiltroListaPalabras(palabras []string) *FiltroListaPalabras {
+	mapa := make(map[string]bool, len(palabras))
+	for _, palabra := range palabras {
+		mapa[utils.NormalizarTexto(strings.TrimSpace(palabra))] = true
+	}
+	return &FiltroListaPalabras{palabras: mapa}
+}
+
+// Evaluar compara cada palabra del texto contra la lista
+func (f *FiltroListaPalabras) Evaluar(texto string) ResultadoFiltro {
+	for _, token := range utils.Tokenizar(texto) {
+		if f.palabras[token] {
+			return ResultadoFiltro{Retener: true, Motivo: "lenguaje inapropiado"}
+		}
+	}
+	return ResultadoFiltro{}
+}
+
+var enlaceRegex = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+// FiltroEnlaces retiene textos con URLs, tipicos de spam
+type FiltroEnlaces struct{}
+
+// Evaluar busca enlaces en el texto
+func (FiltroEnlaces) Evaluar(texto string) ResultadoFiltro {
+	if enlaceRegex.MatchString(texto) {
+		return ResultadoFiltro{Retener: true, Motivo: "contiene enlaces"}
+	}
+	return ResultadoFiltro{}
+}
+
+// FiltrosCombinados aplica varios filtros y retiene con el primero que lo indique
+type FiltrosCombinados []FiltroContenido
+
+// Evaluar ejecuta los filtros en orden
+func (fc FiltrosCombinados) Evaluar(texto string) ResultadoFiltro {
+	for _, filtro := range fc {
+		if resultado := filtro.Evaluar(texto); resultado.Retener {
+			return resultado
+		}
+	}
+	return ResultadoFiltro{}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+)
+
+const (
+	// longitudMaxTextoBusqueda acota el texto guardado para no almacenar consultas enormes
+	longitudMaxTextoBusqueda = 200
+
+	// diasBusquedasPopulares es la ventana de tiempo de las busquedas populares
+	diasBusquedasPopulares = 7
+
+	limiteBusquedasPorDefecto = 10
+	limiteBusquedasMaximo     = 50
+)
+
+// HistorialBusquedaService registra las busquedas y expone el historial y las tendencias
+type HistorialBusquedaService struct {
+	dbManager *repository.DBManager
+}
+
+// NewHistorialBusquedaService crea una nueva instancia del servicio de historial
+func NewHistorialBusquedaService(dbManager *repository.DBManager) *HistorialBusquedaService {
+	return &HistorialBusquedaService{dbManager: dbManager}
+}
+
+// RegistrarBusqueda guarda la busqueda en segundo plano para no retrasar la respuesta
+// idUsuario es nil cuando la busqueda es anonima
+func (hs *HistorialBusquedaService) RegistrarBusqueda(idUsuario *uint, texto, contexto string, idCiudad *uint, resultados int) {
+	busqueda := models.Busqueda{
+		IDUsuario:             idUsuario,
+		Texto:                 normalizarTextoBusqueda(texto),
+		Contexto:              contexto,
+		IDCiudad:              idCiudad,
+		ResultadosEncontrados: resultados,
+		Fecha:                 time.Now(),
+	}
+
+	go func() {
+		if err := hs.dbManager.CrearBusqueda(&busqueda); err != nil {
+			log.Printf("Error al registrar busqueda: %v", err)
+		}
+	}()
+}
+
+// ObtenerBusquedasRecientes lista las ultimas busquedas distintas del usuario
+func (hs *HistorialBusquedaService) ObtenerBusquedasRecientes(idUsuario uint, limite int) ([]models.Busqueda, error) {
+	return hs.dbManager.ObtenerBusquedasRecientes(idUsuario, normalizarLimiteBusquedas(limite))
+}
+
+// EliminarBusquedaReciente quita un texto del historial del usuario
+func (hs *HistorialBusquedaService) EliminarBusquedaReciente(idUsuario, idBusqueda uint) error {
+	_, err := hs.dbManager.EliminarBusqueda(idUsuario, idBusqueda)
+	return err
+}
+
+// LimpiarBusquedasRecientes borra el historial del usuario y retorna cuantos registros se eliminaron
+func (hs *HistorialBusquedaService) LimpiarBusquedasRecientes(idUsuario uint) (int64, error) {
+	return hs.dbManager.EliminarHistorialBusquedas(idUsuario)
+}
+
+// ObtenerBusquedasPopulares lista los textos mas buscados en los ultimos dias, opcionalmente por ciudad
+func (hs *HistorialBusquedaService) ObtenerBusquedasPopulares(idCiudad *uint, limite int) ([]repository.BusquedaPopular, error) {
+	desde := time.Now().AddDate(0, 0, -diasBusquedasPopulares)
+	return hs.dbManager.ObtenerBusquedasPopulares(desde, idCiudad, 0, normalizarLimiteBusquedas(limite))
+}
+
+// normalizarTextoBusqueda colapsa espacios y recorta el texto a la longitud maxima
+func normalizarTextoBusqueda(texto string) string {
+	texto = strings.Join(strings.Fields(texto), " ")
+	if utf8.RuneCountInString(texto) > longitudMaxTextoBusqueda {
+		texto = string([]rune(texto)[:longitudMaxTextoBusqueda])
+	}
+	return texto
+}
+
+func normalizarLimiteBusquedas(limite int) int {
+	if limite <= 0 {
+		return limiteBusquedasPorDefecto
+	}
+	if limite > limiteBusquedasMaximo {
+		return limiteBusquedasMaximo
+	}
+	return limite
+}
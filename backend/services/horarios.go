@@ -0,0 +1,223 @@
+package services
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+	"github.com/tuusuario/quovi/utils"
+)
+
+// verificarHorario determina si un restaurante esta abierto en la hora local de su ciudad
+// y describe los intervalos de hoy, por ejemplo "13:00:00 - 17:00:00, 19:00:00 - 02:00:00"
+// Las excepciones de la fecha, como festivos, reemplazan al horario semanal
+func verificarHorario(horarios []models.Horario, excepciones []models.ExcepcionHorario, zonaHoraria string) (bool, string) {
+	return estadoHorario(horarios, excepciones, time.Now().In(utils.CargarZonaHoraria(zonaHoraria)))
+}
+
+// intervaloHorario es un periodo de atencion que abre en un dia concreto
+type intervaloHorario struct {
+	Apertura string
+	Cierre   string
+}
+
+// cruzaMedianoche indica si el intervalo termina al dia siguiente; apertura igual a cierre son 24 horas
+func (i intervaloHorario) cruzaMedianoche() bool {
+	return i.Cierre <= i.Apertura
+}
+
+// horarioDelDia resume como atiende un restaurante en una fecha
+type horarioDelDia struct {
+	Intervalos []intervaloHorario
+	Especial   bool   // Lo definen excepciones y no el horario semanal
+	Motivo     string // Motivo de la excepcion, si tiene
+}
+
+// estadoHorario evalua los horarios en un momento ya expresado en la hora local del restaurante
+func estadoHorario(horarios []models.Horario, excepciones []models.ExcepcionHorario, local time.Time) (bool, string) {
+	if len(horarios) == 0 && len(excepciones) == 0 {
+		return false, ""
+	}
+
+	hora := local.Format("15:04:05")
+	hoy := obtenerHorarioDelDia(horarios, excepciones, local)
+	ayer := obtenerHorarioDelDia(horarios, excepciones, local.AddDate(0, 0, -1))
+
+	estaAbierto := false
+	for _, intervalo := range hoy.Intervalos {
+		if intervalo.Apertura <= hora && (intervalo.cruzaMedianoche() || hora < intervalo.Cierre) {
+			estaAbierto = true
+		}
+	}
+	// Intervalo de ayer que sigue abierto despues de la medianoche
+	var madrugada *intervaloHorario
+	for i, intervalo := range ayer.Intervalos {
+		if intervalo.cruzaMedianoche() && hora < intervalo.Cierre {
+			estaAbierto = true
+			madrugada = &ayer.Intervalos[i]
+		}
+	}
+
+	if len(hoy.Intervalos) == 0 {
+		if madrugada != nil {
+			return true, "Abierto hasta " + madrugada.Cierre
+		}
+		if hoy.Motivo != "" {
+			return false, "Cerrado hoy: " + hoy.Motivo
+		}
+		return false, "Cerrado hoy"
+	}
+
+	textos := make([]string, 0, len(hoy.Intervalos))
+	for _, intervalo := range hoy.Intervalos {
+		textos = append(textos, intervalo.Apertura+" - "+intervalo.Cierre)
+	}
+	texto := strings.Join(textos, ", ")
+	if hoy.Especial {
+		motivo := hoy.Motivo
+		if motivo == "" {
+			motivo = "horario especial"
+		}
+		texto += " (" + motivo + ")"
+	}
+
+	return estaAbierto, texto
+}
+
+// periodoAtencion es un intervalo de atencion en hora absoluta
+type periodoAtencion struct {
+	Inicio time.Time
+	Fin    time.Time
+}
+
+// periodosAtencion lista cuando atiende el restaurante entre desde y hasta segun la hora local de su ciudad,
+// incluidas las excepciones. Los periodos contiguos, como una madrugada que enlaza con el dia siguiente, se unen
+func periodosAtencion(horarios []models.Horario, excepciones []models.ExcepcionHorario, zonaHoraria string, desde, hasta time.Time) []periodoAtencion {
+	zona := utils.CargarZonaHoraria(zonaHoraria)
+	inicioLocal := desde.In(zona)
+
+	var periodos []periodoAtencion
+	// Se empieza un dia antes por los intervalos que abren ayer y terminan despues de la medianoche
+	for d := -1; ; d++ {
+		fecha := time.Date(inicioLocal.Year(), inicioLocal.Month(), inicioLocal.Day()+d, 0, 0, 0, 0, zona)
+		if !fecha.Before(hasta) {
+			break
+		}
+
+		for _, intervalo := range obtenerHorarioDelDia(horarios, excepciones, fecha).Intervalos {
+			fechaCierre := fecha
+			if intervalo.cruzaMedianoche() {
+				fechaCierre = fecha.AddDate(0, 0, 1)
+			}
+			apertura, errApertura := horaEnFecha(fecha, intervalo.Apertura)
+			cierre, errCierre := horaEnFecha(fechaCierre, intervalo.Cierre)
+			if errApertura != nil || errCierre != nil {
+				continue
+			}
+			if cierre.After(desde) && apertura.Before(hasta) {
+				periodos = append(periodos, periodoAtencion{Inicio: apertura, Fin: cierre})
+			}
+		}
+	}
+
+	sort.Slice(periodos, func(i, j int) bool { return periodos[i].Inicio.Before(periodos[j].Inicio) })
+	unidos := make([]periodoAtencion, 0, len(periodos))
+	for _, periodo := range periodos {
+		if ultimo := len(unidos) - 1; ultimo >= 0 && !periodo.Inicio.After(unidos[ultimo].Fin) {
+			if periodo.Fin.After(unidos[ultimo].Fin) {
+				unidos[ultimo].Fin = periodo.Fin
+			}
+			continue
+		}
+		unidos = append(unidos, periodo)
+	}
+
+	return unidos
+}
+
+// horaEnFecha combina una fecha local con una hora de las columnas TIME, como 13:30:00
+func horaEnFecha(fecha time.Time, hora string) (time.Time, error) {
+	normalizada, err := normalizarHora(hora)
+	if err != nil {
+		return time.Time{}, err
+	}
+	h, _ := time.Parse("15:04:05", normalizada)
+	return time.Date(fecha.Year(), fecha.Month(), fecha.Day(), h.Hour(), h.Minute(), h.Second(), 0, fecha.Location()), nil
+}
+
+// obtenerHorarioDelDia retorna los intervalos que abren en la fecha local, ordenados por apertura
+// Si alguna excepcion aplica ese dia reemplaza al horario semanal; una de cierre cierra todo el dia
+func obtenerHorarioDelDia(horarios []models.Horario, excepciones []models.ExcepcionHorario, fecha time.Time) horarioDelDia {
+	var dia horarioDelDia
+
+	cerrado := false
+	for _, excepcion := range excepciones {
+		if !ExcepcionAplicaEnFecha(excepcion, fecha) {
+			continue
+		}
+		dia.Especial = true
+		if dia.Motivo == "" || excepcion.Cerrado {
+			dia.Motivo = excepcion.Motivo
+		}
+		if excepcion.Cerrado {
+			cerrado = true
+			continue
+		}
+		if excepcion.Apertura != nil && excepcion.Cierre != nil {
+			dia.Intervalos = append(dia.Intervalos, intervaloHorario{Apertura: *excepcion.Apertura, Cierre: *excepcion.Cierre})
+		}
+	}
+
+	if cerrado {
+		dia.Intervalos = nil
+		return dia
+	}
+
+	if !dia.Especial {
+		diaSemana := repository.DiaSemana(fecha)
+		for _, horario := range horarios {
+			if int(horario.Dia) == diaSemana && !horario.Cerrado {
+				dia.Intervalos = append(dia.Intervalos, intervaloHorario{Apertura: horario.Apertura, Cierre: horario.Cierre})
+			}
+		}
+	}
+
+	sort.Slice(dia.Intervalos, func(i, j int) bool { return dia.Intervalos[i].Apertura < dia.Intervalos[j].Apertura })
+	return dia
+}
+
+// ExcepcionAplicaEnFecha indica si la excepcion cubre la fecha local; las recurrentes ignoran el año
+// y su rango puede cruzar el fin de año, como del 24 de diciembre al 1 de enero
+func ExcepcionAplicaEnFecha(excepcion models.ExcepcionHorario, fecha time.Time) bool {
+	if !excepcion.RecurrenteAnual {
+		dia := claveFecha(fecha)
+		return claveFecha(excepcion.FechaInicio) <= dia && dia <= claveFecha(excepcion.FechaFin)
+	}
+
+	mesDia := repository.MesDia(fecha)
+	inicio, fin := repository.MesDia(excepcion.FechaInicio), repository.MesDia(excepcion.FechaFin)
+	if inicio <= fin {
+		return inicio <= mesDia && mesDia <= fin
+	}
+	return mesDia >= inicio || mesDia <= fin
+}
+
+// claveFecha compara fechas de calendario sin importar la hora ni la zona, como 20260916
+func claveFecha(fecha time.Time) int {
+	return fecha.Year()*10000 + repository.MesDia(fecha)
+}
+
+// filtrarAbiertoAhora restringe el filtro a restaurantes abiertos ahora en la hora local de su ciudad
+func filtrarAbiertoAhora(dbManager *repository.DBManager, filtro *repository.FiltroBusquedaRestaurantes) error {
+	zonas, err := dbManager.ObtenerZonasHorarias()
+	if err != nil {
+		return err
+	}
+
+	ahora := time.Now()
+	filtro.AbiertoEn = &ahora
+	filtro.ZonasHorarias = zonas
+	return nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+)
+
+// horariosSemana atiende en turno partido y de madrugada el lunes, descansa el martes, abre las
+// 24 horas el miercoles y cruza la medianoche el viernes y el sabado
+var horariosSemana = []models.Horario{
+	{Dia: 1, Apertura: "19:00:00", Cierre: "02:00:00"},
+	{Dia: 1, Apertura: "13:00:00", Cierre: "17:00:00"},
+	{Dia: 3, Apertura: "00:00:00", Cierre: "00:00:00"},
+	{Dia: 4, Apertura: "09:00:00", Cierre: "12:00:00"},
+	{Dia: 4, Apertura: "16:00:00", Cierre: "18:00:00"},
+	{Dia: 5, Apertura: "20:00:00", Cierre: "04:00:00"},
+	{Dia: 6, Apertura: "22:00:00", Cierre: "03:00:00"},
+	{Dia: 7, Apertura: "10:00:00", Cierre: "14:00:00", Cerrado: true},
+}
+
+// momentoSemana es la hora local de un dia de la semana del 14 de septiembre de 2026, que es lunes
+func momentoSemana(dia, hora, minuto int) time.Time {
+	return time.Date(2026, time.September, 13+dia, hora, minuto, 0, 0, time.UTC)
+}
+
+func textoHorario(texto string) *string {
+	return &texto
+}
+
+func TestEstadoHorario(t *testing.T) {
+	lunes := time.Date(2026, time.September, 14, 0, 0, 0, 0, time.UTC)
+	martes := lunes.AddDate(0, 0, 1)
+	cierreLunes := []models.ExcepcionHorario{{FechaInicio: lunes, FechaFin: lunes, Cerrado: true, Motivo: "Inventario"}}
+	eventoMartes := []models.ExcepcionHorario{{
+		FechaInicio: martes, FechaFin: martes, Apertura: textoHorario("18:00:00"), Cierre: textoHorario("01:00:00"), Motivo: "Evento",
+	}}
+	vacaciones := []models.ExcepcionHorario{{
+		FechaInicio:     time.Date(2020, time.December, 24, 0, 0, 0, 0, time.UTC),
+		FechaFin:        time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Cerrado:         true,
+		RecurrenteAnual: true,
+		Motivo:          "Vacaciones",
+	}}
+	textoLunes := "13:00:00 - 17:00:00, 19:00:00 - 02:00:00"
+	textoJueves := "09:00:00 - 12:00:00, 16:00:00 - 18:00:00"
+
+	casos := []struct {
+		nombre      string
+		horarios    []models.Horario
+		excepciones []models.ExcepcionHorario
+		momento     time.Time
+		abierto     bool
+		texto       string
+	}{
+		{nombre: "antes del primer turno", momento: momentoSemana(1, 12, 59), texto: textoLunes},
+		{nombre: "al abrir el primer turno", momento: momentoSemana(1, 13, 0), abierto: true, texto: textoLunes},
+		{nombre: "al cerrar el primer turno", momento: momentoSemana(1, 17, 0), texto: textoLunes},
+		{nombre: "entre turnos", momento: momentoSemana(1, 18, 30), texto: textoLunes},
+		{nombre: "segundo turno antes de medianoche", momento: momentoSemana(1, 23, 59), abierto: true, texto: textoLunes},
+		{nombre: "madrugada de un dia sin horario", momento: momentoSemana(2, 1, 59), abierto: true, texto: "Abierto hasta 02:00:00"},
+		{nombre: "al cerrar la madrugada", momento: momentoSemana(2, 2, 0), texto: "Cerrado hoy"},
+		{nombre: "24 horas al iniciar el dia", momento: momentoSemana(3, 0, 0), abierto: true, texto: "00:00:00 - 00:00:00"},
+		{nombre: "24 horas al final del dia", momento: momentoSemana(3, 23, 59), abierto: true, texto: "00:00:00 - 00:00:00"},
+		{nombre: "las 24 horas terminan a medianoche", momento: momentoSemana(4, 0, 0), texto: textoJueves},
+		{nombre: "madrugada del viernes con horario el sabado", momento: momentoSemana(6, 3, 30), abierto: true, texto: "22:00:00 - 03:00:00"},
+		{nombre: "sabado entre la madrugada y la apertura", momento: momentoSemana(6, 12, 0), texto: "22:00:00 - 03:00:00"},
+		{nombre: "madrugada del sabado en un dia cerrado", momento: momentoSemana(7, 2, 0), abierto: true, texto: "Abierto hasta 03:00:00"},
+		{nombre: "dia marcado como cerrado", momento: momentoSemana(7, 11, 0), texto: "Cerrado hoy"},
+		{nombre: "sin horarios", horarios: []models.Horario{}, momento: momentoSemana(1, 14, 0), texto: ""},
+		{nombre: "excepcion de cierre con motivo", excepciones: cierreLunes, momento: momentoSemana(1, 14, 0), texto: "Cerrado hoy: Inventario"},
+		{nombre: "el cierre quita la madrugada siguiente", excepciones: cierreLunes, momento: momentoSemana(2, 1, 0), texto: "Cerrado hoy"},
+		{nombre: "horario especial en un dia de descanso", excepciones: eventoMartes, momento: momentoSemana(2, 19, 0), abierto: true, texto: "18:00:00 - 01:00:00 (Evento)"},
+		{nombre: "horario especial con madrugada anterior", excepciones: eventoMartes, momento: momentoSemana(2, 1, 0), abierto: true, texto: "18:00:00 - 01:00:00 (Evento)"},
+		{nombre: "madrugada del horario especial", excepciones: eventoMartes, momento: momentoSemana(3, 0, 30), abierto: true, texto: "00:00:00 - 00:00:00"},
+		{nombre: "excepcion anual que cruza el fin de año", excepciones: vacaciones, momento: time.Date(2026, time.December, 31, 10, 0, 0, 0, time.UTC), texto: "Cerrado hoy: Vacaciones"},
+		{nombre: "excepcion anual ya terminada", excepciones: vacaciones, momento: time.Date(2027, time.January, 4, 14, 0, 0, 0, time.UTC), abierto: true, texto: textoLunes},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			horarios := caso.horarios
+			if horarios == nil {
+				horarios = horariosSemana
+			}
+			abierto, texto := estadoHorario(horarios, caso.excepciones, caso.momento)
+			if abierto != caso.abierto || texto != caso.texto {
+				t.Fatalf("se obtuvo (%v, %q), se esperaba (%v, %q)", abierto, texto, caso.abierto, caso.texto)
+			}
+		})
+	}
+}
+
+// TestEstadoHorarioCoincideConPeriodos recorre la semana minuto a minuto y compara si esta abierto
+// con los periodos de atencion, que se calculan en hora absoluta
+func TestEstadoHorarioCoincideConPeriodos(t *testing.T) {
+	martes := time.Date(2026, time.September, 15, 0, 0, 0, 0, time.UTC)
+	casos := []struct {
+		nombre      string
+		excepciones []models.ExcepcionHorario
+	}{
+		{nombre: "horario semanal"},
+		{nombre: "con horario especial", excepciones: []models.ExcepcionHorario{{
+			FechaInicio: martes, FechaFin: martes, Apertura: textoHorario("18:00:00"), Cierre: textoHorario("01:00:00"),
+		}}},
+		{nombre: "con cierre", excepciones: []models.ExcepcionHorario{{FechaInicio: martes.AddDate(0, 0, 3), FechaFin: martes.AddDate(0, 0, 3), Cerrado: true}}},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			inicio := momentoSemana(1, 0, 0)
+			for momento := inicio; momento.Before(inicio.AddDate(0, 0, 8)); momento = momento.Add(time.Minute) {
+				esperado := false
+				for _, periodo := range periodosAtencion(horariosSemana, caso.excepciones, "UTC", momento, momento.Add(time.Second)) {
+					esperado = esperado || !momento.Before(periodo.Inicio) && momento.Before(periodo.Fin)
+				}
+				if abierto, _ := estadoHorario(horariosSemana, caso.excepciones, momento); abierto != esperado {
+					t.Fatalf("%s: abierto = %v, los periodos dicen %v", momento.Format(time.RFC3339), abierto, esperado)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/utils"
+)
+
+// AgregarImagenesReseña guarda las fotos que el autor adjunta a su reseña
+// Se valida el lote completo antes de escribir en disco para no dejar archivos huerfanos
+func (rs *ReseñaService) AgregarImagenesReseña(idUsuario, idReseña uint, archivos []*multipart.FileHeader) ([]models.ImagenReseña, error) {
+	if len(archivos) == 0 {
+		return nil, errors.New("no se recibio ninguna imagen")
+	}
+
+	reseña, err := rs.dbManager.ObtenerReseñaPorID(idReseña)
+	if err != nil {
+		return nil, err
+	}
+
+	if reseña.IDUsuario != idUsuario {
+		return nil, errors.New("solo el autor puede adjuntar fotos a la reseña")
+	}
+
+	if reseña.Estado == models.EstadoReseñaEliminada {
+		return nil, errors.New("esta reseña fue eliminada por moderacion y no puede editarse")
+	}
+
+	// Descarta pronto los lotes que no caben; el limite se vuelve a verificar al insertar
+	existentes, err := rs.dbManager.ContarImagenesReseña(idReseña)
+	if err != nil {
+		return nil, err
+	}
+
+	if int(existentes)+len(archivos) > rs.config.MaxFotosPorReseña {
+		return nil, fmt.Errorf("una reseña admite como maximo %d fotos", rs.config.MaxFotosPorReseña)
+	}
+
+	for _, archivo := range archivos {
+		if _, err := utils.ValidarImagen(archivo); err != nil {
+			return nil, err
+		}
+	}
+
+	imagenes := make([]models.ImagenReseña, 0, len(archivos))
+	for _, archivo := range archivos {
+		url, err := utils.GuardarImagenEn(archivo, utils.UploadDirResenas, fmt.Sprintf("resena_%d", idReseña))
+		if err != nil {
+			eliminarArchivosImagenes(imagenes)
+			return nil, err
+		}
+
+		imagenes = append(imagenes, models.ImagenReseña{
+			IDReseña:    idReseña,
+			IDUsuario:   idUsuario,
+			URL:         url,
+			FechaSubida: time.Now(),
+		})
+	}
+
+	if err := rs.dbManager.CrearImagenesReseña(idReseña, imagenes, rs.config.MaxFotosPorReseña); err != nil {
+		eliminarArchivosImagenes(imagenes)
+		return nil, err
+	}
+
+	return imagenes, nil
+}
+
+// EliminarImagenReseña quita una foto de la reseña del usuario
+// Si la foto ya fue promovida a la galeria, el archivo se conserva
+func (rs *ReseñaService) EliminarImagenReseña(idUsuario, idReseña, idImagen uint) error {
+	imagen, err := rs.dbManager.ObtenerImagenReseña(idReseña, idImagen)
+	if err != nil {
+		return err
+	}
+
+	if imagen.IDUsuario != idUsuario {
+		return errors.New("solo el autor puede eliminar fotos de la reseña")
+	}
+
+	if err := rs.dbManager.EliminarImagenReseña(idImagen); err != nil {
+		return err
+	}
+
+	if !imagen.Promovida {
+		utils.EliminarImagen(imagen.URL)
+	}
+
+	return nil
+}
+
+// AprobarImagenReseña suma la aprobacion de un usuario a una foto y la promueve
+// a la galeria del restaurante cuando alcanza el umbral configurado
+func (rs *ReseñaService) AprobarImagenReseña(idUsuario, idReseña, idImagen uint) (*models.ImagenReseña, *models.ImagenRestaurante, error) {
+	reseña, err := rs.dbManager.ObtenerReseñaPorID(idReseña)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if reseña.Estado != models.EstadoReseñaPublicada {
+		return nil, nil, errors.New("la reseña no esta publicada")
+	}
+
+	imagen, err := rs.dbManager.ObtenerImagenReseña(idReseña, idImagen)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if imagen.IDUsuario == idUsuario {
+		return nil, nil, errors.New("no puedes aprobar tu propia foto")
+	}
+
+	imagen, err = rs.dbManager.AprobarImagenReseña(idImagen, idUsuario)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	umbral := rs.config.AprobacionesParaPromover
+	if umbral <= 0 || imagen.Promovida || imagen.Aprobaciones < umbral {
+		return imagen, nil, nil
+	}
+
+	galeria, err := rs.dbManager.PromoverImagenReseña(idImagen)
+	if err != nil {
+		// La aprobacion ya quedo registrada; la promocion se reintenta con la siguiente
+		return imagen, nil, nil
+	}
+
+	imagen.Promovida = true
+	return imagen, galeria, nil
+}
+
+// eliminarArchivosImagenes borra del disco las fotos ya guardadas de un lote fallido
+func eliminarArchivosImagenes(imagenes []models.ImagenReseña) {
+	for _, imagen := range imagenes {
+		utils.EliminarImagen(imagen.URL)
+	}
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tuusuario/quovi/algorithms"
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+)
+
+// Pesos de cada campo en la relevancia del indice de restaurantes
+const (
+	pesoNombreRestaurante = 3.0
+	pesoCategoria         = 2.0
+	pesoPlatillo          = 1.5
+	pesoDescripcion       = 1.0
+)
+
+// Pesos de cada campo en la relevancia del indice de platillos
+const (
+	pesoNombrePlatillo      = 3.0
+	pesoIngredientes        = 1.5
+	pesoDescripcionPlatillo = 1.0
+)
+
+// margenSincronizacion cubre diferencias de reloj entre la aplicacion y la base de datos;
+// reindexar de mas un restaurante no tiene efecto, perder un cambio si
+const margenSincronizacion = 30 * time.Second
+
+// IndiceRestaurantes mantiene en memoria los indices de restaurantes y de platillos
+// sincronizados con el catalogo
+type IndiceRestaurantes struct {
+	dbManager *repository.DBManager
+	indice    *algorithms.IndiceBusqueda
+	platillos *algorithms.IndiceBusqueda
+
+	mu                      sync.Mutex // Serializa reconstrucciones y refrescos
+	ultimaSincronizacion    time.Time
+	platillosPorRestaurante map[uint][]uint // Para retirar platillos que dejaron de existir
+	listo                   atomic.Bool
+	alCambiar               []func()
+}
+
+// NewIndiceRestaurantes crea el indice vacio; debe llamarse Reconstruir antes de usarlo
+func NewIndiceRestaurantes(dbManager *repository.DBManager) *IndiceRestaurantes {
+	return &IndiceRestaurantes{
+		dbManager:               dbManager,
+		indice:                  algorithms.NewIndiceBusqueda(),
+		platillos:               algorithms.NewIndiceBusqueda(),
+		platillosPorRestaurante: make(map[uint][]uint),
+	}
+}
+
+// Listo indica si el indice ya se construyo al menos una vez
+func (ir *IndiceRestaurantes) Listo() bool {
+	return ir.listo.Load()
+}
+
+// Total retorna el numero de restaurantes indexados
+func (ir *IndiceRestaurantes) Total() int {
+	return ir.indice.Total()
+}
+
+// AlCambiar registra una funcion que se llama cada vez que el indice detecta cambios en el catalogo
+// Se ejecuta con el indice bloqueado, por lo que debe ser breve
+func (ir *IndiceRestaurantes) AlCambiar(fn func()) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	ir.alCambiar = append(ir.alCambiar, fn)
+}
+
+// Buscar retorna los restaurantes que coinciden con el termino, ordenados por relevancia
+func (ir *IndiceRestaurantes) Buscar(termino string, limite int) []algorithms.ResultadoIndice {
+	return ir.indice.Buscar(termino, limite)
+}
+
+// BuscarPlatillos retorna los platillos que coinciden con el termino, ordenados por relevancia
+func (ir *IndiceRestaurantes) BuscarPlatillos(termino string, limite int) []algorithms.ResultadoIndice {
+	return ir.platillos.Buscar(termino, limite)
+}
+
+// Reconstruir carga todos los restaurantes activos y reemplaza el indice completo
+func (ir *IndiceRestaurantes) Reconstruir() error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	inicio := time.Now()
+
+	restaurantes, err := ir.dbManager.ObtenerRestaurantesParaIndice(nil)
+	if err != nil {
+		return err
+	}
+
+	docs := make([]algorithms.DocumentoBusqueda, 0, len(restaurantes))
+	var docsPlatillos []algorithms.DocumentoBusqueda
+	platillosPorRestaurante := make(map[uint][]uint, len(restaurantes))
+	for _, rest := range restaurantes {
+		docs = append(docs, documentoRestaurante(rest))
+		for _, platillo := range rest.Platillos {
+			docsPlatillos = append(docsPlatillos, documentoPlatillo(platillo))
+			platillosPorRestaurante[rest.IDRestaurante] = append(platillosPorRestaurante[rest.IDRestaurante], platillo.IDPlatillo)
+		}
+	}
+
+	ir.indice.Reemplazar(docs)
+	ir.platillos.Reemplazar(docsPlatillos)
+	ir.platillosPorRestaurante = platillosPorRestaurante
+	ir.ultimaSincronizacion = inicio
+	ir.listo.Store(true)
+	ir.notificarCambio()
+
+	return nil
+}
+
+// Refrescar reindexa solo los restaurantes modificados desde la ultima sincronizacion
+func (ir *IndiceRestaurantes) Refrescar() error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	inicio := time.Now()
+
+	ids, err := ir.dbManager.ObtenerRestaurantesModificadosDesde(ir.ultimaSincronizacion.Add(-margenSincronizacion))
+	if err != nil {
+		return err
+	}
+
+	if err := ir.refrescarIDs(ids); err != nil {
+		return err
+	}
+
+	ir.ultimaSincronizacion = inicio
+	if len(ids) > 0 {
+		ir.notificarCambio()
+	}
+	return nil
+}
+
+// RefrescarRestaurante reindexa un restaurante inmediatamente, por ejemplo tras editarlo
+func (ir *IndiceRestaurantes) RefrescarRestaurante(idRestaurante uint) error {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	if err := ir.refrescarIDs([]uint{idRestaurante}); err != nil {
+		return err
+	}
+
+	ir.notificarCambio()
+	return nil
+}
+
+// IniciarRefresco lanza en segundo plano el refresco incremental y la reconstruccion periodica
+// La reconstruccion completa detecta cambios que no actualizan fechas, como borrados o categorias
+func (ir *IndiceRestaurantes) IniciarRefresco(intervalo, intervaloReconstruccion time.Duration) {
+	go func() {
+		refresco := time.NewTicker(intervalo)
+		reconstruccion := time.NewTicker(intervaloReconstruccion)
+		defer refresco.Stop()
+		defer reconstruccion.Stop()
+
+		for {
+			select {
+			case <-refresco.C:
+				// Si la construccion inicial fallo se reintenta completa
+				actualizar := ir.Refrescar
+				if !ir.Listo() {
+					actualizar = ir.Reconstruir
+				}
+				if err := actualizar(); err != nil {
+					log.Printf("Error al refrescar indice de busqueda: %v", err)
+				}
+			case <-reconstruccion.C:
+				if err := ir.Reconstruir(); err != nil {
+					log.Printf("Error al reconstruir indice de busqueda: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// refrescarIDs indexa los restaurantes activos y retira los inactivos o eliminados
+func (ir *IndiceRestaurantes) refrescarIDs(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	restaurantes, err := ir.dbManager.ObtenerRestaurantesParaIndice(ids)
+	if err != nil {
+		return err
+	}
+
+	encontrados := make(map[uint]bool, len(restaurantes))
+	for _, rest := range restaurantes {
+		encontrados[rest.IDRestaurante] = true
+		ir.retirarPlatillos(rest.IDRestaurante)
+
+		if !rest.Activo {
+			ir.indice.Eliminar(rest.IDRestaurante)
+			continue
+		}
+
+		ir.indice.Indexar(documentoRestaurante(rest))
+		for _, platillo := range rest.Platillos {
+			ir.platillos.Indexar(documentoPlatillo(platillo))
+			ir.platillosPorRestaurante[rest.IDRestaurante] = append(ir.platillosPorRestaurante[rest.IDRestaurante], platillo.IDPlatillo)
+		}
+	}
+
+	for _, id := range ids {
+		if !encontrados[id] {
+			ir.indice.Eliminar(id)
+			ir.retirarPlatillos(id)
+		}
+	}
+
+	return nil
+}
+
+func (ir *IndiceRestaurantes) notificarCambio() {
+	for _, fn := range ir.alCambiar {
+		fn()
+	}
+}
+
+// retirarPlatillos elimina del indice los platillos registrados de un restaurante
+func (ir *IndiceRestaurantes) retirarPlatillos(idRestaurante uint) {
+	for _, idPlatillo := range ir.platillosPorRestaurante[idRestaurante] {
+		ir.platillos.Eliminar(idPlatillo)
+	}
+	delete(ir.platillosPorRestaurante, idRestaurante)
+}
+
+// documentoRestaurante convierte un restaurante en documento indexable
+func documentoRestaurante(rest models.Restaurante) algorithms.DocumentoBusqueda {
+	categorias := make([]string, 0, len(rest.Categorias))
+	for _, categoria := range rest.Categorias {
+		categorias = append(categorias, categoria.NombreCategoria)
+	}
+
+	platillos := make([]string, 0, len(rest.Platillos))
+	for _, platillo := range rest.Platillos {
+		platillos = append(platillos, platillo.Nombre)
+	}
+
+	return algorithms.DocumentoBusqueda{
+		ID: rest.IDRestaurante,
+		Campos: []algorithms.CampoDocumento{
+			{Texto: rest.Nombre, Peso: pesoNombreRestaurante},
+			{Texto: strings.Join(categorias, " "), Peso: pesoCategoria},
+			{Texto: strings.Join(platillos, " "), Peso: pesoPlatillo},
+			{Texto: rest.Descripcion, Peso: pesoDescripcion},
+		},
+	}
+}
+
+// documentoPlatillo convierte un platillo en documento indexable
+func documentoPlatillo(platillo models.Platillo) algorithms.DocumentoBusqueda {
+	return algorithms.DocumentoBusqueda{
+		ID: platillo.IDPlatillo,
+		Campos: []algorithms.CampoDocumento{
+			{Texto: platillo.Nombre, Peso: pesoNombrePlatillo},
+			{Texto: platillo.Ingredientes, Peso: pesoIngredientes},
+			{Texto: platillo.Descripcion, Peso: pesoDescripcionPlatillo},
+		},
+	}
+}
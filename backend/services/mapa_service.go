@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"math"
+
+	"github.com/tuusuario/quovi/repository"
+)
+
+// Tipos de respuesta del mapa segun el zoom
+const (
+	TipoMapaGrupos       = "grupos"
+	TipoMapaRestaurantes = "restaurantes"
+)
+
+const (
+	ZoomMapaMinimo = 0
+	ZoomMapaMaximo = 22
+
+	// zoomRestaurantesIndividuales es el zoom desde el que se devuelven restaurantes en lugar de grupos
+	zoomRestaurantesIndividuales = 15
+
+	// limitePuntosMapa es cuantos restaurantes individuales se devuelven como maximo;
+	// si el area tiene mas se agrupan aunque el zoom sea alto
+	limitePuntosMapa = 300
+
+	// celdasPorTesela divide cada tesela de 256px del mapa en celdas de 64px para agrupar
+	celdasPorTesela = 4
+
+	// maxGruposMapa es cuantas celdas puede cubrir el area visible; si el area es grande
+	// para el zoom las celdas crecen hasta que caben, asi los grupos nunca pasan de este numero
+	maxGruposMapa = 300
+)
+
+// MapaService responde las consultas del area visible del mapa
+type MapaService struct {
+	dbManager *repository.DBManager
+}
+
+// NewMapaService crea una nueva instancia del servicio
+func NewMapaService(dbManager *repository.DBManager) *MapaService {
+	return &MapaService{dbManager: dbManager}
+}
+
+// FiltrosMapa es el area visible, el zoom y los filtros opcionales del mapa
+type FiltrosMapa struct {
+	Caja            repository.CajaCoordenadas
+	Zoom            int
+	IDCategorias    []uint
+	CalificacionMin *float64
+	PrecioMax       *float64
+}
+
+// ResultadoMapa contiene grupos o restaurantes individuales segun Tipo
+type ResultadoMapa struct {
+	Tipo         string
+	Zoom         int
+	Total        int
+	Grupos       []repository.GrupoMapa
+	Restaurantes []repository.PuntoMapa
+}
+
+// ObtenerMapa retorna lo que hay dentro del area visible
+// Con zoom bajo agrupa en una cuadricula y con zoom alto devuelve cada restaurante
+func (ms *MapaService) ObtenerMapa(filtros FiltrosMapa) (*ResultadoMapa, error) {
+	if err := validarCajaMapa(filtros.Caja); err != nil {
+		return nil, err
+	}
+	if filtros.Zoom < ZoomMapaMinimo || filtros.Zoom > ZoomMapaMaximo {
+		return nil, errors.New("zoom debe estar entre 0 y 22")
+	}
+
+	caja := filtros.Caja
+	filtro := repository.FiltroBusquedaRestaurantes{
+		IDCategorias:    filtros.IDCategorias,
+		CalificacionMin: filtros.CalificacionMin,
+		PrecioMax:       filtros.PrecioMax,
+		Caja:            &caja,
+	}
+
+	if filtros.Zoom >= zoomRestaurantesIndividuales {
+		puntos, err := ms.dbManager.ObtenerPuntosMapa(filtro, limitePuntosMapa+1)
+		if err != nil {
+			return nil, err
+		}
+		if len(puntos) <= limitePuntosMapa {
+			return &ResultadoMapa{
+				Tipo:         TipoMapaRestaurantes,
+				Zoom:         filtros.Zoom,
+				Total:        len(puntos),
+				Restaurantes: puntos,
+			}, nil
+		}
+	}
+
+	celdaLat, celdaLng := tamañoCeldaMapa(filtros.Zoom, caja)
+	grupos, err := ms.dbManager.AgruparRestaurantesMapa(filtro, celdaLat, celdaLng)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, grupo := range grupos {
+		total += grupo.Total
+	}
+
+	return &ResultadoMapa{
+		Tipo:   TipoMapaGrupos,
+		Zoom:   filtros.Zoom,
+		Total:  total,
+		Grupos: grupos,
+	}, nil
+}
+
+// tamañoCeldaMapa calcula la celda de agrupacion en grados para un zoom de mapa web
+// En la proyeccion del mapa un grado de latitud ocupa mas pantalla lejos del ecuador,
+// por eso la celda en latitud se reduce con el coseno para que se vea cuadrada
+// Si con ese tamaño la caja cubre mas de maxGruposMapa celdas, la celda se duplica
+// hasta que caben, de modo que una pantalla grande con zoom alto no devuelve un grupo por restaurante
+func tamañoCeldaMapa(zoom int, caja repository.CajaCoordenadas) (float64, float64) {
+	celdaLng := 360.0 / math.Pow(2, float64(zoom)) / celdasPorTesela
+	coseno := math.Max(math.Cos((caja.MinLat+caja.MaxLat)/2*math.Pi/180), 0.1)
+	celdaLat := celdaLng * coseno
+
+	for celdasEnCaja(caja.MinLat, caja.MaxLat, celdaLat)*celdasEnCaja(caja.MinLng, caja.MaxLng, celdaLng) > maxGruposMapa {
+		celdaLat *= 2
+		celdaLng *= 2
+	}
+	return celdaLat, celdaLng
+}
+
+// celdasEnCaja cuenta las celdas de la rejilla que toca el intervalo, igual que el FLOOR del agrupado
+func celdasEnCaja(minimo, maximo, celda float64) float64 {
+	return math.Floor(maximo/celda) - math.Floor(minimo/celda) + 1
+}
+
+func validarCajaMapa(caja repository.CajaCoordenadas) error {
+	if caja.MinLat < -90 || caja.MaxLat > 90 {
+		return errors.New("latitud debe estar entre -90 y 90")
+	}
+	if caja.MinLng < -180 || caja.MaxLng > 180 {
+		return errors.New("longitud debe estar entre -180 y 180")
+	}
+	if caja.MinLat >= caja.MaxLat {
+		return errors.New("minLat debe ser menor que maxLat")
+	}
+	if caja.MinLng >= caja.MaxLng {
+		return errors.New("minLng debe ser menor que maxLng; si el area cruza el antimeridiano divide la consulta")
+	}
+	return nil
+}
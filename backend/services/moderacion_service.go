@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+	"github.com/tuusuario/quovi/utils"
+)
+
+// transicionesReseña define los cambios de estado permitidos en la moderacion
+var transicionesReseña = map[string][]string{
+	models.EstadoReseñaPendiente: {models.EstadoReseñaPublicada, models.EstadoReseñaOculta, models.EstadoReseñaEliminada},
+	models.EstadoReseñaPublicada: {models.EstadoReseñaPendiente, models.EstadoReseñaOculta, models.EstadoReseñaEliminada},
+	models.EstadoReseñaOculta:    {models.EstadoReseñaPublicada, models.EstadoReseñaEliminada},
+	models.EstadoReseñaEliminada: {},
+}
+
+var motivosReporte = map[string]bool{
+	models.MotivoReporteSpam:        true,
+	models.MotivoReporteOfensivo:    true,
+	models.MotivoReporteFalso:       true,
+	models.MotivoReporteIrrelevante: true,
+	models.MotivoReporteOtro:        true,
+}
+
+// ModeracionService maneja reportes de usuarios y la cola de moderacion de reseñas
+type ModeracionService struct {
+	dbManager      *repository.DBManager
+	umbralReportes int
+}
+
+// NewModeracionService crea el servicio; con umbralReportes reportes abiertos una reseña vuelve a revision
+func NewModeracionService(dbManager *repository.DBManager, umbralReportes int) *ModeracionService {
+	return &ModeracionService{
+		dbManager:      dbManager,
+		umbralReportes: umbralReportes,
+	}
+}
+
+// ReportarReseña registra un reporte y retiene la reseña si alcanza el umbral
+func (ms *ModeracionService) ReportarReseña(idUsuario, idReseña uint, motivo, descripcion string) (*models.ReporteReseña, error) {
+	motivo = strings.ToLower(strings.TrimSpace(motivo))
+	if !motivosReporte[motivo] {
+		return nil, errors.New("motivo invalido, usa: spam, ofensivo, falso, irrelevante u otro")
+	}
+
+	descripcion = strings.TrimSpace(descripcion)
+	if err := utils.ValidarLongitudTexto(descripcion, 0, 500, "La descripcion"); err != nil {
+		return nil, err
+	}
+
+	reseña, err := ms.dbManager.ObtenerReseñaPorID(idReseña)
+	if err != nil {
+		return nil, err
+	}
+
+	if reseña.Estado != models.EstadoReseñaPublicada {
+		return nil, errors.New("la reseña no esta publicada")
+	}
+
+	if reseña.IDUsuario == idUsuario {
+		return nil, errors.New("no puedes reportar tu propia reseña")
+	}
+
+	yaReportada, err := ms.dbManager.ExisteReporte(idUsuario, idReseña)
+	if err != nil {
+		return nil, err
+	}
+	if yaReportada {
+		return nil, errors.New("ya reportaste esta reseña")
+	}
+
+	reporte := &models.ReporteReseña{
+		IDReseña:    idReseña,
+		IDUsuario:   idUsuario,
+		Motivo:      motivo,
+		Descripcion: utils.SanitizarInput(descripcion),
+		Estado:      models.EstadoReportePendiente,
+		Fecha:       time.Now(),
+	}
+
+	// El reporte y la retencion por exceso de reportes se guardan juntos
+	if err := ms.dbManager.CrearReporte(reporte, ms.umbralReportes); err != nil {
+		return nil, err
+	}
+
+	return reporte, nil
+}
+
+// ObtenerColaModeracion lista reseñas a revisar, opcionalmente filtradas por estado
+func (ms *ModeracionService) ObtenerColaModeracion(estado string, pagina, limite int) ([]repository.ElementoColaModeracion, int64, error) {
+	if estado != "" {
+		if _, ok := transicionesReseña[estado]; !ok {
+			return nil, 0, errors.New("estado de reseña invalido")
+		}
+	}
+
+	pagina, limite = NormalizarPaginacion(pagina, limite)
+	return ms.dbManager.ObtenerColaModeracion(estado, (pagina-1)*limite, limite)
+}
+
+// ObtenerReportes lista los reportes de una reseña
+func (ms *ModeracionService) ObtenerReportes(idReseña uint) ([]models.ReporteReseña, error) {
+	return ms.dbManager.ObtenerReportesPorReseña(idReseña)
+}
+
+// CambiarEstadoReseña aplica una decision de moderacion validando la transicion
+func (ms *ModeracionService) CambiarEstadoReseña(idModerador, idReseña uint, nuevoEstado string) (*models.Reseña, error) {
+	reseña, err := ms.dbManager.ObtenerReseñaPorID(idReseña)
+	if err != nil {
+		return nil, err
+	}
+
+	if !transicionPermitida(reseña.Estado, nuevoEstado) {
+		return nil, errors.New("no se puede cambiar una reseña de '" + reseña.Estado + "' a '" + nuevoEstado + "'")
+	}
+
+	// Publicar descarta los reportes abiertos; ocultar o eliminar los da por resueltos
+	estadoReportes := models.EstadoReporteResuelto
+	switch nuevoEstado {
+	case models.EstadoReseñaPublicada:
+		estadoReportes = models.EstadoReporteDescartado
+	case models.EstadoReseñaPendiente:
+		estadoReportes = ""
+	}
+
+	if err := ms.dbManager.CambiarEstadoReseña(idReseña, nuevoEstado, estadoReportes, &idModerador); err != nil {
+		return nil, err
+	}
+
+	reseña.Estado = nuevoEstado
+	return reseña, nil
+}
+
+// transicionPermitida consulta la maquina de estados de moderacion
+func transicionPermitida(actual, nuevo string) bool {
+	for _, permitido := range transicionesReseña[actual] {
+		if permitido == nuevo {
+			return true
+		}
+	}
+	return false
+}
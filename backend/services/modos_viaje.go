@@ -0,0 +1,59 @@
+package services
+
+import (
+	"time"
+
+	"github.com/tuusuario/quovi/algorithms"
+)
+
+// Modos de viaje disponibles
+const (
+	ModoViajeCaminando  = "caminando"
+	ModoViajeBicicleta  = "bicicleta"
+	ModoViajeAuto       = "auto"
+	ModoViajeTransporte = "transporte" // Estimacion para transporte publico, sin horarios reales
+)
+
+// Objetivos con los que se puede optimizar un tour
+const (
+	ObjetivoDistancia = "distancia"
+	ObjetivoTiempo    = "tiempo"
+)
+
+// ModoViaje estima distancia y duracion de un trayecto, por calles o a partir de la linea recta
+type ModoViaje struct {
+	VelocidadKmH float64                // Velocidad promedio en movimiento
+	FactorRodeo  float64                // Distancia por calles sobre la distancia en linea recta, sin red vial
+	TiempoFijo   time.Duration          // Se suma a cada trayecto: estacionarse, esperar el transporte
+	Perfil       algorithms.PerfilRuteo // Vias y sentidos que puede recorrer en la red vial
+}
+
+// ModosViajePorDefecto son los valores de cada modo cuando no se configuran otros
+var ModosViajePorDefecto = map[string]ModoViaje{
+	ModoViajeCaminando:  {VelocidadKmH: 4.5, FactorRodeo: 1.25, Perfil: algorithms.PerfilPeaton},
+	ModoViajeBicicleta:  {VelocidadKmH: 14, FactorRodeo: 1.3, TiempoFijo: 2 * time.Minute, Perfil: algorithms.PerfilBicicleta},
+	ModoViajeAuto:       {VelocidadKmH: 30, FactorRodeo: 1.4, TiempoFijo: 5 * time.Minute, Perfil: algorithms.PerfilVehiculo},
+	ModoViajeTransporte: {VelocidadKmH: 18, FactorRodeo: 1.5, TiempoFijo: 10 * time.Minute, Perfil: algorithms.PerfilVehiculo},
+}
+
+// ModoViajePorDefecto es el modo que se usa cuando no se elige otro
+const ModoViajePorDefecto = ModoViajeAuto
+
+// Distancia estima los kilometros recorridos por calles
+func (m ModoViaje) Distancia(lineaRectaKm float64) float64 {
+	return lineaRectaKm * m.FactorRodeo
+}
+
+// Duracion estima el tiempo del trayecto a partir de la distancia en linea recta
+func (m ModoViaje) Duracion(lineaRectaKm float64) time.Duration {
+	return m.DuracionRecorrido(m.Distancia(lineaRectaKm))
+}
+
+// DuracionRecorrido es el tiempo para recorrer los kilometros dados; sin distancia no hay trayecto
+func (m ModoViaje) DuracionRecorrido(km float64) time.Duration {
+	if km <= 0 {
+		return 0
+	}
+	enMovimiento := time.Duration(km / m.VelocidadKmH * float64(time.Hour))
+	return (m.TiempoFijo + enMovimiento).Round(time.Second)
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+)
+
+// NotificacionService maneja los avisos dentro de la aplicacion
+type NotificacionService struct {
+	dbManager *repository.DBManager
+}
+
+// NewNotificacionService crea una nueva instancia del servicio de notificaciones
+func NewNotificacionService(dbManager *repository.DBManager) *NotificacionService {
+	return &NotificacionService{dbManager: dbManager}
+}
+
+// ObtenerNotificaciones lista las notificaciones del usuario y cuantas siguen sin leer
+func (ns *NotificacionService) ObtenerNotificaciones(idUsuario uint, pagina, limite int, soloNoLeidas bool) ([]models.Notificacion, int64, int64, error) {
+	pagina, limite = NormalizarPaginacion(pagina, limite)
+
+	notificaciones, total, err := ns.dbManager.ObtenerNotificacionesUsuario(idUsuario, soloNoLeidas, (pagina-1)*limite, limite)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	noLeidas, err := ns.dbManager.ContarNotificacionesNoLeidas(idUsuario)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return notificaciones, total, noLeidas, nil
+}
+
+// MarcarLeida marca una notificacion del usuario como leida
+func (ns *NotificacionService) MarcarLeida(idUsuario, idNotificacion uint) (*models.Notificacion, error) {
+	return ns.dbManager.MarcarNotificacionLeida(idUsuario, idNotificacion)
+}
+
+// MarcarTodasLeidas marca como leidas todas las notificaciones pendientes del usuario
+func (ns *NotificacionService) MarcarTodasLeidas(idUsuario uint) (int64, error) {
+	return ns.dbManager.MarcarTodasNotificacionesLeidas(idUsuario)
+}
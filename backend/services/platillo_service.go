@@ -1,28 +1,80 @@
 package services
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
 	"github.com/tuusuario/quovi/models"
 	"github.com/tuusuario/quovi/repository"
 )
 
+const (
+	// limitePlatillosIndice acota cuantos platillos se toman del indice por busqueda
+	limitePlatillosIndice = 1000
+
+	// limitePlatillosPorRestaurante acota los platillos coincidentes que se devuelven de cada restaurante
+	limitePlatillosPorRestaurante = 10
+
+	// RadioMaximoPlatillosKm es el radio mas amplio de la busqueda por platillo, igual que en cercanos
+	RadioMaximoPlatillosKm = 50.0
+)
+
 type PlatilloService struct {
 	dbManager *repository.DBManager
+	indice    *IndiceRestaurantes
 }
 
-func NewPlatilloService(dbManager *repository.DBManager) *PlatilloService {
+func NewPlatilloService(dbManager *repository.DBManager, indice *IndiceRestaurantes) *PlatilloService {
 	return &PlatilloService{
 		dbManager: dbManager,
+		indice:    indice,
 	}
 }
 
-// ObtenerPlatillosPorRestaurante retorna todos los platillos disponibles de un restaurante
-func (ps *PlatilloService) ObtenerPlatillosPorRestaurante(idRestaurante uint) ([]models.Platillo, error) {
-	return ps.dbManager.ObtenerPlatillosPorRestaurante(idRestaurante)
+// PlatilloCoincidente es un platillo encontrado por la busqueda con su relevancia
+type PlatilloCoincidente struct {
+	models.Platillo
+	Relevancia float64 `json:"relevancia,omitempty"`
+}
+
+// RestauranteConPlatillos agrupa los platillos encontrados bajo el restaurante que los sirve
+type RestauranteConPlatillos struct {
+	RestauranteConDistancia
+	PlatillosCoincidentes []PlatilloCoincidente `json:"platillosCoincidentes"`
+}
+
+// FiltrosBusquedaPlatillos contiene los criterios de la busqueda por platillo
+type FiltrosBusquedaPlatillos struct {
+	Termino          string
+	PrecioMax        *float64 // Precio maximo del platillo, no del restaurante
+	Etiquetas        []string // El platillo debe tener todas, por ejemplo vegano
+	ExcluirEtiquetas []string // El platillo no debe tener ninguna, por ejemplo contiene_cacahuate
+	IDCiudad         *uint
+	AbiertoAhora     bool
+	Latitud          *float64
+	Longitud         *float64
+	RadioKm          float64
+	Limite           int // Restaurantes a devolver; se normaliza igual que los listados
+}
+
+// ObtenerPlatillosPorRestaurante retorna los platillos disponibles de un restaurante,
+// opcionalmente solo los que tienen ciertas etiquetas o no tienen otras
+func (ps *PlatilloService) ObtenerPlatillosPorRestaurante(idRestaurante uint, etiquetas, excluirEtiquetas []string) ([]models.Platillo, error) {
+	if err := validarEtiquetas(etiquetas, excluirEtiquetas); err != nil {
+		return nil, err
+	}
+
+	return ps.dbManager.ObtenerPlatillosPorRestaurante(idRestaurante, repository.FiltroPlatillos{
+		Etiquetas:        etiquetas,
+		ExcluirEtiquetas: excluirEtiquetas,
+	})
 }
 
 // ObtenerPlatillosDestacados filtra solo los platillos destacados
 func (ps *PlatilloService) ObtenerPlatillosDestacados(idRestaurante uint) ([]models.Platillo, error) {
-	platillos, err := ps.dbManager.ObtenerPlatillosPorRestaurante(idRestaurante)
+	platillos, err := ps.dbManager.ObtenerPlatillosPorRestaurante(idRestaurante, repository.FiltroPlatillos{})
 	if err != nil {
 		return nil, err
 	}
@@ -36,3 +88,140 @@ func (ps *PlatilloService) ObtenerPlatillosDestacados(idRestaurante uint) ([]mod
 
 	return destacados, nil
 }
+
+// BuscarPlatillos busca platillos por nombre, descripcion e ingredientes y retorna los restaurantes
+// que los sirven junto con los platillos que coincidieron
+// Devuelve como maximo Limite restaurantes y limitePlatillosPorRestaurante platillos de cada uno
+func (ps *PlatilloService) BuscarPlatillos(filtros FiltrosBusquedaPlatillos) ([]RestauranteConPlatillos, error) {
+	termino := strings.TrimSpace(filtros.Termino)
+	if termino == "" {
+		return nil, fmt.Errorf("%w: el termino de busqueda es requerido", ErrFiltroInvalido)
+	}
+
+	if filtros.PrecioMax != nil && *filtros.PrecioMax < 0 {
+		return nil, fmt.Errorf("%w: el precio maximo no puede ser negativo", ErrFiltroInvalido)
+	}
+
+	if err := validarEtiquetas(filtros.Etiquetas, filtros.ExcluirEtiquetas); err != nil {
+		return nil, err
+	}
+
+	filtroPlatillos := repository.FiltroPlatillos{
+		PrecioMax:        filtros.PrecioMax,
+		Etiquetas:        filtros.Etiquetas,
+		ExcluirEtiquetas: filtros.ExcluirEtiquetas,
+	}
+
+	// El indice aporta la relevancia; si aun no esta listo se recurre a LIKE sin puntuacion
+	var platillos []models.Platillo
+	relevancia := make(map[uint]float64)
+	if ps.indice != nil && ps.indice.Listo() {
+		coincidencias := ps.indice.BuscarPlatillos(termino, limitePlatillosIndice)
+		ids := make([]uint, 0, len(coincidencias))
+		for _, coincidencia := range coincidencias {
+			relevancia[coincidencia.ID] = coincidencia.Puntuacion
+			ids = append(ids, coincidencia.ID)
+		}
+
+		var err error
+		platillos, err = ps.dbManager.ObtenerPlatillosPorIDs(ids, filtroPlatillos)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		platillos, err = ps.dbManager.BuscarPlatillosPorTexto(termino, filtroPlatillos, limitePlatillosIndice)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(platillos) == 0 {
+		return []RestauranteConPlatillos{}, nil
+	}
+
+	porRestaurante := make(map[uint][]PlatilloCoincidente)
+	idsRestaurantes := make([]uint, 0)
+	for _, platillo := range platillos {
+		if _, ok := porRestaurante[platillo.IDRestaurante]; !ok {
+			idsRestaurantes = append(idsRestaurantes, platillo.IDRestaurante)
+		}
+		porRestaurante[platillo.IDRestaurante] = append(porRestaurante[platillo.IDRestaurante], PlatilloCoincidente{
+			Platillo:   platillo,
+			Relevancia: relevancia[platillo.IDPlatillo],
+		})
+	}
+
+	filtro := repository.FiltroBusquedaRestaurantes{
+		IDRestaurantes: idsRestaurantes,
+		IDCiudad:       filtros.IDCiudad,
+		Latitud:        filtros.Latitud,
+		Longitud:       filtros.Longitud,
+		RadioKm:        math.Min(filtros.RadioKm, RadioMaximoPlatillosKm),
+	}
+	if filtros.AbiertoAhora {
+		if err := filtrarAbiertoAhora(ps.dbManager, &filtro); err != nil {
+			return nil, err
+		}
+	}
+
+	restaurantes, err := ps.dbManager.BuscarRestaurantesFiltrados(filtro)
+	if err != nil {
+		return nil, err
+	}
+
+	conUbicacion := filtros.Latitud != nil && filtros.Longitud != nil
+	resultado := make([]RestauranteConPlatillos, 0, len(restaurantes))
+
+	for _, rest := range restaurantes {
+		coincidentes := porRestaurante[rest.IDRestaurante]
+		sort.SliceStable(coincidentes, func(i, j int) bool {
+			if coincidentes[i].Relevancia != coincidentes[j].Relevancia {
+				return coincidentes[i].Relevancia > coincidentes[j].Relevancia
+			}
+			return coincidentes[i].Precio < coincidentes[j].Precio
+		})
+
+		estaAbierto, horarioHoy := verificarHorario(rest.Horarios, rest.Excepciones, rest.Ciudad.ZonaHoraria)
+
+		item := RestauranteConPlatillos{
+			RestauranteConDistancia: RestauranteConDistancia{
+				Restaurante: rest,
+				EstaAbierto: estaAbierto,
+				HorarioHoy:  horarioHoy,
+				Relevancia:  coincidentes[0].Relevancia,
+			},
+			PlatillosCoincidentes: coincidentes,
+		}
+
+		if conUbicacion {
+			distancia := calcularDistancia(*filtros.Latitud, *filtros.Longitud, rest.Latitud, rest.Longitud)
+			item.DistanciaKm = math.Round(distancia*100) / 100
+			item.TiempoEstimado = calcularTiempoEstimado(distancia)
+		}
+
+		resultado = append(resultado, item)
+	}
+
+	// Con ubicacion se ordena por cercania; sin ella por el platillo mas relevante de cada restaurante
+	sort.SliceStable(resultado, func(i, j int) bool {
+		if conUbicacion && resultado[i].DistanciaKm != resultado[j].DistanciaKm {
+			return resultado[i].DistanciaKm < resultado[j].DistanciaKm
+		}
+		if resultado[i].Relevancia != resultado[j].Relevancia {
+			return resultado[i].Relevancia > resultado[j].Relevancia
+		}
+		return resultado[i].CalificacionPromedio > resultado[j].CalificacionPromedio
+	})
+
+	if limite := NormalizarLimiteRestaurantes(filtros.Limite); len(resultado) > limite {
+		resultado = resultado[:limite]
+	}
+	for i := range resultado {
+		if len(resultado[i].PlatillosCoincidentes) > limitePlatillosPorRestaurante {
+			resultado[i].PlatillosCoincidentes = resultado[i].PlatillosCoincidentes[:limitePlatillosPorRestaurante]
+		}
+	}
+
+	return resultado, nil
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+	"github.com/tuusuario/quovi/utils"
+)
+
+// inicioNoPlatoFuerte son las palabras o frases con que empieza el nombre de bebidas, postres y
+// complementos; en minusculas, sin acentos y en singular. El resto de los platillos cuenta como plato fuerte
+var inicioNoPlatoFuerte = []string{
+	// Bebidas
+	"agua", "refresco", "soda", "jugo", "licuado", "malteada", "smoothie", "limonada", "naranjada", "cafe",
+	"capuchino", "cappuccino", "espresso", "expreso", "americano", "latte", "te", "infusion", "chocolate caliente",
+	"atole", "horchata", "cerveza", "michelada", "vino", "copa", "coctel", "margarita", "mezcal", "tequila",
+	"whisky", "ron", "vodka", "ginebra", "clericot", "sangria", "carajillo", "bebida",
+	// Postres
+	"postre", "pastel", "flan", "helado", "nieve", "paleta", "churro", "brownie", "galleta", "pay", "tarta",
+	"gelatina", "arroz con leche", "cheesecake", "mousse", "tiramisu", "crepa dulce", "pan dulce", "concha",
+	"bunuelo", "fresas con crema", "dulce",
+	// Complementos
+	"extra", "orden de tortilla", "orden de pan", "orden de papa", "guarnicion", "acompanamiento", "salsa",
+	"aderezo", "tortilla", "pan",
+}
+
+// inicioAmbiguo son los inicios de nombre que tambien nombran platos fuertes segun lo que sigue,
+// como "Pastel de carne" o "Paleta de cerdo" frente a "Pastel de chocolate" o "Paleta de limon"
+var inicioAmbiguo = map[string]bool{
+	"pastel": true, "paleta": true, "tarta": true, "pay": true, "pan": true, "tortilla": true,
+}
+
+// ingredientesPlatoFuerte son las proteinas que, despues de un inicio ambiguo, indican un plato fuerte
+var ingredientesPlatoFuerte = map[string]bool{
+	"carne": true, "res": true, "cerdo": true, "puerco": true, "pollo": true, "pavo": true, "cordero": true,
+	"borrego": true, "pescado": true, "atun": true, "salmon": true, "camaron": true, "camarones": true,
+	"marisco": true, "mariscos": true, "pulpo": true, "jamon": true, "chorizo": true, "tocino": true,
+}
+
+// frasesNoPlatoFuerte agrupa las frases por numero de palabras para compararlas con el inicio del nombre
+var frasesNoPlatoFuerte = agruparFrasesPorLongitud(inicioNoPlatoFuerte)
+
+// longitudesNoPlatoFuerte son los numeros de palabras de las frases, de mayor a menor, para que
+// "pan dulce" se reconozca antes que "pan"
+var longitudesNoPlatoFuerte = ordenarLongitudes(frasesNoPlatoFuerte)
+
+func agruparFrasesPorLongitud(frases []string) map[int]map[string]bool {
+	grupos := make(map[int]map[string]bool)
+	for _, frase := range frases {
+		palabras := utils.Tokenizar(frase)
+		if grupos[len(palabras)] == nil {
+			grupos[len(palabras)] = make(map[string]bool)
+		}
+		grupos[len(palabras)][strings.Join(palabras, " ")] = true
+	}
+	return grupos
+}
+
+func ordenarLongitudes(grupos map[int]map[string]bool) []int {
+	longitudes := make([]int, 0, len(grupos))
+	for n := range grupos {
+		longitudes = append(longitudes, n)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(longitudes)))
+	return longitudes
+}
+
+// EsPlatoFuerte indica si el platillo parece un plato principal y no una bebida, postre o complemento
+// Se revisa el inicio del nombre: "Pollo en salsa verde" es plato fuerte y "Salsa extra" no. Cuando el
+// inicio es ambiguo decide el resto del nombre: "Pastel de carne" es plato fuerte y "Pastel de fresa" no
+func EsPlatoFuerte(platillo models.Platillo) bool {
+	palabras := utils.Tokenizar(platillo.Nombre)
+
+	inicio, ok := inicioNoPlatoFuerteEn(palabras)
+	if !ok {
+		return true
+	}
+	if inicioAmbiguo[inicio] {
+		for _, palabra := range palabras[len(utils.Tokenizar(inicio)):] {
+			if ingredientesPlatoFuerte[palabra] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inicioNoPlatoFuerteEn retorna, en singular, la frase de bebida, postre o complemento con que
+// empiezan las palabras, si la hay
+func inicioNoPlatoFuerteEn(palabras []string) (string, bool) {
+	for _, n := range longitudesNoPlatoFuerte {
+		if n > len(palabras) {
+			continue
+		}
+		frases := frasesNoPlatoFuerte[n]
+		inicio := palabras[:n]
+		if frase := strings.Join(inicio, " "); frases[frase] {
+			return frase, true
+		}
+		// Plural de la ultima palabra, como "Aguas frescas" o "Churros con cajeta"
+		ultima := inicio[n-1]
+		for _, sufijo := range []string{"es", "s"} {
+			if !strings.HasSuffix(ultima, sufijo) {
+				continue
+			}
+			singular := append(append([]string{}, inicio[:n-1]...), strings.TrimSuffix(ultima, sufijo))
+			if frase := strings.Join(singular, " "); frases[frase] {
+				return frase, true
+			}
+		}
+	}
+	return "", false
+}
+
+// CalcularPreciosRestaurante resume los precios de los platillos de un restaurante
+// Retorna nil si ninguno tiene precio
+func CalcularPreciosRestaurante(idRestaurante uint, platillos []models.Platillo) *models.PreciosRestaurante {
+	var todos, fuertes []float64
+	for _, platillo := range platillos {
+		if platillo.Precio <= 0 {
+			continue
+		}
+		todos = append(todos, platillo.Precio)
+		if EsPlatoFuerte(platillo) {
+			fuertes = append(fuertes, platillo.Precio)
+		}
+	}
+	if len(todos) == 0 {
+		return nil
+	}
+
+	sort.Float64s(todos)
+	precios := &models.PreciosRestaurante{
+		IDRestaurante:  idRestaurante,
+		TotalPlatillos: len(todos),
+		PrecioMinimo:   todos[0],
+		PrecioMaximo:   todos[len(todos)-1],
+		PrecioMediano:  mediana(todos),
+	}
+	precios.PrecioReferencia = precios.PrecioMediano
+
+	if len(fuertes) > 0 {
+		sort.Float64s(fuertes)
+		minimo, medio := fuertes[0], mediana(fuertes)
+		precios.PrecioMinimoFuerte = &minimo
+		precios.PrecioMedianoFuerte = &medio
+		precios.PrecioReferencia = medio
+	}
+
+	return precios
+}
+
+// mediana de valores ya ordenados, redondeada a centavos
+func mediana(ordenados []float64) float64 {
+	mitad := len(ordenados) / 2
+	valor := ordenados[mitad]
+	if len(ordenados)%2 == 0 {
+		valor = (ordenados[mitad-1] + ordenados[mitad]) / 2
+	}
+	return math.Round(valor*100) / 100
+}
+
+// PreciosRestauranteService mantiene las estadisticas de precio del menu de cada restaurante
+// Se recalculan para los restaurantes cuyos platillos cambiaron y por completo cada cierto tiempo,
+// ya que borrar un platillo no deja fecha de actualizacion
+type PreciosRestauranteService struct {
+	dbManager *repository.DBManager
+
+	mu                   sync.Mutex // Serializa los recalculos
+	ultimaSincronizacion time.Time
+}
+
+// NewPreciosRestauranteService crea una nueva instancia del servicio
+func NewPreciosRestauranteService(dbManager *repository.DBManager) *PreciosRestauranteService {
+	return &PreciosRestauranteService{dbManager: dbManager}
+}
+
+// Reconstruir recalcula las estadisticas de todos los restaurantes
+func (ps *PreciosRestauranteService) Reconstruir() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	return ps.recalcular(nil, time.Now())
+}
+
+// Refrescar recalcula solo los restaurantes modificados desde la ultima sincronizacion
+func (ps *PreciosRestauranteService) Refrescar() error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	inicio := time.Now()
+
+	// Sin sincronizacion previa se recalculan todos
+	if ps.ultimaSincronizacion.IsZero() {
+		return ps.recalcular(nil, inicio)
+	}
+
+	ids, err := ps.dbManager.ObtenerRestaurantesModificadosDesde(ps.ultimaSincronizacion.Add(-margenSincronizacion))
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		ps.ultimaSincronizacion = inicio
+		return nil
+	}
+
+	return ps.recalcular(ids, inicio)
+}
+
+// IniciarRefresco lanza en segundo plano el refresco incremental y el recalculo completo periodico
+func (ps *PreciosRestauranteService) IniciarRefresco(intervalo, intervaloReconstruccion time.Duration) {
+	go func() {
+		refresco := time.NewTicker(intervalo)
+		reconstruccion := time.NewTicker(intervaloReconstruccion)
+		defer refresco.Stop()
+		defer reconstruccion.Stop()
+
+		for {
+			select {
+			case <-refresco.C:
+				if err := ps.Refrescar(); err != nil {
+					log.Printf("Error al refrescar precios de restaurantes: %v", err)
+				}
+			case <-reconstruccion.C:
+				if err := ps.Reconstruir(); err != nil {
+					log.Printf("Error al recalcular precios de restaurantes: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// recalcular calcula y guarda las estadisticas de los restaurantes indicados, o de todos si ids es nil
+// inicio es el momento desde el que se buscaran cambios en el siguiente refresco
+func (ps *PreciosRestauranteService) recalcular(ids []uint, inicio time.Time) error {
+	platillos, err := ps.dbManager.ObtenerPlatillosParaPrecios(ids)
+	if err != nil {
+		return err
+	}
+
+	porRestaurante := make(map[uint][]models.Platillo)
+	for _, platillo := range platillos {
+		porRestaurante[platillo.IDRestaurante] = append(porRestaurante[platillo.IDRestaurante], platillo)
+	}
+
+	precios := make([]models.PreciosRestaurante, 0, len(porRestaurante))
+	for idRestaurante, platillosRestaurante := range porRestaurante {
+		if calculados := CalcularPreciosRestaurante(idRestaurante, platillosRestaurante); calculados != nil {
+			calculados.FechaCalculo = inicio
+			precios = append(precios, *calculados)
+		}
+	}
+
+	if err := ps.dbManager.GuardarPreciosRestaurantes(ids, precios); err != nil {
+		return err
+	}
+
+	ps.ultimaSincronizacion = inicio
+	return nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+)
+
+// PropietarioService administra que usuarios son dueños de cada restaurante
+type PropietarioService struct {
+	dbManager *repository.DBManager
+}
+
+// NewPropietarioService crea una nueva instancia del servicio de propietarios
+func NewPropietarioService(dbManager *repository.DBManager) *PropietarioService {
+	return &PropietarioService{dbManager: dbManager}
+}
+
+// AsignarPropietario vincula a un usuario existente con un restaurante
+func (ps *PropietarioService) AsignarPropietario(idUsuario, idRestaurante uint) error {
+	if _, err := ps.dbManager.ObtenerUsuarioPorID(idUsuario); err != nil {
+		return errors.New("usuario no encontrado")
+	}
+
+	if _, err := ps.dbManager.ObtenerRestaurantePorID(idRestaurante); err != nil {
+		return errors.New("restaurante no encontrado")
+	}
+
+	return ps.dbManager.AsignarPropietario(idUsuario, idRestaurante)
+}
+
+// QuitarPropietario desvincula a un usuario de un restaurante
+func (ps *PropietarioService) QuitarPropietario(idUsuario, idRestaurante uint) error {
+	return ps.dbManager.QuitarPropietario(idUsuario, idRestaurante)
+}
+
+// ObtenerPropietarios lista los propietarios de un restaurante
+func (ps *PropietarioService) ObtenerPropietarios(idRestaurante uint) ([]models.Usuario, error) {
+	return ps.dbManager.ObtenerPropietariosRestaurante(idRestaurante)
+}
+
+// EsPropietario indica si el usuario administra el restaurante
+func (ps *PropietarioService) EsPropietario(idUsuario, idRestaurante uint) (bool, error) {
+	return ps.dbManager.EsPropietarioRestaurante(idUsuario, idRestaurante)
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+	"github.com/tuusuario/quovi/utils"
+)
+
+const (
+	LimiteReseñasPorDefecto  = 10
+	LimiteReseñasMaximo      = 50
+	LongitudMaximaComentario = 2000
+)
+
+// ConfiguracionReseñas define la verificacion de visitas, el filtro de contenido y las fotos
+type ConfiguracionReseñas struct {
+	RadioCheckinMetros       float64         // Distancia maxima al restaurante para aceptar un check-in
+	DiasVigenciaVisita       int             // Dias durante los que una visita verifica nuevas reseñas
+	Filtro                   FiltroContenido // Retiene para moderacion las reseñas que no pasan el filtro
+	MaxFotosPorReseña        int             // Numero maximo de fotos adjuntas a una reseña
+	AprobacionesParaPromover int             // Aprobaciones para pasar una foto a la galeria; 0 desactiva la promocion
+}
+
+// ReseñaService maneja la logica de negocio de las reseñas de restaurantes
+type ReseñaService struct {
+	dbManager *repository.DBManager
+	config    ConfiguracionReseñas
+}
+
+// NewReseñaService crea una nueva instancia del servicio de reseñas
+func NewReseñaService(dbManager *repository.DBManager, config ConfiguracionReseñas) *ReseñaService {
+	return &ReseñaService{
+		dbManager: dbManager,
+		config:    config,
+	}
+}
+
+// OpcionesListadoReseñas define paginacion y filtros para listar reseñas
+type OpcionesListadoReseñas struct {
+	Pagina               int
+	Limite               int
+	Orden                string // recientes, mejores, peores o utiles
+	SoloVerificadas      bool
+	PriorizarVerificadas bool
+}
+
+// CrearReseña registra la reseña de un usuario; solo se permite una por restaurante
+func (rs *ReseñaService) CrearReseña(idUsuario, idRestaurante uint, calificacion int, comentario string) (*models.Reseña, error) {
+	comentario, err := validarReseña(calificacion, comentario)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := rs.dbManager.ObtenerRestaurantePorID(idRestaurante); err != nil {
+		return nil, errors.New("restaurante no encontrado")
+	}
+
+	// El indice unico de la tabla cubre las peticiones simultaneas; CrearReseña lo traduce al mismo error
+	existe, err := rs.dbManager.ExisteReseñaUsuario(idUsuario, idRestaurante)
+	if err != nil {
+		return nil, err
+	}
+	if existe {
+		return nil, repository.ErrReseñaDuplicada
+	}
+
+	reseña := &models.Reseña{
+		IDUsuario:     idUsuario,
+		IDRestaurante: idRestaurante,
+		Calificacion:  int8(calificacion),
+		Comentario:    comentario,
+		Fecha:         time.Now(),
+		Verificada:    rs.tieneVisitaVigente(idUsuario, idRestaurante),
+		Estado:        rs.estadoInicial(comentario),
+	}
+
+	if err := rs.dbManager.CrearReseña(reseña); err != nil {
+		return nil, err
+	}
+
+	return reseña, nil
+}
+
+// ActualizarReseña modifica la calificacion y comentario de la reseña del usuario
+func (rs *ReseñaService) ActualizarReseña(idUsuario, idRestaurante uint, calificacion int, comentario string) (*models.Reseña, error) {
+	comentario, err := validarReseña(calificacion, comentario)
+	if err != nil {
+		return nil, err
+	}
+
+	reseña, err := rs.dbManager.ObtenerReseñaUsuario(idUsuario, idRestaurante)
+	if err != nil {
+		return nil, err
+	}
+
+	if reseña.Estado == models.EstadoReseñaEliminada {
+		return nil, errors.New("esta reseña fue eliminada por moderacion y no puede editarse")
+	}
+
+	// Las reseñas retenidas u ocultas siguen en manos de moderacion
+	if reseña.Estado == models.EstadoReseñaPublicada {
+		reseña.Estado = rs.estadoInicial(comentario)
+	}
+
+	reseña.Calificacion = int8(calificacion)
+	reseña.Comentario = comentario
+	reseña.Verificada = reseña.Verificada || rs.tieneVisitaVigente(idUsuario, idRestaurante)
+
+	if err := rs.dbManager.ActualizarReseña(reseña); err != nil {
+		return nil, err
+	}
+
+	return reseña, nil
+}
+
+// EliminarReseña borra la reseña del usuario para un restaurante junto con sus fotos
+// Las fotos promovidas a la galeria del restaurante se conservan en disco
+func (rs *ReseñaService) EliminarReseña(idUsuario, idRestaurante uint) error {
+	var imagenes []models.ImagenReseña
+	if reseña, err := rs.dbManager.ObtenerReseñaUsuario(idUsuario, idRestaurante); err == nil {
+		imagenes, _ = rs.dbManager.ObtenerImagenesReseña(reseña.IDReseña)
+	}
+
+	if err := rs.dbManager.EliminarReseña(idUsuario, idRestaurante); err != nil {
+		return err
+	}
+
+	for _, imagen := range imagenes {
+		if !imagen.Promovida {
+			utils.EliminarImagen(imagen.URL)
+		}
+	}
+
+	return nil
+}
+
+// ObtenerReseñaUsuario retorna la reseña que el usuario escribio para un restaurante
+func (rs *ReseñaService) ObtenerReseñaUsuario(idUsuario, idRestaurante uint) (*models.Reseña, error) {
+	return rs.dbManager.ObtenerReseñaUsuario(idUsuario, idRestaurante)
+}
+
+// ObtenerReseñasPorRestaurante lista las reseñas publicadas de un restaurante; por defecto las mas recientes primero
+func (rs *ReseñaService) ObtenerReseñasPorRestaurante(idRestaurante uint, opciones OpcionesListadoReseñas) ([]models.Reseña, int64, error) {
+	pagina, limite := NormalizarPaginacion(opciones.Pagina, opciones.Limite)
+
+	if opciones.Orden == "" {
+		opciones.Orden = repository.OrdenReseñasRecientes
+	}
+	if !repository.EsOrdenReseñasValido(opciones.Orden) {
+		return nil, 0, errors.New("orden invalido, usa: recientes, mejores, peores o utiles")
+	}
+
+	return rs.dbManager.ObtenerReseñasPorRestaurante(idRestaurante, repository.FiltroReseñas{
+		Offset:               (pagina - 1) * limite,
+		Limite:               limite,
+		Orden:                opciones.Orden,
+		SoloVerificadas:      opciones.SoloVerificadas,
+		PriorizarVerificadas: opciones.PriorizarVerificadas,
+	})
+}
+
+// VotarReseña marca una reseña publicada como util o no util; un voto por usuario
+func (rs *ReseñaService) VotarReseña(idUsuario, idReseña uint, util bool) (*models.Reseña, error) {
+	reseña, err := rs.dbManager.ObtenerReseñaPorID(idReseña)
+	if err != nil {
+		return nil, err
+	}
+
+	if reseña.Estado != models.EstadoReseñaPublicada {
+		return nil, errors.New("la reseña no esta publicada")
+	}
+
+	if reseña.IDUsuario == idUsuario {
+		return nil, errors.New("no puedes votar tu propia reseña")
+	}
+
+	return rs.dbManager.VotarReseña(idReseña, idUsuario, util)
+}
+
+// EliminarVotoReseña retira el voto del usuario sobre una reseña
+func (rs *ReseñaService) EliminarVotoReseña(idUsuario, idReseña uint) (*models.Reseña, error) {
+	return rs.dbManager.EliminarVotoReseña(idReseña, idUsuario)
+}
+
+// RegistrarVisita guarda un check-in si las coordenadas estan dentro del radio del restaurante
+func (rs *ReseñaService) RegistrarVisita(idUsuario, idRestaurante uint, lat, lng float64) (*models.Visita, error) {
+	restaurante, err := rs.dbManager.ObtenerRestaurantePorID(idRestaurante)
+	if err != nil {
+		return nil, errors.New("restaurante no encontrado")
+	}
+
+	distanciaMetros := calcularDistancia(lat, lng, restaurante.Latitud, restaurante.Longitud) * 1000
+	if distanciaMetros > rs.config.RadioCheckinMetros {
+		return nil, fmt.Errorf("debes estar a menos de %.0f metros del restaurante para registrar tu visita", rs.config.RadioCheckinMetros)
+	}
+
+	visita := &models.Visita{
+		IDUsuario:       idUsuario,
+		IDRestaurante:   idRestaurante,
+		Latitud:         lat,
+		Longitud:        lng,
+		DistanciaMetros: math.Round(distanciaMetros*100) / 100,
+		Fecha:           time.Now(),
+	}
+
+	if err := rs.dbManager.CrearVisita(visita); err != nil {
+		return nil, err
+	}
+
+	return visita, nil
+}
+
+// VigenciaVisita retorna hasta cuando una visita permite publicar reseñas verificadas
+func (rs *ReseñaService) VigenciaVisita(visita *models.Visita) time.Time {
+	return visita.Fecha.AddDate(0, 0, rs.config.DiasVigenciaVisita)
+}
+
+// estadoInicial publica la reseña salvo que el filtro de contenido la retenga
+func (rs *ReseñaService) estadoInicial(comentario string) string {
+	if rs.config.Filtro != nil && rs.config.Filtro.Evaluar(comentario).Retener {
+		return models.EstadoReseñaPendiente
+	}
+	return models.EstadoReseñaPublicada
+}
+
+// tieneVisitaVigente indica si el usuario visito el restaurante en los ultimos N dias
+func (rs *ReseñaService) tieneVisitaVigente(idUsuario, idRestaurante uint) bool {
+	desde := time.Now().AddDate(0, 0, -rs.config.DiasVigenciaVisita)
+	existe, err := rs.dbManager.ExisteVisitaDesde(idUsuario, idRestaurante, desde)
+	if err != nil {
+		return false
+	}
+	return existe
+}
+
+// NormalizarPaginacion ajusta pagina y limite a valores permitidos
+func NormalizarPaginacion(pagina, limite int) (int, int) {
+	if pagina < 1 {
+		pagina = 1
+	}
+	if limite <= 0 {
+		limite = LimiteReseñasPorDefecto
+	}
+	if limite > LimiteReseñasMaximo {
+		limite = LimiteReseñasMaximo
+	}
+	return pagina, limite
+}
+
+// validarReseña verifica la calificacion y retorna el comentario sanitizado
+func validarReseña(calificacion int, comentario string) (string, error) {
+	if calificacion < 1 || calificacion > 5 {
+		return "", errors.New("la calificacion debe estar entre 1 y 5")
+	}
+
+	comentario = strings.TrimSpace(comentario)
+	if err := utils.ValidarLongitudTexto(comentario, 0, LongitudMaximaComentario, "El comentario"); err != nil {
+		return "", err
+	}
+
+	return utils.SanitizarInput(comentario), nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tuusuario/quovi/models"
+	"github.com/tuusuario/quovi/repository"
+	"github.com/tuusuario/quovi/utils"
+)
+
+// RespuestaReseñaService maneja las respuestas publicas de los propietarios a las reseñas
+type RespuestaReseñaService struct {
+	dbManager *repository.DBManager
+}
+
+// NewRespuestaReseñaService crea una nueva instancia del servicio de respuestas
+func NewRespuestaReseñaService(dbManager *repository.DBManager) *RespuestaReseñaService {
+	return &RespuestaReseñaService{dbManager: dbManager}
+}
+
+// ResponderReseña publica la respuesta del propietario y notifica al autor de la reseña
+func (rs *RespuestaReseñaService) ResponderReseña(idUsuario, idReseña uint, comentario string) (*models.RespuestaReseña, error) {
+	comentario, err := validarRespuesta(comentario)
+	if err != nil {
+		return nil, err
+	}
+
+	reseña, restaurante, err := rs.reseñaDePropietario(idUsuario, idReseña)
+	if err != nil {
+		return nil, err
+	}
+
+	if existente, _ := rs.dbManager.ObtenerRespuestaReseña(idReseña); existente != nil {
+		return nil, errors.New("esta reseña ya tiene respuesta; editala en su lugar")
+	}
+
+	respuesta := &models.RespuestaReseña{
+		IDReseña:   idReseña,
+		IDUsuario:  idUsuario,
+		Comentario: comentario,
+		Fecha:      time.Now(),
+	}
+
+	notificacion := notificacionRespuesta(reseña, restaurante, idUsuario, "%s respondio a tu reseña")
+	if err := rs.dbManager.CrearRespuestaReseña(respuesta, notificacion); err != nil {
+		return nil, err
+	}
+
+	return respuesta, nil
+}
+
+// EditarRespuestaReseña cambia el texto de la respuesta y vuelve a avisar al autor
+func (rs *RespuestaReseñaService) EditarRespuestaReseña(idUsuario, idReseña uint, comentario string) (*models.RespuestaReseña, error) {
+	comentario, err := validarRespuesta(comentario)
+	if err != nil {
+		return nil, err
+	}
+
+	reseña, restaurante, err := rs.reseñaDePropietario(idUsuario, idReseña)
+	if err != nil {
+		return nil, err
+	}
+
+	respuesta, err := rs.dbManager.ObtenerRespuestaReseña(idReseña)
+	if err != nil {
+		return nil, err
+	}
+
+	ahora := time.Now()
+	respuesta.IDUsuario = idUsuario
+	respuesta.Comentario = comentario
+	respuesta.FechaEdicion = &ahora
+
+	notificacion := notificacionRespuesta(reseña, restaurante, idUsuario, "%s actualizo su respuesta a tu reseña")
+	if err := rs.dbManager.ActualizarRespuestaReseña(respuesta, notificacion); err != nil {
+		return nil, err
+	}
+
+	return respuesta, nil
+}
+
+// EliminarRespuestaReseña retira la respuesta del propietario
+func (rs *RespuestaReseñaService) EliminarRespuestaReseña(idUsuario, idReseña uint) error {
+	if _, _, err := rs.reseñaDePropietario(idUsuario, idReseña); err != nil {
+		return err
+	}
+	return rs.dbManager.EliminarRespuestaReseña(idReseña)
+}
+
+// reseñaDePropietario carga la reseña y verifica que el usuario sea propietario del restaurante
+func (rs *RespuestaReseñaService) reseñaDePropietario(idUsuario, idReseña uint) (*models.Reseña, *models.Restaurante, error) {
+	reseña, err := rs.dbManager.ObtenerReseñaPorID(idReseña)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	esPropietario, err := rs.dbManager.EsPropietarioRestaurante(idUsuario, reseña.IDRestaurante)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !esPropietario {
+		return nil, nil, errors.New("solo los propietarios del restaurante pueden responder reseñas")
+	}
+
+	if reseña.Estado != models.EstadoReseñaPublicada {
+		return nil, nil, errors.New("la reseña no esta publicada")
+	}
+
+	restaurante, err := rs.dbManager.ObtenerRestaurantePorID(reseña.IDRestaurante)
+	if err != nil {
+		return nil, nil, errors.New("restaurante no encontrado")
+	}
+
+	return reseña, restaurante, nil
+}
+
+// notificacionRespuesta arma el aviso para el autor; no se notifica a quien responde su propia reseña
+func notificacionRespuesta(reseña *models.Reseña, restaurante *models.Restaurante, idUsuario uint, formato string) *models.Notificacion {
+	if reseña.IDUsuario == idUsuario {
+		return nil
+	}
+
+	return &models.Notificacion{
+		IDUsuario:     reseña.IDUsuario,
+		Tipo:          models.TipoNotificacionRespuestaReseña,
+		Mensaje:       fmt.Sprintf(formato, restaurante.Nombre),
+		IDRestaurante: &reseña.IDRestaurante,
+		IDReseña:      &reseña.IDReseña,
+		Fecha:         time.Now(),
+	}
+}
+
+// validarRespuesta exige texto y retorna la respuesta sanitizada
+func validarRespuesta(comentario string) (string, error) {
+	comentario = strings.TrimSpace(comentario)
+	if err := utils.ValidarLongitudTexto(comentario, 1, LongitudMaximaComentario, "La respuesta"); err != nil {
+		return "", err
+	}
+	return utils.SanitizarInput(comentario), nil
+}
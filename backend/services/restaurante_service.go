@@ -2,22 +2,32 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	"math"
-	"sort"
 	"strings"
-	"time"
 
 	"github.com/tuusuario/quovi/models"
 	"github.com/tuusuario/quovi/repository"
 )
 
+// limiteResultadosIndice acota cuantos restaurantes del indice pasan a la consulta SQL
+const limiteResultadosIndice = 500
+
+// Tamaño de pagina de los listados de restaurantes
+const (
+	LimiteRestaurantesPorDefecto = 20
+	LimiteRestaurantesMaximo     = 100
+)
+
 type RestauranteService struct {
 	dbManager *repository.DBManager
+	indice    *IndiceRestaurantes
 }
 
-func NewRestauranteService(dbManager *repository.DBManager) *RestauranteService {
+func NewRestauranteService(dbManager *repository.DBManager, indice *IndiceRestaurantes) *RestauranteService {
 	return &RestauranteService{
 		dbManager: dbManager,
+		indice:    indice,
 	}
 }
 
@@ -28,11 +38,29 @@ type RestauranteConDistancia struct {
 	TiempoEstimado string  `json:"tiempoEstimado"`
 	EstaAbierto    bool    `json:"estaAbierto"`
 	HorarioHoy     string  `json:"horarioHoy,omitempty"`
+	Relevancia     float64 `json:"relevancia,omitempty"`
+}
+
+// OpcionesPaginacion es la pagina de un listado que pide el cliente
+type OpcionesPaginacion struct {
+	Limite int
+	Cursor string
+	Orden  string // distancia, calificacion, precio, nombre, recientes o relevancia (solo busqueda)
+}
+
+// PaginaRestaurantes es una pagina de un listado de restaurantes
+type PaginaRestaurantes struct {
+	Restaurantes    []RestauranteConDistancia
+	SiguienteCursor string
+	TotalEstimado   int64
+	Orden           string
+	Limite          int
 }
 
-// ObtenerTodosLosRestaurantes retorna todos los restaurantes activos
-func (rs *RestauranteService) ObtenerTodosLosRestaurantes() ([]models.Restaurante, error) {
-	return rs.dbManager.ObtenerTodosLosRestaurantes()
+// ObtenerTodosLosRestaurantes retorna una pagina de los restaurantes activos
+func (rs *RestauranteService) ObtenerTodosLosRestaurantes(lat, lng *float64, paginacion OpcionesPaginacion) (*PaginaRestaurantes, error) {
+	filtro := repository.FiltroBusquedaRestaurantes{Latitud: lat, Longitud: lng}
+	return rs.paginarRestaurantes(filtro, paginacion, nil)
 }
 
 // ObtenerRestaurantePorID busca un restaurante especifico
@@ -40,162 +68,268 @@ func (rs *RestauranteService) ObtenerRestaurantePorID(id uint) (*models.Restaura
 	return rs.dbManager.ObtenerRestaurantePorID(id)
 }
 
-// ObtenerRestaurantesCercanos busca restaurantes dentro de un radio y calcula distancias
-func (rs *RestauranteService) ObtenerRestaurantesCercanos(lat, lng, radioKm float64) ([]RestauranteConDistancia, error) {
-	restaurantes, err := rs.dbManager.ObtenerRestaurantesCercanos(lat, lng, radioKm)
-	if err != nil {
-		return nil, err
+// ObtenerRestaurantesCercanos busca restaurantes dentro de un radio; por defecto los mas cercanos primero
+func (rs *RestauranteService) ObtenerRestaurantesCercanos(lat, lng, radioKm float64, paginacion OpcionesPaginacion) (*PaginaRestaurantes, error) {
+	filtro := repository.FiltroBusquedaRestaurantes{
+		Latitud:  &lat,
+		Longitud: &lng,
+		RadioKm:  radioKm,
 	}
+	return rs.paginarRestaurantes(filtro, paginacion, nil)
+}
 
-	resultado := make([]RestauranteConDistancia, 0, len(restaurantes))
+// FiltrosBusqueda define los criterios combinables de la busqueda de restaurantes
+type FiltrosBusqueda struct {
+	Termino             string
+	NombreCategoria     string
+	IDCategorias        []uint
+	IDCaracteristicas   []uint
+	ModoCaracteristicas string // todas (por defecto) o alguna
+	PrecioMin           *float64
+	PrecioMax           *float64
+	BandasPrecio        []string
+	CalificacionMin     *float64
+	IDCiudad            *uint
+	AbiertoAhora        bool
+	Latitud             *float64
+	Longitud            *float64
+	RadioKm             float64
+
+	// PrecioPlatoFuerteMax pide al menos un plato fuerte que cueste eso o menos
+	PrecioPlatoFuerteMax *float64
+
+	// PlatillosConEtiqueta exige un minimo de platillos con cada etiqueta; un minimo de 0 equivale a 1
+	PlatillosConEtiqueta []repository.MinimoPlatillosEtiqueta
+}
 
-	for _, rest := range restaurantes {
-		distancia := calcularDistancia(lat, lng, rest.Latitud, rest.Longitud)
+// ErrFiltroInvalido indica que un filtro de la busqueda no es valido; se compara con errors.Is
+var ErrFiltroInvalido = errors.New("filtro invalido")
 
-		if distancia <= radioKm {
-			tiempoEstimado := calcularTiempoEstimado(distancia)
-			estaAbierto, horarioHoy := verificarHorario(rest.Horarios)
+// ResultadoBusqueda contiene una pagina de restaurantes encontrados y los conteos por faceta
+type ResultadoBusqueda struct {
+	PaginaRestaurantes
+	Facetas *repository.FacetasBusqueda
+}
 
-			resultado = append(resultado, RestauranteConDistancia{
-				Restaurante:    rest,
-				DistanciaKm:    math.Round(distancia*100) / 100,
-				TiempoEstimado: tiempoEstimado,
-				EstaAbierto:    estaAbierto,
-				HorarioHoy:     horarioHoy,
-			})
-		}
+// BuscarRestaurantes aplica todos los filtros recibidos en una sola consulta y calcula las facetas
+func (rs *RestauranteService) BuscarRestaurantes(filtros FiltrosBusqueda, paginacion OpcionesPaginacion) (*ResultadoBusqueda, error) {
+	if filtros.PrecioMin != nil && filtros.PrecioMax != nil && *filtros.PrecioMin > *filtros.PrecioMax {
+		return nil, fmt.Errorf("%w: el precio minimo no puede ser mayor al maximo", ErrFiltroInvalido)
 	}
 
-	// Ordenar por distancia ascendente
-	sort.Slice(resultado, func(i, j int) bool {
-		return resultado[i].DistanciaKm < resultado[j].DistanciaKm
-	})
+	if filtros.PrecioPlatoFuerteMax != nil && *filtros.PrecioPlatoFuerteMax < 0 {
+		return nil, fmt.Errorf("%w: el precio del plato fuerte no puede ser negativo", ErrFiltroInvalido)
+	}
 
-	return resultado, nil
-}
+	if filtros.CalificacionMin != nil && (*filtros.CalificacionMin < 0 || *filtros.CalificacionMin > 5) {
+		return nil, fmt.Errorf("%w: la calificacion minima debe estar entre 0 y 5", ErrFiltroInvalido)
+	}
 
-// BuscarRestaurantes busca por termino, categoria o ubicacion
-func (rs *RestauranteService) BuscarRestaurantes(
-	termino string,
-	nombreCategoria string,
-	idCategoria *uint,
-	lat, lng *float64,
-	radioKm float64,
-) ([]RestauranteConDistancia, error) {
+	if !repository.EsModoCaracteristicasValido(filtros.ModoCaracteristicas) {
+		return nil, fmt.Errorf("%w: modo de caracteristicas invalido, usa: todas o alguna", ErrFiltroInvalido)
+	}
 
-	termino = strings.TrimSpace(termino)
-	nombreCategoria = strings.TrimSpace(nombreCategoria)
+	requisitosEtiquetas := make([]repository.MinimoPlatillosEtiqueta, 0, len(filtros.PlatillosConEtiqueta))
+	for _, requisito := range filtros.PlatillosConEtiqueta {
+		if !EsEtiquetaValida(requisito.Etiqueta) {
+			return nil, fmt.Errorf("%w: etiqueta invalida: %s", ErrFiltroInvalido, requisito.Etiqueta)
+		}
+		if requisito.Minimo < 0 {
+			return nil, fmt.Errorf("%w: el minimo de platillos no puede ser negativo", ErrFiltroInvalido)
+		}
+		requisito.Minimo = max(requisito.Minimo, 1)
+		requisitosEtiquetas = append(requisitosEtiquetas, requisito)
+	}
 
-	var restaurantes []models.Restaurante
-	var err error
+	for _, clave := range filtros.BandasPrecio {
+		if _, ok := repository.ObtenerBandaPrecio(clave); !ok {
+			return nil, fmt.Errorf("%w: banda de precio invalida, usa: $, $$, $$$ o $$$$", ErrFiltroInvalido)
+		}
+	}
 
-	// Buscar segun criterio
-	if idCategoria != nil {
-		restaurantes, err = rs.dbManager.ObtenerRestaurantesPorCategoria(*idCategoria)
-	} else if nombreCategoria != "" {
-		restaurantes, err = rs.dbManager.BuscarRestaurantesPorCategoriaNombre(nombreCategoria)
-	} else if termino != "" {
-		restaurantes, err = rs.dbManager.BuscarRestaurantes(termino)
-	} else {
-		return nil, errors.New("debe proporcionar al menos un criterio de busqueda")
+	filtro := repository.FiltroBusquedaRestaurantes{
+		IDCategorias:        filtros.IDCategorias,
+		NombreCategoria:     strings.TrimSpace(filtros.NombreCategoria),
+		IDCaracteristicas:   filtros.IDCaracteristicas,
+		ModoCaracteristicas: filtros.ModoCaracteristicas,
+		PrecioMin:           filtros.PrecioMin,
+		PrecioMax:           filtros.PrecioMax,
+		BandasPrecio:        filtros.BandasPrecio,
+		CalificacionMin:     filtros.CalificacionMin,
+		IDCiudad:            filtros.IDCiudad,
+		Latitud:             filtros.Latitud,
+		Longitud:            filtros.Longitud,
+		RadioKm:             filtros.RadioKm,
+
+		PrecioPlatoFuerteMax: filtros.PrecioPlatoFuerteMax,
+		PlatillosConEtiqueta: requisitosEtiquetas,
+	}
+	if filtros.AbiertoAhora {
+		if err := filtrarAbiertoAhora(rs.dbManager, &filtro); err != nil {
+			return nil, err
+		}
 	}
 
+	// El termino se resuelve con el indice en memoria; si aun no esta listo se usa LIKE
+	var relevancia map[uint]float64
+	if termino := strings.TrimSpace(filtros.Termino); termino != "" {
+		if rs.indice != nil && rs.indice.Listo() {
+			coincidencias := rs.indice.Buscar(termino, limiteResultadosIndice)
+			relevancia = make(map[uint]float64, len(coincidencias))
+			filtro.IDRestaurantes = make([]uint, 0, len(coincidencias))
+			for _, coincidencia := range coincidencias {
+				relevancia[coincidencia.ID] = coincidencia.Puntuacion
+				filtro.IDRestaurantes = append(filtro.IDRestaurantes, coincidencia.ID)
+			}
+		} else {
+			filtro.Termino = termino
+		}
+	}
+
+	pagina, err := rs.paginarRestaurantes(filtro, paginacion, relevancia)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(restaurantes) == 0 {
-		return []RestauranteConDistancia{}, nil
+	facetas, err := rs.dbManager.ContarFacetasBusqueda(filtro)
+	if err != nil {
+		return nil, err
 	}
 
-	// Si no hay ubicacion, retornar sin calcular distancias
-	if lat == nil || lng == nil {
-		resultado := make([]RestauranteConDistancia, 0, len(restaurantes))
-		for _, rest := range restaurantes {
-			estaAbierto, horarioHoy := verificarHorario(rest.Horarios)
-			resultado = append(resultado, RestauranteConDistancia{
-				Restaurante: rest,
-				DistanciaKm: 0,
-				EstaAbierto: estaAbierto,
-				HorarioHoy:  horarioHoy,
-			})
-		}
-		return resultado, nil
+	return &ResultadoBusqueda{
+		PaginaRestaurantes: *pagina,
+		Facetas:            facetas,
+	}, nil
+}
+
+// ObtenerRestaurantesPorCategoria filtra por categoria con informacion de distancia opcional
+func (rs *RestauranteService) ObtenerRestaurantesPorCategoria(idCategoria uint, lat, lng *float64, paginacion OpcionesPaginacion) (*PaginaRestaurantes, error) {
+	filtro := repository.FiltroBusquedaRestaurantes{
+		IDCategorias: []uint{idCategoria},
+		Latitud:      lat,
+		Longitud:     lng,
 	}
+	return rs.paginarRestaurantes(filtro, paginacion, nil)
+}
 
-	// Calcular distancias y filtrar por radio
-	resultado := make([]RestauranteConDistancia, 0)
+// ObtenerRestaurantesPorCaracteristica filtra por caracteristica con informacion de distancia opcional
+func (rs *RestauranteService) ObtenerRestaurantesPorCaracteristica(idCaracteristica uint, lat, lng *float64, paginacion OpcionesPaginacion) (*PaginaRestaurantes, error) {
+	filtro := repository.FiltroBusquedaRestaurantes{
+		IDCaracteristicas: []uint{idCaracteristica},
+		Latitud:           lat,
+		Longitud:          lng,
+	}
+	return rs.paginarRestaurantes(filtro, paginacion, nil)
+}
 
-	for _, rest := range restaurantes {
-		distancia := calcularDistancia(*lat, *lng, rest.Latitud, rest.Longitud)
+// ReconstruirIndiceBusqueda vuelve a cargar el indice de busqueda y retorna cuantos restaurantes contiene
+func (rs *RestauranteService) ReconstruirIndiceBusqueda() (int, error) {
+	if rs.indice == nil {
+		return 0, errors.New("indice de busqueda no configurado")
+	}
+	if err := rs.indice.Reconstruir(); err != nil {
+		return 0, err
+	}
+	return rs.indice.Total(), nil
+}
 
-		if radioKm > 0 && distancia > radioKm {
-			continue
-		}
+// ObtenerCategorias retorna todas las categorias disponibles
+func (rs *RestauranteService) ObtenerCategorias() ([]models.CategoriaRestaurante, error) {
+	return rs.dbManager.ObtenerTodasLasCategorias()
+}
 
-		tiempoEstimado := calcularTiempoEstimado(distancia)
-		estaAbierto, horarioHoy := verificarHorario(rest.Horarios)
+// ObtenerCaracteristicas retorna todas las caracteristicas disponibles
+func (rs *RestauranteService) ObtenerCaracteristicas() ([]models.CaracteristicaRestaurante, error) {
+	return rs.dbManager.ObtenerTodasLasCaracteristicas()
+}
 
-		resultado = append(resultado, RestauranteConDistancia{
-			Restaurante:    rest,
-			DistanciaKm:    math.Round(distancia*100) / 100,
-			TiempoEstimado: tiempoEstimado,
-			EstaAbierto:    estaAbierto,
-			HorarioHoy:     horarioHoy,
-		})
+// ObtenerCiudades retorna todas las ciudades disponibles
+func (rs *RestauranteService) ObtenerCiudades() ([]models.Ciudad, error) {
+	return rs.dbManager.ObtenerTodasLasCiudades()
+}
+
+// ObtenerRestaurantesPorCiudad aplica los filtros de la busqueda restringidos a una ciudad
+// Si la ciudad no existe retorna gorm.ErrRecordNotFound
+func (rs *RestauranteService) ObtenerRestaurantesPorCiudad(idCiudad uint, filtros FiltrosBusqueda, paginacion OpcionesPaginacion) (*ResultadoBusqueda, error) {
+	if _, err := rs.dbManager.ObtenerCiudadPorID(idCiudad); err != nil {
+		return nil, err
 	}
 
-	sort.Slice(resultado, func(i, j int) bool {
-		return resultado[i].DistanciaKm < resultado[j].DistanciaKm
-	})
+	filtros.IDCiudad = &idCiudad
+	return rs.BuscarRestaurantes(filtros, paginacion)
+}
 
-	return resultado, nil
+// restaurantesDestacadosCiudad es cuantos restaurantes mejor calificados incluye el resumen de una ciudad
+const restaurantesDestacadosCiudad = 5
+
+// Coordenada es un punto geografico
+type Coordenada struct {
+	Latitud  float64 `json:"latitud"`
+	Longitud float64 `json:"longitud"`
 }
 
-// ObtenerRestaurantesPorCategoria filtra por categoria con informacion de distancia opcional
-func (rs *RestauranteService) ObtenerRestaurantesPorCategoria(idCategoria uint, lat, lng *float64) ([]RestauranteConDistancia, error) {
-	restaurantes, err := rs.dbManager.ObtenerRestaurantesPorCategoria(idCategoria)
+// ResumenCiudad agrupa las estadisticas de una ciudad para su pagina de exploracion
+type ResumenCiudad struct {
+	Ciudad               models.Ciudad                  `json:"ciudad"`
+	Centro               *Coordenada                    `json:"centro"`
+	TotalRestaurantes    int64                          `json:"totalRestaurantes"`
+	PrecioPromedio       *float64                       `json:"precioPromedio"`
+	CalificacionPromedio *float64                       `json:"calificacionPromedio"`
+	Categorias           []repository.FacetaConteo      `json:"categorias"`
+	BandasPrecio         []repository.FacetaBandaPrecio `json:"bandasPrecio"`
+	MejorCalificados     []RestauranteConDistancia      `json:"mejorCalificados"`
+}
+
+// ObtenerResumenCiudad retorna conteo, distribucion por categoria, precio promedio,
+// mejores calificados y centro de la ciudad
+// El centro es el registrado en la ciudad; si no tiene coordenadas se usa el de sus restaurantes
+// Si la ciudad no existe retorna gorm.ErrRecordNotFound
+func (rs *RestauranteService) ObtenerResumenCiudad(idCiudad uint) (*ResumenCiudad, error) {
+	ciudad, err := rs.dbManager.ObtenerCiudadPorID(idCiudad)
 	if err != nil {
 		return nil, err
 	}
 
-	resultado := make([]RestauranteConDistancia, 0, len(restaurantes))
-
-	for _, rest := range restaurantes {
-		estaAbierto, horarioHoy := verificarHorario(rest.Horarios)
-
-		item := RestauranteConDistancia{
-			Restaurante: rest,
-			EstaAbierto: estaAbierto,
-			HorarioHoy:  horarioHoy,
-		}
-
-		if lat != nil && lng != nil {
-			distancia := calcularDistancia(*lat, *lng, rest.Latitud, rest.Longitud)
-			item.DistanciaKm = math.Round(distancia*100) / 100
-			item.TiempoEstimado = calcularTiempoEstimado(distancia)
-		}
+	estadisticas, err := rs.dbManager.ObtenerEstadisticasCiudad(idCiudad)
+	if err != nil {
+		return nil, err
+	}
 
-		resultado = append(resultado, item)
+	filtro := repository.FiltroBusquedaRestaurantes{IDCiudad: &idCiudad}
+	facetas, err := rs.dbManager.ContarFacetasBusqueda(filtro)
+	if err != nil {
+		return nil, err
 	}
 
-	if lat != nil && lng != nil {
-		sort.Slice(resultado, func(i, j int) bool {
-			return resultado[i].DistanciaKm < resultado[j].DistanciaKm
-		})
+	mejores, err := rs.paginarRestaurantes(filtro, OpcionesPaginacion{
+		Limite: restaurantesDestacadosCiudad,
+		Orden:  repository.OrdenRestaurantesCalificacion,
+	}, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return resultado, nil
-}
+	resumen := &ResumenCiudad{
+		Ciudad:               *ciudad,
+		TotalRestaurantes:    estadisticas.TotalRestaurantes,
+		PrecioPromedio:       estadisticas.PrecioPromedio,
+		CalificacionPromedio: estadisticas.CalificacionPromedio,
+		Categorias:           facetas.Categorias,
+		BandasPrecio:         facetas.BandasPrecio,
+		MejorCalificados:     mejores.Restaurantes,
+	}
 
-// ObtenerCategorias retorna todas las categorias disponibles
-func (rs *RestauranteService) ObtenerCategorias() ([]models.CategoriaRestaurante, error) {
-	return rs.dbManager.ObtenerTodasLasCategorias()
-}
+	switch {
+	case ciudad.Latitud != 0 || ciudad.Longitud != 0:
+		resumen.Centro = &Coordenada{Latitud: ciudad.Latitud, Longitud: ciudad.Longitud}
+	case estadisticas.LatitudPromedio != nil && estadisticas.LongitudPromedio != nil:
+		resumen.Centro = &Coordenada{
+			Latitud:  math.Round(*estadisticas.LatitudPromedio*1e6) / 1e6,
+			Longitud: math.Round(*estadisticas.LongitudPromedio*1e6) / 1e6,
+		}
+	}
 
-// ObtenerCiudades retorna todas las ciudades disponibles
-func (rs *RestauranteService) ObtenerCiudades() ([]models.Ciudad, error) {
-	return rs.dbManager.ObtenerTodasLasCiudades()
+	return resumen, nil
 }
 
 // AgregarFavorito marca un restaurante como favorito
@@ -213,26 +347,64 @@ func (rs *RestauranteService) EliminarFavorito(idUsuario, idRestaurante uint) er
 	return rs.dbManager.EliminarFavorito(idUsuario, idRestaurante)
 }
 
-// ObtenerFavoritos lista todos los favoritos del usuario
-func (rs *RestauranteService) ObtenerFavoritos(idUsuario uint, lat, lng *float64) ([]RestauranteConDistancia, error) {
-	restaurantes, err := rs.dbManager.ObtenerFavoritosUsuario(idUsuario)
+// ObtenerFavoritos lista los favoritos del usuario con informacion de distancia opcional
+func (rs *RestauranteService) ObtenerFavoritos(idUsuario uint, lat, lng *float64, paginacion OpcionesPaginacion) (*PaginaRestaurantes, error) {
+	filtro := repository.FiltroBusquedaRestaurantes{
+		IDUsuarioFavorito: &idUsuario,
+		Latitud:           lat,
+		Longitud:          lng,
+	}
+	return rs.paginarRestaurantes(filtro, paginacion, nil)
+}
+
+// paginarRestaurantes obtiene una pagina del filtro y agrega distancia y horario a cada restaurante
+// Sin orden explicito usa distancia si hay ubicacion, aunque haya termino; relevancia si solo hay
+// termino y calificacion en otro caso
+func (rs *RestauranteService) paginarRestaurantes(filtro repository.FiltroBusquedaRestaurantes, paginacion OpcionesPaginacion, relevancia map[uint]float64) (*PaginaRestaurantes, error) {
+	conUbicacion := filtro.Latitud != nil && filtro.Longitud != nil
+
+	orden := paginacion.Orden
+	if orden == "" {
+		switch {
+		case conUbicacion:
+			orden = repository.OrdenRestaurantesDistancia
+		case relevancia != nil:
+			orden = repository.OrdenRestaurantesRelevancia
+		default:
+			orden = repository.OrdenRestaurantesCalificacion
+		}
+	}
+	if !repository.EsOrdenRestaurantesValido(orden) {
+		return nil, fmt.Errorf("%w, usa: distancia, calificacion, precio, nombre, recientes o relevancia", repository.ErrOrdenInvalido)
+	}
+
+	limite := NormalizarLimiteRestaurantes(paginacion.Limite)
+
+	pagina, err := rs.dbManager.PaginarRestaurantes(filtro, repository.PaginacionRestaurantes{
+		Limite:   limite,
+		Orden:    orden,
+		Cursor:   paginacion.Cursor,
+		Latitud:  filtro.Latitud,
+		Longitud: filtro.Longitud,
+		OrdenIDs: filtro.IDRestaurantes,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	resultado := make([]RestauranteConDistancia, 0, len(restaurantes))
-
-	for _, rest := range restaurantes {
-		estaAbierto, horarioHoy := verificarHorario(rest.Horarios)
+	resultado := make([]RestauranteConDistancia, 0, len(pagina.Restaurantes))
+	for _, rest := range pagina.Restaurantes {
+		estaAbierto, horarioHoy := verificarHorario(rest.Horarios, rest.Excepciones, rest.Ciudad.ZonaHoraria)
 
 		item := RestauranteConDistancia{
 			Restaurante: rest,
 			EstaAbierto: estaAbierto,
 			HorarioHoy:  horarioHoy,
+			Relevancia:  relevancia[rest.IDRestaurante],
 		}
 
-		if lat != nil && lng != nil {
-			distancia := calcularDistancia(*lat, *lng, rest.Latitud, rest.Longitud)
+		if conUbicacion {
+			distancia := calcularDistancia(*filtro.Latitud, *filtro.Longitud, rest.Latitud, rest.Longitud)
 			item.DistanciaKm = math.Round(distancia*100) / 100
 			item.TiempoEstimado = calcularTiempoEstimado(distancia)
 		}
@@ -240,7 +412,24 @@ func (rs *RestauranteService) ObtenerFavoritos(idUsuario uint, lat, lng *float64
 		resultado = append(resultado, item)
 	}
 
-	return resultado, nil
+	return &PaginaRestaurantes{
+		Restaurantes:    resultado,
+		SiguienteCursor: pagina.SiguienteCursor,
+		TotalEstimado:   pagina.TotalEstimado,
+		Orden:           orden,
+		Limite:          limite,
+	}, nil
+}
+
+// NormalizarLimiteRestaurantes ajusta el tamaño de pagina de los listados de restaurantes
+func NormalizarLimiteRestaurantes(limite int) int {
+	if limite <= 0 {
+		return LimiteRestaurantesPorDefecto
+	}
+	if limite > LimiteRestaurantesMaximo {
+		return LimiteRestaurantesMaximo
+	}
+	return limite
 }
 
 // calcularDistancia usa la formula de Haversine para calcular distancia entre coordenadas
@@ -261,11 +450,10 @@ func calcularDistancia(lat1, lng1, lat2, lng2 float64) float64 {
 	return R * c
 }
 
-// calcularTiempoEstimado estima tiempo de llegada en rangos
+// calcularTiempoEstimado estima tiempo de llegada en rangos con el modo de viaje por defecto,
+// el mismo con el que se calculan los trayectos de los tours
 func calcularTiempoEstimado(distanciaKm float64) string {
-	velocidadPromedio := 20.0
-	tiempoHoras := distanciaKm / velocidadPromedio
-	tiempoMinutos := int(tiempoHoras * 60)
+	tiempoMinutos := int(ModosViajePorDefecto[ModoViajePorDefecto].Duracion(distanciaKm).Minutes())
 
 	if tiempoMinutos < 5 {
 		return "2-5 min"
@@ -280,39 +468,3 @@ func calcularTiempoEstimado(distanciaKm float64) string {
 	}
 	return "30+ min"
 }
-
-// verificarHorario determina si un restaurante esta abierto segun el dia y hora actual
-func verificarHorario(horarios []models.Horario) (bool, string) {
-	if len(horarios) == 0 {
-		return false, ""
-	}
-
-	ahora := time.Now()
-	diaActual := int(ahora.Weekday())
-	if diaActual == 0 {
-		diaActual = 7 // Domingo como 7
-	}
-
-	var horarioHoy *models.Horario
-	for i := range horarios {
-		if int(horarios[i].Dia) == diaActual {
-			horarioHoy = &horarios[i]
-			break
-		}
-	}
-
-	if horarioHoy == nil {
-		return false, "Cerrado hoy"
-	}
-
-	if horarioHoy.Cerrado {
-		return false, "Cerrado hoy"
-	}
-
-	horarioTexto := horarioHoy.Apertura + " - " + horarioHoy.Cierre
-
-	horaActual := ahora.Format("15:04:05")
-	estaAbierto := horaActual >= horarioHoy.Apertura && horaActual <= horarioHoy.Cierre
-
-	return estaAbierto, horarioTexto
-}
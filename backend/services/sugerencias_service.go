@@ -0,0 +1,176 @@
+package services
+
+import (
+	"log"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/tuusuario/quovi/algorithms"
+	"github.com/tuusuario/quovi/repository"
+)
+
+// Tipos de sugerencia que devuelve el autocompletado
+const (
+	TipoSugerenciaBusqueda    = "busqueda"
+	TipoSugerenciaRestaurante = "restaurante"
+	TipoSugerenciaCategoria   = "categoria"
+	TipoSugerenciaPlatillo    = "platillo"
+	TipoSugerenciaCiudad      = "ciudad"
+)
+
+const (
+	// sugerenciasPorNodo es cuantas sugerencias precalcula el trie por prefijo
+	sugerenciasPorNodo = 30
+
+	// busquedasPopularesSugeridas es cuantos textos populares entran al autocompletado
+	busquedasPopularesSugeridas = 200
+
+	// usuariosMinimosSugerencia es cuantos usuarios distintos deben buscar un texto para sugerirlo a todos,
+	// asi una consulta que escribio una sola persona no aparece en el autocompletado de los demas
+	usuariosMinimosSugerencia = 3
+
+	limiteSugerenciasPorDefecto = 8
+	limiteSugerenciasMaximo     = 20
+
+	// pesoBusquedaPopular coloca las busquedas populares antes que cualquier nombre del catalogo
+	pesoBusquedaPopular = 1000.0
+)
+
+// pesoBaseSugerencia ordena los tipos del catalogo cuando su popularidad es similar
+var pesoBaseSugerencia = map[string]float64{
+	TipoSugerenciaRestaurante: 3,
+	TipoSugerenciaCategoria:   3,
+	TipoSugerenciaCiudad:      2,
+	TipoSugerenciaPlatillo:    1,
+}
+
+// SugerenciasService responde el autocompletado de la barra de busqueda desde un trie en memoria
+// El trie se reconstruye cuando el indice de restaurantes detecta cambios y periodicamente
+// para incorporar las busquedas populares
+type SugerenciasService struct {
+	dbManager *repository.DBManager
+
+	trie       atomic.Pointer[algorithms.Trie]
+	mu         sync.Mutex // Serializa reconstrucciones
+	pendiente  atomic.Bool
+	construido time.Time
+}
+
+// NewSugerenciasService crea el servicio y lo suscribe a los cambios del catalogo
+func NewSugerenciasService(dbManager *repository.DBManager, indice *IndiceRestaurantes) *SugerenciasService {
+	ss := &SugerenciasService{dbManager: dbManager}
+	if indice != nil {
+		indice.AlCambiar(func() { ss.pendiente.Store(true) })
+	}
+	return ss
+}
+
+// Sugerir retorna las sugerencias que completan el prefijo, primero las busquedas populares
+// Un mismo texto aparece una sola vez aunque exista como busqueda y como nombre del catalogo
+func (ss *SugerenciasService) Sugerir(prefijo string, limite int) []algorithms.Sugerencia {
+	if limite <= 0 {
+		limite = limiteSugerenciasPorDefecto
+	}
+	if limite > limiteSugerenciasMaximo {
+		limite = limiteSugerenciasMaximo
+	}
+
+	trie := ss.trie.Load()
+	if trie == nil {
+		return []algorithms.Sugerencia{}
+	}
+
+	vistos := make(map[string]bool)
+	resultado := make([]algorithms.Sugerencia, 0, limite)
+	for _, sugerencia := range trie.BuscarPrefijo(prefijo, sugerenciasPorNodo) {
+		clave := algorithms.NormalizarPrefijo(sugerencia.Texto)
+		if vistos[clave] {
+			continue
+		}
+		vistos[clave] = true
+
+		resultado = append(resultado, sugerencia)
+		if len(resultado) == limite {
+			break
+		}
+	}
+
+	return resultado
+}
+
+// Reconstruir vuelve a armar el trie con el catalogo y las busquedas populares actuales
+func (ss *SugerenciasService) Reconstruir() error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	// Se limpia antes de leer para no perder un cambio que llegue durante la reconstruccion
+	ss.pendiente.Store(false)
+
+	catalogo, err := ss.dbManager.ObtenerCatalogoSugerencias()
+	if err != nil {
+		ss.pendiente.Store(true)
+		return err
+	}
+
+	desde := time.Now().AddDate(0, 0, -diasBusquedasPopulares)
+	populares, err := ss.dbManager.ObtenerBusquedasPopulares(desde, nil, usuariosMinimosSugerencia, busquedasPopularesSugeridas)
+	if err != nil {
+		ss.pendiente.Store(true)
+		return err
+	}
+
+	trie := algorithms.NewTrie(sugerenciasPorNodo)
+
+	for _, popular := range populares {
+		trie.Insertar(algorithms.Sugerencia{
+			Tipo:  TipoSugerenciaBusqueda,
+			Texto: popular.Texto,
+			Peso:  pesoBusquedaPopular + float64(popular.Total),
+		})
+	}
+
+	agregarElementos := func(tipo string, elementos []repository.ElementoCatalogo) {
+		for _, elemento := range elementos {
+			trie.Insertar(algorithms.Sugerencia{
+				Tipo:  tipo,
+				ID:    elemento.ID,
+				Texto: elemento.Nombre,
+				Peso:  pesoBaseSugerencia[tipo] + math.Log1p(elemento.Popularidad),
+			})
+		}
+	}
+	agregarElementos(TipoSugerenciaRestaurante, catalogo.Restaurantes)
+	agregarElementos(TipoSugerenciaCategoria, catalogo.Categorias)
+	agregarElementos(TipoSugerenciaPlatillo, catalogo.Platillos)
+	agregarElementos(TipoSugerenciaCiudad, catalogo.Ciudades)
+
+	ss.trie.Store(trie)
+	ss.construido = time.Now()
+
+	return nil
+}
+
+// IniciarRefresco revisa cada intervalo si el catalogo cambio y reconstruye el trie;
+// aunque no haya cambios lo reconstruye cada intervaloPopulares para actualizar las busquedas populares
+func (ss *SugerenciasService) IniciarRefresco(intervalo, intervaloPopulares time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ss.mu.Lock()
+			vencido := time.Since(ss.construido) >= intervaloPopulares
+			ss.mu.Unlock()
+
+			if !ss.pendiente.Load() && !vencido {
+				continue
+			}
+
+			if err := ss.Reconstruir(); err != nil {
+				log.Printf("Error al reconstruir sugerencias de busqueda: %v", err)
+			}
+		}
+	}()
+}
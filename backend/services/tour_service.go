@@ -1,20 +1,369 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/tuusuario/quovi/algorithms"
 	"github.com/tuusuario/quovi/models"
 	"github.com/tuusuario/quovi/repository"
+	"github.com/tuusuario/quovi/utils"
 )
 
+// MaxRestaurantesTour limita las paradas de un tour; por encima la cota inferior deja de ser barata
+const MaxRestaurantesTour = 100
+
+// ConfiguracionTour define cuanto esfuerzo se dedica a optimizar cada tour
+type ConfiguracionTour struct {
+	MaxParadasExacto        int                  // Paradas hasta las que la ruta es optima; 0 o mas que algorithms.MaxParadasExacto usa ese limite
+	PresupuestoOptimizacion time.Duration        // Tiempo de mejora local para tours mas grandes; 0 usa el valor por defecto
+	ModosViaje              map[string]ModoViaje // Los modos que falten o sean invalidos usan ModosViajePorDefecto
+	RedVial                 *algorithms.RedVial  // Calles para medir los trayectos; nil usa linea recta con rodeo
+}
+
 // TourService maneja la logica de negocio para tours gastronomicos
 type TourService struct {
-	repo *repository.DBManager
+	repo   *repository.DBManager
+	config ConfiguracionTour
 }
 
 // NewTourService crea una nueva instancia del servicio
-func NewTourService(repo *repository.DBManager) *TourService {
+func NewTourService(repo *repository.DBManager, config ConfiguracionTour) *TourService {
+	modos := make(map[string]ModoViaje, len(ModosViajePorDefecto))
+	for nombre, modo := range ModosViajePorDefecto {
+		if propio, ok := config.ModosViaje[nombre]; ok && propio.VelocidadKmH > 0 && propio.FactorRodeo >= 1 && propio.TiempoFijo >= 0 {
+			modo = propio
+		}
+		modos[nombre] = modo
+	}
+	config.ModosViaje = modos
+
 	return &TourService{
-		repo: repo,
+		repo:   repo,
+		config: config,
+	}
+}
+
+// Agenda de los tours
+const (
+	EstanciaTourPorDefecto = 30 * time.Minute
+	horizonteTour          = 7 * 24 * time.Hour // Periodo en que se buscan horarios de atencion
+)
+
+// Formas de terminar un tour
+const (
+	ModoTourAbierto  = "abierto"  // Termina en el ultimo restaurante
+	ModoTourCircular = "circular" // Regresa al origen
+	ModoTourDestino  = "destino"  // Termina en un punto fijo, como un hotel
+)
+
+// SolicitudTour son los datos con los que se arma un tour
+type SolicitudTour struct {
+	Origen        algorithms.Coordenada
+	Inicio        time.Time              // Hora de salida desde el origen
+	Estancia      time.Duration          // Permanencia en cada restaurante; 0 usa EstanciaTourPorDefecto
+	Estancias     map[uint]time.Duration // Permanencia propia de algunos restaurantes
+	Regresar      bool                   // Cierra el recorrido en el origen
+	Destino       *algorithms.Coordenada // Punto donde termina el tour; no se combina con Regresar
+	PrimeraParada uint                   // Restaurante que se visita primero; 0 lo elige el optimizador
+	UltimaParada  uint                   // Restaurante que se visita al final; 0 lo elige el optimizador
+	ModoViaje     string                 // Como se desplaza el usuario; vacio usa ModoViajePorDefecto
+	Objetivo      string                 // ObjetivoDistancia o ObjetivoTiempo; vacio minimiza la distancia
+}
+
+// Modo indica como termina el tour solicitado
+func (s SolicitudTour) Modo() string {
+	switch {
+	case s.Regresar:
+		return ModoTourCircular
+	case s.Destino != nil:
+		return ModoTourDestino
+	}
+	return ModoTourAbierto
+}
+
+// VisitaTour es una parada programada; las horas estan en la zona de la ciudad del restaurante
+type VisitaTour struct {
+	Restaurante  models.Restaurante
+	Llegada      time.Time
+	InicioVisita time.Time // Llegada mas la espera hasta que abra
+	Salida       time.Time
+	Espera       time.Duration
+	DistanciaKm  float64       // Desde el punto anterior, por calles o estimada con el rodeo del modo de viaje
+	Trayecto     time.Duration // Desde el punto anterior en el modo de viaje
+	PorCalles    bool          // La distancia y el trazado siguen la red vial
+	Trazado      []algorithms.Coordenada
+	SinHorario   bool // El restaurante no tiene horarios registrados y no se verifico
+}
+
+// ParadaNoProgramada es un restaurante que no se puede visitar dentro de su horario
+type ParadaNoProgramada struct {
+	Restaurante models.Restaurante
+	Motivo      string
+}
+
+// TramoFinal es el trayecto del ultimo restaurante al punto donde termina el tour
+type TramoFinal struct {
+	Destino     algorithms.Coordenada
+	DistanciaKm float64
+	Trayecto    time.Duration
+	Llegada     time.Time
+	PorCalles   bool
+	Trazado     []algorithms.Coordenada
+}
+
+// TourOptimizado es la agenda calculada para un conjunto de restaurantes
+type TourOptimizado struct {
+	Modo              string
+	ModoViaje         string
+	Objetivo          string
+	Visitas           []VisitaTour // En orden de visita
+	NoProgramados     []ParadaNoProgramada
+	TramoFinal        *TramoFinal // Regreso al origen o llegada al destino; nil en tours abiertos
+	Inicio            time.Time
+	Fin               time.Time // Llegada al punto final o salida del ultimo restaurante
+	DistanciaKm       float64
+	CotaInferiorKm    float64       // Con ObjetivoDistancia, ninguna ruta por los mismos restaurantes puede ser mas corta
+	CotaInferior      time.Duration // Con ObjetivoTiempo, ningun tour por los mismos restaurantes puede durar menos
+	BrechaOptimalidad float64       // Fraccion maxima por encima del optimo en el objetivo; 0 si la ruta es exacta
+	Metodo            string
+	Exacto            bool
+}
+
+// OptimizarRuta programa la visita a los restaurantes desde el origen con la menor distancia o, con
+// ObjetivoTiempo, terminando lo antes posible contando trayectos, esperas y estancias. Los horarios
+// de cada restaurante, en la hora local de su ciudad, son ventanas de tiempo: se espera si se llega antes
+// de que abra y la estancia debe terminar antes del cierre. Los que no caben se reportan sin programar.
+// El tour puede regresar al origen o terminar en un destino, y el primer y el ultimo restaurante pueden
+// fijarse; los demas, incluido el primero si no se fija, los elige el optimizador.
+// La ruta es optima hasta MaxParadasExacto paradas; con mas se reporta la brecha contra la cota inferior
+func (ts *TourService) OptimizarRuta(restaurantes []models.Restaurante, solicitud SolicitudTour) (*TourOptimizado, error) {
+	if len(restaurantes) == 0 {
+		return nil, errors.New("se requiere al menos un restaurante")
+	}
+	if len(restaurantes) > MaxRestaurantesTour {
+		return nil, fmt.Errorf("un tour admite como maximo %d restaurantes", MaxRestaurantesTour)
+	}
+	if solicitud.Estancia <= 0 {
+		solicitud.Estancia = EstanciaTourPorDefecto
+	}
+	if solicitud.Regresar && solicitud.Destino != nil {
+		return nil, errors.New("un tour no puede regresar al origen y terminar en un destino a la vez")
+	}
+	if solicitud.PrimeraParada != 0 && solicitud.PrimeraParada == solicitud.UltimaParada && len(restaurantes) > 1 {
+		return nil, errors.New("un restaurante no puede ser la primera y la ultima parada a la vez")
+	}
+	if solicitud.ModoViaje == "" {
+		solicitud.ModoViaje = ModoViajePorDefecto
+	}
+	modo, ok := ts.config.ModosViaje[solicitud.ModoViaje]
+	if !ok {
+		return nil, fmt.Errorf("modo de viaje %q no soportado", solicitud.ModoViaje)
+	}
+	switch solicitud.Objetivo {
+	case "":
+		solicitud.Objetivo = ObjetivoDistancia
+	case ObjetivoDistancia, ObjetivoTiempo:
+	default:
+		return nil, fmt.Errorf("objetivo %q no soportado, usa %q o %q", solicitud.Objetivo, ObjetivoDistancia, ObjetivoTiempo)
+	}
+	porTiempo := solicitud.Objetivo == ObjetivoTiempo
+
+	paradas := make([]algorithms.Coordenada, len(restaurantes))
+	for i, rest := range restaurantes {
+		paradas[i] = algorithms.Coordenada{Latitud: rest.Latitud, Longitud: rest.Longitud}
+	}
+
+	// Indices de los puntos: 0 es el origen, i+1 el restaurante i y al final el destino si lo hay
+	puntos := append([]algorithms.Coordenada{solicitud.Origen}, paradas...)
+	destino, indiceFinal := solicitud.Destino, 0
+	if solicitud.Regresar {
+		destino = &solicitud.Origen
+	}
+	if solicitud.Destino != nil {
+		puntos = append(puntos, *solicitud.Destino)
+		indiceFinal = len(puntos) - 1
+	}
+	recorridos, porCalles := ts.recorridos(puntos, modo)
+
+	nodos := len(paradas) + 1
+	distancias := make([][]float64, nodos)
+	ventanas := &algorithms.VentanasTiempo{
+		Tiempos:           make([][]float64, nodos),
+		Estancias:         make([]float64, nodos),
+		Ventanas:          make([][]algorithms.Intervalo, nodos),
+		MinimizarDuracion: porTiempo,
+	}
+	for i := range distancias {
+		distancias[i] = recorridos[i][:nodos]
+		ventanas.Tiempos[i] = make([]float64, nodos)
+		for j, distancia := range distancias[i] {
+			ventanas.Tiempos[i][j] = modo.DuracionRecorrido(distancia).Minutes()
+		}
+	}
+	costos := distancias
+	if porTiempo {
+		costos = ventanas.Tiempos
+	}
+
+	// Trayecto desde cada nodo hasta donde termina el tour
+	var costosFinal []float64
+	if destino != nil {
+		costosFinal = make([]float64, nodos)
+		for i := range costosFinal {
+			costosFinal[i] = recorridos[i][indiceFinal]
+			if porTiempo {
+				costosFinal[i] = modo.DuracionRecorrido(recorridos[i][indiceFinal]).Minutes()
+			}
+		}
+	}
+
+	primera, ultima := 0, 0
+	hasta := solicitud.Inicio.Add(horizonteTour)
+	for i, rest := range restaurantes {
+		switch rest.IDRestaurante {
+		case solicitud.PrimeraParada:
+			primera = i + 1
+		case solicitud.UltimaParada:
+			ultima = i + 1
+		}
+
+		estancia := solicitud.Estancia
+		if propia, ok := solicitud.Estancias[rest.IDRestaurante]; ok && propia > 0 {
+			estancia = propia
+		}
+		ventanas.Estancias[i+1] = estancia.Minutes()
+
+		if len(rest.Horarios) == 0 && len(rest.Excepciones) == 0 {
+			continue // Sin horarios registrados no se restringe
+		}
+		intervalos := []algorithms.Intervalo{}
+		for _, periodo := range periodosAtencion(rest.Horarios, rest.Excepciones, rest.Ciudad.ZonaHoraria, solicitud.Inicio, hasta) {
+			intervalos = append(intervalos, algorithms.Intervalo{
+				Inicio: periodo.Inicio.Sub(solicitud.Inicio).Minutes(),
+				Fin:    periodo.Fin.Sub(solicitud.Inicio).Minutes(),
+			})
+		}
+		ventanas.Ventanas[i+1] = intervalos
+	}
+
+	if solicitud.PrimeraParada != 0 && primera == 0 || solicitud.UltimaParada != 0 && ultima == 0 {
+		return nil, errors.New("las paradas fijas deben estar entre los restaurantes del tour")
+	}
+
+	resultado, err := algorithms.OptimizarTour(costos, algorithms.OpcionesOptimizacion{
+		MaxParadasExacto: ts.config.MaxParadasExacto,
+		Presupuesto:      ts.config.PresupuestoOptimizacion,
+		Ventanas:         ventanas,
+		CostosFinal:      costosFinal,
+		Primera:          primera,
+		Ultima:           ultima,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tour := &TourOptimizado{
+		Modo:              solicitud.Modo(),
+		ModoViaje:         solicitud.ModoViaje,
+		Objetivo:          solicitud.Objetivo,
+		Inicio:            solicitud.Inicio,
+		Fin:               solicitud.Inicio,
+		BrechaOptimalidad: resultado.Brecha(),
+		Metodo:            resultado.Metodo,
+		Exacto:            resultado.Exacto,
+	}
+
+	minuto := func(minutos float64) time.Time {
+		return solicitud.Inicio.Add(time.Duration(minutos * float64(time.Minute))).Round(time.Second)
+	}
+	anterior := 0
+	for _, visita := range resultado.Agenda {
+		rest := restaurantes[visita.Parada-1]
+		zona := utils.CargarZonaHoraria(rest.Ciudad.ZonaHoraria)
+		tour.Visitas = append(tour.Visitas, VisitaTour{
+			Restaurante:  rest,
+			Llegada:      minuto(visita.Llegada).In(zona),
+			InicioVisita: minuto(visita.Inicio).In(zona),
+			Salida:       minuto(visita.Salida).In(zona),
+			Espera:       minuto(visita.Inicio).Sub(minuto(visita.Llegada)),
+			DistanciaKm:  distancias[anterior][visita.Parada],
+			Trayecto:     modo.DuracionRecorrido(distancias[anterior][visita.Parada]),
+			PorCalles:    porCalles[anterior][visita.Parada],
+			Trazado:      ts.trazado(puntos[anterior], puntos[visita.Parada], modo, porCalles[anterior][visita.Parada]),
+			SinHorario:   ventanas.Ventanas[visita.Parada] == nil,
+		})
+		tour.Fin = minuto(visita.Salida)
+		tour.DistanciaKm += distancias[anterior][visita.Parada]
+		anterior = visita.Parada
+	}
+
+	if destino != nil {
+		distancia := recorridos[anterior][indiceFinal]
+		trayecto := modo.DuracionRecorrido(distancia)
+		tour.TramoFinal = &TramoFinal{
+			Destino:     *destino,
+			DistanciaKm: distancia,
+			Trayecto:    trayecto,
+			Llegada:     tour.Fin.Add(trayecto),
+			PorCalles:   porCalles[anterior][indiceFinal],
+			Trazado:     ts.trazado(puntos[anterior], *destino, modo, porCalles[anterior][indiceFinal]),
+		}
+		tour.Fin = tour.TramoFinal.Llegada
+		tour.DistanciaKm += tour.TramoFinal.DistanciaKm
+	}
+
+	if porTiempo {
+		tour.CotaInferior = time.Duration(resultado.CotaInferior * float64(time.Minute)).Round(time.Second)
+	} else {
+		tour.CotaInferiorKm = resultado.CotaInferior
+	}
+
+	for _, parada := range resultado.Omitidas {
+		motivo := "No alcanza a visitarse dentro de su horario de atencion"
+		if len(ventanas.Ventanas[parada]) == 0 {
+			motivo = "Cerrado durante el periodo del tour"
+		}
+		tour.NoProgramados = append(tour.NoProgramados, ParadaNoProgramada{Restaurante: restaurantes[parada-1], Motivo: motivo})
+	}
+
+	return tour, nil
+}
+
+// recorridos mide los kilometros entre cada par de puntos: por calles si hay red vial y existe ruta,
+// o con la linea recta y el rodeo del modo de viaje si no. Indica que pares siguen las calles
+func (ts *TourService) recorridos(puntos []algorithms.Coordenada, modo ModoViaje) ([][]float64, [][]bool) {
+	var porRed [][]float64
+	if ts.config.RedVial != nil {
+		porRed = ts.config.RedVial.MatrizRutas(puntos, modo.Perfil)
+	}
+
+	distancias := make([][]float64, len(puntos))
+	porCalles := make([][]bool, len(puntos))
+	for i := range puntos {
+		distancias[i] = make([]float64, len(puntos))
+		porCalles[i] = make([]bool, len(puntos))
+		for j := range puntos {
+			if porRed != nil && !math.IsInf(porRed[i][j], 1) {
+				distancias[i][j], porCalles[i][j] = porRed[i][j], true
+			} else {
+				distancias[i][j] = modo.Distancia(algorithms.DistanciaHaversine(puntos[i], puntos[j]))
+			}
+		}
+	}
+	return distancias, porCalles
+}
+
+// trazado es la polilinea de un trayecto; sin ruta por calles es la linea recta entre los puntos
+func (ts *TourService) trazado(desde, hasta algorithms.Coordenada, modo ModoViaje, porCalles bool) []algorithms.Coordenada {
+	if porCalles {
+		if ruta, err := ts.config.RedVial.Ruta(desde, hasta, modo.Perfil); err == nil {
+			return ruta.Puntos
+		}
 	}
+	return []algorithms.Coordenada{desde, hasta}
 }
 
 // ObtenerRestaurantesPorIDs obtiene informacion de varios restaurantes
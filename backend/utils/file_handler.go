@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,38 +15,72 @@ import (
 
 const (
 	MaxFileSize      = 5 * 1024 * 1024 // 5MB
+	UploadRoot       = "./uploads"
 	UploadDir        = "./uploads/avatars"
+	UploadDirResenas = "./uploads/resenas"
 	AllowedMimeTypes = "image/jpeg,image/png,image/jpg,image/gif,image/webp"
 )
 
-// ValidarImagen verifica tamaño y tipo MIME del archivo
-func ValidarImagen(file *multipart.FileHeader) error {
+// extensionesPorMime asocia cada tipo MIME permitido con la extension que se guarda en disco
+var extensionesPorMime = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// ValidarImagen verifica tamaño y tipo MIME real del archivo y retorna la extension segura
+// El tipo se detecta por el contenido; el Content-Type y la extension enviados por el cliente no se usan
+func ValidarImagen(file *multipart.FileHeader) (string, error) {
 	if file.Size > MaxFileSize {
-		return errors.New("el archivo excede el tamaño máximo permitido (5MB)")
+		return "", errors.New("el archivo excede el tamaño máximo permitido (5MB)")
 	}
 
-	mimeType := file.Header.Get("Content-Type")
-	if !strings.Contains(AllowedMimeTypes, mimeType) {
-		return fmt.Errorf("tipo de archivo no permitido. Solo se aceptan: %s", AllowedMimeTypes)
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("error al abrir archivo: %v", err)
 	}
+	defer src.Close()
 
-	return nil
+	cabecera := make([]byte, 512)
+	n, err := io.ReadFull(src, cabecera)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", errors.New("no se pudo leer el archivo")
+	}
+
+	mimeType := http.DetectContentType(cabecera[:n])
+	extension, ok := extensionesPorMime[mimeType]
+	if !ok {
+		return "", fmt.Errorf("tipo de archivo no permitido. Solo se aceptan: %s", AllowedMimeTypes)
+	}
+
+	return extension, nil
 }
 
-// GuardarImagen guarda el archivo en el servidor y retorna la URL
+// GuardarImagen guarda el avatar del usuario en el servidor y retorna la URL
 func GuardarImagen(file *multipart.FileHeader, userID uint) (string, error) {
-	if err := ValidarImagen(file); err != nil {
+	return GuardarImagenEn(file, UploadDir, fmt.Sprintf("user_%d", userID))
+}
+
+// GuardarImagenEn valida y guarda una imagen en un directorio bajo UploadRoot
+// El nombre final es {prefijo}_{random}{ext} y la URL retornada cuelga de /uploads
+func GuardarImagenEn(file *multipart.FileHeader, directorio, prefijo string) (string, error) {
+	extension, err := ValidarImagen(file)
+	if err != nil {
 		return "", err
 	}
 
-	if err := os.MkdirAll(UploadDir, 0755); err != nil {
+	rutaURL, err := rutaURLDesdeDirectorio(directorio)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(directorio, 0755); err != nil {
 		return "", fmt.Errorf("error al crear directorio de uploads: %v", err)
 	}
 
-	// Genera nombre único: user_{id}_{random}.ext
-	ext := filepath.Ext(file.Filename)
-	filename := fmt.Sprintf("user_%d_%s%s", userID, generarIDUnico(), ext)
-	filepath := filepath.Join(UploadDir, filename)
+	filename := fmt.Sprintf("%s_%s%s", prefijo, generarIDUnico(), extension)
+	destino := filepath.Join(directorio, filename)
 
 	src, err := file.Open()
 	if err != nil {
@@ -53,27 +88,33 @@ func GuardarImagen(file *multipart.FileHeader, userID uint) (string, error) {
 	}
 	defer src.Close()
 
-	dst, err := os.Create(filepath)
+	dst, err := os.OpenFile(destino, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
 	if err != nil {
 		return "", fmt.Errorf("error al crear archivo: %v", err)
 	}
 	defer dst.Close()
 
-	if _, err := io.Copy(dst, src); err != nil {
+	if _, err := io.Copy(dst, io.LimitReader(src, MaxFileSize)); err != nil {
+		os.Remove(destino)
 		return "", fmt.Errorf("error al guardar archivo: %v", err)
 	}
 
-	return fmt.Sprintf("/uploads/avatars/%s", filename), nil
+	return rutaURL + "/" + filename, nil
 }
 
-// EliminarImagen borra la imagen del servidor
+// EliminarImagen borra del servidor una imagen subida a partir de su URL
+// Solo se eliminan archivos dentro de UploadRoot
 func EliminarImagen(imageURL string) error {
-	if imageURL == "" {
+	if imageURL == "" || !strings.HasPrefix(imageURL, "/uploads/") {
 		return nil
 	}
 
-	filename := filepath.Base(imageURL)
-	filepath := filepath.Join(UploadDir, filename)
+	relativa := filepath.Clean(strings.TrimPrefix(imageURL, "/uploads/"))
+	if relativa == "." || strings.HasPrefix(relativa, "..") || filepath.IsAbs(relativa) {
+		return errors.New("ruta de imagen invalida")
+	}
+
+	filepath := filepath.Join(UploadRoot, relativa)
 
 	if _, err := os.Stat(filepath); os.IsNotExist(err) {
 		return nil
@@ -86,6 +127,15 @@ func EliminarImagen(imageURL string) error {
 	return nil
 }
 
+// rutaURLDesdeDirectorio convierte un directorio bajo UploadRoot en su ruta publica
+func rutaURLDesdeDirectorio(directorio string) (string, error) {
+	relativa, err := filepath.Rel(UploadRoot, directorio)
+	if err != nil || relativa == "." || strings.HasPrefix(relativa, "..") {
+		return "", errors.New("directorio de uploads invalido")
+	}
+	return "/uploads/" + filepath.ToSlash(relativa), nil
+}
+
 // generarIDUnico crea un ID aleatorio de 16 caracteres
 func generarIDUnico() string {
 	b := make([]byte, 16)
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+var reemplazoAcentos = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u", "ç", "c",
+)
+
+// NormalizarTexto convierte a minusculas y elimina acentos para comparaciones
+func NormalizarTexto(texto string) string {
+	return reemplazoAcentos.Replace(strings.ToLower(texto))
+}
+
+// Tokenizar normaliza el texto y lo separa en palabras alfanumericas
+func Tokenizar(texto string) []string {
+	return strings.FieldsFunc(NormalizarTexto(texto), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
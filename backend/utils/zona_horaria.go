@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"log"
+	"sync"
+	"time"
+	_ "time/tzdata" // La imagen de produccion no incluye la base de zonas horarias
+)
+
+// ZonaHorariaPorDefecto se usa cuando una ciudad no tiene zona horaria valida
+const ZonaHorariaPorDefecto = "America/Mexico_City"
+
+var zonasCargadas sync.Map // nombre -> *time.Location
+
+// EsZonaHorariaValida indica si el nombre es una zona IANA conocida, como America/Tijuana
+func EsZonaHorariaValida(nombre string) bool {
+	if nombre == "" {
+		return false
+	}
+	_, err := time.LoadLocation(nombre)
+	return err == nil
+}
+
+// CargarZonaHoraria retorna la zona con ese nombre; si no existe usa la zona por defecto
+func CargarZonaHoraria(nombre string) *time.Location {
+	if nombre == "" {
+		nombre = ZonaHorariaPorDefecto
+	}
+	if zona, ok := zonasCargadas.Load(nombre); ok {
+		return zona.(*time.Location)
+	}
+
+	zona, err := time.LoadLocation(nombre)
+	if err != nil {
+		log.Printf("Zona horaria desconocida %q, se usa %s", nombre, ZonaHorariaPorDefecto)
+		zona, err = time.LoadLocation(ZonaHorariaPorDefecto)
+		if err != nil {
+			zona = time.Local
+		}
+	}
+
+	zonasCargadas.Store(nombre, zona)
+	return zona
+}